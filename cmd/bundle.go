@@ -0,0 +1,704 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gabrie30/ghorg/colorlog"
+	"github.com/gabrie30/ghorg/git"
+	"github.com/gabrie30/ghorg/scm"
+	"github.com/spf13/cobra"
+)
+
+var bundleCmd = &cobra.Command{
+	Use:   "bundle <dir>",
+	Short: "Export the repos of a clone directory into git bundles for offline transfer",
+	Long: `Writes a git bundle of every git repo in a clone directory, along with a manifest.json recording the sha256 checksum, refs, checked out branch and origin remote of each. Copy the output directory to a machine that can't reach the scm and run ghorg unbundle there to recreate the clone directory.
+
+With --incremental only the objects added since the last bundle of the clone directory are exported and repos without new objects get no bundle file. The refs of each export are recorded in _ghorg_bundle_state.json in the clone directory, incremental bundles need every earlier bundle to be imported first.`,
+	Example: `  ghorg bundle my-org
+  ghorg bundle my-org --output=/mnt/transfer/my-org-full
+  ghorg bundle my-org --incremental --output=/mnt/transfer/my-org-week-2`,
+	Args: cobra.ExactArgs(1),
+	Run:  bundleFunc,
+}
+
+var unbundleCmd = &cobra.Command{
+	Use:   "unbundle <bundle dir>",
+	Short: "Import the bundles written by ghorg bundle into a clone directory",
+	Long: `Imports the bundles in a directory written by ghorg bundle into GHORG_ABSOLUTE_PATH_TO_CLONE_TO, recreating the layout of the clone directory they were exported from along with the refs, checked out branch and origin remote of each repo. Every bundle is checked against the checksum in the manifest before it's imported.
+
+Repos that don't exist yet are created from full bundles. Existing repos get the refs of the bundle and their working copy is reset to the checked out branch. Repos with uncommitted changes, or with commits made since they were imported that the bundle would overwrite, are skipped. Incremental bundles must be imported in the order they were created.`,
+	Example: `  ghorg unbundle /mnt/transfer/my-org-full
+  ghorg unbundle /mnt/transfer/my-org-week-2 --output-dir=my-org-mirror`,
+	Args: cobra.ExactArgs(1),
+	Run:  unbundleFunc,
+}
+
+const (
+	// bundleManifestFileName is the manifest written next to the bundles of an export
+	bundleManifestFileName = "manifest.json"
+	// bundleStateFileName records the refs of the last export of a clone directory, incremental exports
+	// leave out the objects they point to
+	bundleStateFileName = "_ghorg_bundle_state.json"
+	// bundleManifestVersion is bumped when the format of the manifest changes in a way older versions can't read
+	bundleManifestVersion = 1
+)
+
+// bundleManifest describes an export of a clone directory
+type bundleManifest struct {
+	Version int `json:"version"`
+	// Source is the name of the clone directory the repos were exported from
+	Source    string    `json:"source"`
+	CreatedAt time.Time `json:"created_at"`
+	// Since is when the export this one is incremental to was created, nil for a full export
+	Since *time.Time           `json:"since,omitempty"`
+	Repos []bundleManifestRepo `json:"repos"`
+}
+
+// bundleManifestRepo is the bundle of a single repo
+type bundleManifestRepo struct {
+	// Path is the directory of the repo relative to the clone directory, always with forward slashes
+	Path string `json:"path"`
+	// Bundle is the bundle file relative to the manifest, it's empty when the repo has no new objects
+	Bundle string `json:"bundle,omitempty"`
+	SHA256 string `json:"sha256,omitempty"`
+	Size   int64  `json:"size_bytes,omitempty"`
+	// Incremental bundles only hold the objects added since the previous export of the repo
+	Incremental bool `json:"incremental"`
+	// Remote is the origin url of the repo without credentials
+	Remote string `json:"remote,omitempty"`
+	// Head is the branch checked out in the repo, it's empty when HEAD is detached
+	Head string `json:"head,omitempty"`
+	// Refs are every ref of the repo, a bundle leaves out refs pointing at objects of an earlier export
+	Refs        map[string]string `json:"refs"`
+	DeletedRefs []string          `json:"deleted_refs,omitempty"`
+	// PreviousRefs are the refs of the export an incremental bundle follows, refs of the imported repo that moved
+	// away from them have commits made after the import
+	PreviousRefs map[string]string `json:"previous_refs,omitempty"`
+	// Metadata is the copy of the --backup-metadata export of the repo relative to the manifest, it's copied
+	// whole into every export
+	Metadata string `json:"metadata,omitempty"`
+}
+
+// bundleResult is what happened to the repos of an export or import
+type bundleResult struct {
+	infos     []string
+	errors    []string
+	unchanged int
+}
+
+func bundleFunc(cmd *cobra.Command, argz []string) {
+	if cmd.Flags().Changed("concurrency") {
+		_ = os.Setenv("GHORG_CONCURRENCY", cmd.Flag("concurrency").Value.String())
+	}
+	incremental, _ := cmd.Flags().GetBool("incremental")
+	output, _ := cmd.Flags().GetString("output")
+
+	dir, err := resolveCloneDir(argz[0])
+	if err != nil {
+		colorlog.PrintErrorAndExit(fmt.Sprintf("Could not find the clone directory %s: %v", argz[0], err))
+	}
+	dir = filepath.Clean(dir)
+
+	if output == "" {
+		output = fmt.Sprintf("%s-bundle-%s", filepath.Base(dir), time.Now().Format("20060102-150405"))
+	}
+	if output, err = filepath.Abs(output); err != nil {
+		colorlog.PrintErrorAndExit(fmt.Sprintf("Invalid --output: %v", err))
+	}
+	if _, err := os.Stat(filepath.Join(output, bundleManifestFileName)); err == nil {
+		colorlog.PrintErrorAndExit(fmt.Sprintf("%s already has an export in it, choose another --output", output))
+	}
+
+	var previous *bundleManifest
+	if incremental {
+		if previous, err = readBundleManifest(filepath.Join(dir, bundleStateFileName)); err != nil {
+			colorlog.PrintErrorAndExit(fmt.Sprintf("Could not read the refs of the last export, run a full ghorg bundle first: %v", err))
+		}
+	}
+
+	// getRelativePathRepositories finds the repos relative to outputDirAbsolutePath
+	outputDirAbsolutePath = dir
+	repoPaths, err := getRelativePathRepositories(dir)
+	if err != nil {
+		colorlog.PrintErrorAndExit(fmt.Sprintf("Could not find the repos in %s: %v", dir, err))
+	}
+	if len(repoPaths) == 0 {
+		colorlog.PrintError("No clones found. Please clone some and try again.")
+		return
+	}
+
+	if err := os.MkdirAll(output, 0755); err != nil {
+		colorlog.PrintErrorAndExit(fmt.Sprintf("Could not create %s: %v", output, err))
+	}
+
+	start := time.Now()
+	colorlog.PrintInfo(fmt.Sprintf("Bundling %d repos in %s into %s", len(repoPaths), dir, output))
+	manifest, state, result := bundleRepos(git.NewGit(), dir, repoPaths, output, previous)
+
+	if err := writeBundleManifest(filepath.Join(output, bundleManifestFileName), manifest); err != nil {
+		colorlog.PrintErrorAndExit(fmt.Sprintf("Could not write the manifest: %v", err))
+	}
+	// the index tells ghorg ls and later clones where each repo came from, it's carried over to the other side
+	if err := copyFile(filepath.Join(dir, repoIndexFileName), filepath.Join(output, repoIndexFileName)); err != nil && !os.IsNotExist(err) {
+		result.infos = append(result.infos, fmt.Sprintf("Could not copy the repo index: %v", err))
+	}
+	if err := writeBundleManifest(filepath.Join(dir, bundleStateFileName), state); err != nil {
+		result.errors = append(result.errors, fmt.Sprintf("Could not record the refs of this export, the next --incremental export would be wrong: %v", err))
+	}
+
+	cloneInfos = result.infos
+	cloneErrors = result.errors
+	printRemainingMessages()
+	colorlog.PrintSuccess(fmt.Sprintf("\nFinished bundling %d repos into %s, %d had no new objects%s", len(manifest.Repos), output, result.unchanged, formatDurationText(int(time.Since(start).Seconds()+0.5))))
+
+	exitWithCloneStatus(len(cloneInfos), len(cloneErrors))
+}
+
+// bundleRepos bundles every repo into output, GHORG_CONCURRENCY repos are bundled at once. With a previous export
+// the bundles leave out the objects it had. It returns the manifest of the export and the state the next
+// incremental export starts from, which keeps the previous refs of repos that failed to bundle.
+func bundleRepos(bundler git.Bundler, dir string, repoPaths []string, output string, previous *bundleManifest) (bundleManifest, bundleManifest, bundleResult) {
+	manifest := bundleManifest{Version: bundleManifestVersion, Source: filepath.Base(dir), CreatedAt: time.Now().UTC(), Repos: []bundleManifestRepo{}}
+	previousRepos := map[string]bundleManifestRepo{}
+	if previous != nil {
+		manifest.Since = &previous.CreatedAt
+		for _, repo := range previous.Repos {
+			previousRepos[repo.Path] = repo
+		}
+	}
+	state := manifest
+	state.Since = nil
+	state.Repos = []bundleManifestRepo{}
+
+	sem := make(chan struct{}, lsConcurrency())
+	result := bundleResult{}
+	var mu sync.Mutex
+
+	var wg sync.WaitGroup
+	for _, rel := range repoPaths {
+		wg.Add(1)
+		go func(rel string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			path := filepath.ToSlash(rel)
+			base, hasBase := previousRepos[path]
+			var entry bundleManifestRepo
+			var err error
+			if _, hgErr := os.Stat(filepath.Join(dir, rel, ".hg")); hgErr == nil {
+				err = errors.New("only git repos can be bundled")
+			} else if hasBase {
+				entry, err = bundleRepo(bundler, dir, path, output, &base)
+			} else {
+				entry, err = bundleRepo(bundler, dir, path, output, nil)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				result.errors = append(result.errors, fmt.Sprintf("Could not bundle repo: %s, error: %v", path, err))
+				if hasBase {
+					base.Bundle, base.SHA256, base.Size = "", "", 0
+					state.Repos = append(state.Repos, base)
+				}
+				return
+			}
+			if entry.Bundle == "" {
+				result.unchanged++
+			}
+			manifest.Repos = append(manifest.Repos, entry)
+			// the next export only needs the refs of this one
+			entry.PreviousRefs = nil
+			state.Repos = append(state.Repos, entry)
+		}(rel)
+	}
+	wg.Wait()
+
+	sortBundleRepos(manifest.Repos)
+	sortBundleRepos(state.Repos)
+	slices.Sort(result.errors)
+	return manifest, state, result
+}
+
+// bundleRepo writes the bundle of the repo at path within dir to the same path within output. With base, the
+// previous export of the repo, objects it had are left out and refs it had that are gone are recorded as deleted.
+func bundleRepo(bundler git.Bundler, dir string, path string, output string, base *bundleManifestRepo) (bundleManifestRepo, error) {
+	repo := scm.Repo{Name: filepath.Base(path), HostPath: filepath.Join(dir, filepath.FromSlash(path))}
+	if bundler.IsShallowClone(repo) {
+		return bundleManifestRepo{}, errors.New("shallow clones don't have the history a bundle needs")
+	}
+
+	refs, err := bundler.Refs(repo)
+	if err != nil {
+		return bundleManifestRepo{}, err
+	}
+	head, err := bundler.HeadBranch(repo)
+	if err != nil {
+		return bundleManifestRepo{}, err
+	}
+	entry := bundleManifestRepo{Path: path, Head: head, Refs: refs}
+	// repos cloned without a remote or with it removed have no origin to record
+	if remote, err := bundler.GetRemoteURL(repo); err == nil {
+		entry.Remote = redactURL(remote)
+	}
+	if _, err := os.Stat(metadataDirPath(repo.HostPath)); err == nil {
+		entry.Metadata = path + metadataDirSuffix
+		if err := copyDir(metadataDirPath(repo.HostPath), filepath.Join(output, filepath.FromSlash(entry.Metadata))); err != nil {
+			return bundleManifestRepo{}, fmt.Errorf("could not copy the metadata export: %w", err)
+		}
+	}
+
+	exclude := []string{}
+	if base != nil {
+		entry.Incremental = true
+		entry.PreviousRefs = base.Refs
+		for ref, id := range base.Refs {
+			if _, ok := refs[ref]; !ok {
+				entry.DeletedRefs = append(entry.DeletedRefs, ref)
+			}
+			// objects that are gone, e.g. after a force push and gc, can't be left out of the bundle
+			if !slices.Contains(exclude, id) && bundler.HasObject(repo, id) {
+				exclude = append(exclude, id)
+			}
+		}
+		slices.Sort(entry.DeletedRefs)
+		slices.Sort(exclude)
+	}
+
+	if len(refs) == 0 {
+		return entry, nil
+	}
+	count, err := bundler.CountBundleObjects(repo, exclude)
+	if err != nil || count == 0 {
+		return entry, err
+	}
+
+	entry.Bundle = path + ".bundle"
+	bundlePath := filepath.Join(output, filepath.FromSlash(entry.Bundle))
+	if err := os.MkdirAll(filepath.Dir(bundlePath), 0755); err != nil {
+		return bundleManifestRepo{}, err
+	}
+	if err := bundler.CreateBundle(repo, bundlePath, exclude); err != nil {
+		return bundleManifestRepo{}, err
+	}
+	if entry.SHA256, entry.Size, err = fileSHA256(bundlePath); err != nil {
+		return bundleManifestRepo{}, err
+	}
+	return entry, nil
+}
+
+func unbundleFunc(cmd *cobra.Command, argz []string) {
+	if cmd.Flags().Changed("concurrency") {
+		_ = os.Setenv("GHORG_CONCURRENCY", cmd.Flag("concurrency").Value.String())
+	}
+
+	bundleDir, err := filepath.Abs(argz[0])
+	if err != nil {
+		colorlog.PrintErrorAndExit(fmt.Sprintf("Invalid bundle directory %s: %v", argz[0], err))
+	}
+	manifest, err := readBundleManifest(filepath.Join(bundleDir, bundleManifestFileName))
+	if err != nil {
+		colorlog.PrintErrorAndExit(fmt.Sprintf("Could not read the manifest of %s: %v", bundleDir, err))
+	}
+
+	name := manifest.Source
+	if cmd.Flags().Changed("output-dir") {
+		name = cmd.Flag("output-dir").Value.String()
+	}
+	if name == "" || filepath.Base(name) != name || name == ".." {
+		colorlog.PrintErrorAndExit(fmt.Sprintf("Invalid clone directory name %q", name))
+	}
+	target := filepath.Join(os.Getenv("GHORG_ABSOLUTE_PATH_TO_CLONE_TO"), name)
+
+	start := time.Now()
+	colorlog.PrintInfo(fmt.Sprintf("Importing %d repos from %s into %s", len(manifest.Repos), bundleDir, target))
+	result := unbundleRepos(git.NewGit(), bundleDir, manifest, target)
+	imported := len(manifest.Repos) - len(result.errors) - len(result.infos) - result.unchanged
+
+	if err := mergeBundleIndex(bundleDir, target); err != nil {
+		result.infos = append(result.infos, fmt.Sprintf("Could not update the repo index: %v", err))
+	}
+
+	cloneInfos = result.infos
+	cloneErrors = result.errors
+	printRemainingMessages()
+	colorlog.PrintSuccess(fmt.Sprintf("\nFinished importing %d repos into %s, %d were already up to date%s", imported, target, result.unchanged, formatDurationText(int(time.Since(start).Seconds()+0.5))))
+
+	exitWithCloneStatus(len(cloneInfos), len(cloneErrors))
+}
+
+// mergeBundleIndex adds the index entries of a bundle to the index of target, so repos that were already
+// cloned into target keep their entries
+func mergeBundleIndex(bundleDir string, target string) error {
+	bundled, err := loadRepoIndex(bundleDir)
+	if err != nil {
+		return err
+	}
+	entries := bundled.list()
+	if len(entries) == 0 {
+		return nil
+	}
+
+	index, err := loadRepoIndex(target)
+	if err != nil {
+		return err
+	}
+	index.merge(entries)
+	return index.save()
+}
+
+// unbundleRepos imports every repo of the manifest into target, GHORG_CONCURRENCY repos are imported at once
+func unbundleRepos(bundler git.Bundler, bundleDir string, manifest *bundleManifest, target string) bundleResult {
+	sem := make(chan struct{}, lsConcurrency())
+	result := bundleResult{}
+	var mu sync.Mutex
+
+	var wg sync.WaitGroup
+	for _, entry := range manifest.Repos {
+		wg.Add(1)
+		go func(entry bundleManifestRepo) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			imported, err := unbundleRepo(bundler, bundleDir, entry, target)
+			if err == nil {
+				err = unbundleMetadata(bundleDir, entry, target)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case errors.Is(err, errBundleLocalChanges):
+				result.infos = append(result.infos, fmt.Sprintf("Skipped repo with uncommitted changes: %s", entry.Path))
+			case errors.Is(err, errBundleDiverged):
+				result.infos = append(result.infos, fmt.Sprintf("Skipped repo: %s, it has %v", entry.Path, err))
+			case err != nil:
+				result.errors = append(result.errors, fmt.Sprintf("Could not import repo: %s, error: %v", entry.Path, err))
+			case !imported:
+				result.unchanged++
+			}
+		}(entry)
+	}
+	wg.Wait()
+
+	slices.Sort(result.infos)
+	slices.Sort(result.errors)
+	return result
+}
+
+// errBundleLocalChanges is returned for existing repos that are not imported into because of uncommitted changes
+var errBundleLocalChanges = errors.New("the repo has uncommitted changes")
+
+// errBundleDiverged is returned for existing repos that are not imported into because they have commits the
+// import would drop from their refs
+var errBundleDiverged = errors.New("local commits the bundle would overwrite")
+
+// unbundleRepo imports the bundle of a repo into the same path within target, creating the repo when it doesn't
+// exist. It returns false when the repo already had the refs of the bundle.
+func unbundleRepo(bundler git.Bundler, bundleDir string, entry bundleManifestRepo, target string) (bool, error) {
+	path, err := bundleRelPath(entry.Path)
+	if err != nil {
+		return false, err
+	}
+	repo := scm.Repo{Name: filepath.Base(path), HostPath: filepath.Join(target, path)}
+
+	bundlePath := ""
+	if entry.Bundle != "" {
+		rel, err := bundleRelPath(entry.Bundle)
+		if err != nil {
+			return false, err
+		}
+		bundlePath = filepath.Join(bundleDir, rel)
+		sum, _, err := fileSHA256(bundlePath)
+		if err != nil {
+			return false, err
+		}
+		if sum != entry.SHA256 {
+			return false, fmt.Errorf("the checksum of %s doesn't match the manifest, it's incomplete or was modified", entry.Bundle)
+		}
+	}
+
+	exists := isGitRepository(repo.HostPath)
+	current := map[string]string{}
+	if exists {
+		if current, err = bundler.Refs(repo); err != nil {
+			return false, err
+		}
+		if bundleRefsApplied(current, entry) {
+			return false, nil
+		}
+	}
+	if err := validateBundleRefs(bundler, current, entry); err != nil {
+		return false, err
+	}
+
+	if exists {
+		status, err := bundler.ShortStatus(repo)
+		if err != nil {
+			return false, err
+		}
+		if status != "" {
+			return false, errBundleLocalChanges
+		}
+	} else {
+		if entry.Incremental {
+			return false, errors.New("the repo doesn't exist, import the earlier bundles of it first")
+		}
+		if files, err := os.ReadDir(repo.HostPath); err == nil && len(files) > 0 {
+			return false, fmt.Errorf("%s already exists and is not a git repo", repo.HostPath)
+		}
+		if err := os.MkdirAll(filepath.Dir(repo.HostPath), 0755); err != nil {
+			return false, err
+		}
+		if err := bundler.InitBundleRepo(repo, entry.Remote); err != nil {
+			return false, err
+		}
+	}
+
+	// storing the objects changes no ref, so it's done before checking whether the refs can be updated
+	if bundlePath != "" {
+		if err := bundler.UnbundleObjects(repo, bundlePath); err != nil {
+			return false, err
+		}
+	}
+	old, deleted, err := bundleRefUpdates(bundler, repo, current, entry)
+	if err != nil {
+		return false, err
+	}
+	if err := bundler.UpdateRefs(repo, entry.Refs, old, deleted); err != nil {
+		return false, err
+	}
+	if !exists && entry.Head != "" {
+		if err := bundler.SetHead(repo, entry.Head); err != nil {
+			return false, err
+		}
+	}
+	// a new repo exported with a detached HEAD has nothing checked out
+	if len(entry.Refs) > 0 && (exists || entry.Head != "") {
+		if err := bundler.ResetWorktree(repo); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// unbundleMetadata copies the metadata export of a bundled repo next to the imported repo
+func unbundleMetadata(bundleDir string, entry bundleManifestRepo, target string) error {
+	if entry.Metadata == "" {
+		return nil
+	}
+	rel, err := bundleRelPath(entry.Metadata)
+	if err != nil {
+		return err
+	}
+	path, err := bundleRelPath(entry.Path)
+	if err != nil {
+		return err
+	}
+	if err := copyDir(filepath.Join(bundleDir, rel), metadataDirPath(filepath.Join(target, path))); err != nil {
+		return fmt.Errorf("could not copy the metadata export: %w", err)
+	}
+	return nil
+}
+
+// bundleRefUpdates returns the ids the refs an import moves or deletes point to now, update-ref only changes them
+// while they still do, and the deleted refs the repo still has. A ref that moved since the previous export of the
+// repo, or that the bundle knows nothing about, may only move to ids keeping every commit it points to, otherwise
+// commits made in the repo since it was imported would be lost and errBundleDiverged is returned.
+func bundleRefUpdates(bundler git.Bundler, repo scm.Repo, current map[string]string, entry bundleManifestRepo) (map[string]string, []string, error) {
+	old := map[string]string{}
+	for ref, id := range entry.Refs {
+		if currentID, ok := current[ref]; ok && currentID != id {
+			old[ref] = currentID
+		}
+	}
+	deleted := []string{}
+	for _, ref := range entry.DeletedRefs {
+		if currentID, ok := current[ref]; ok {
+			old[ref] = currentID
+			deleted = append(deleted, ref)
+		}
+	}
+
+	targets := []string{}
+	for _, id := range entry.Refs {
+		if !slices.Contains(targets, id) {
+			targets = append(targets, id)
+		}
+	}
+
+	diverged := []string{}
+	for ref, currentID := range old {
+		if previousID, ok := entry.PreviousRefs[ref]; ok && previousID == currentID {
+			continue
+		}
+		count, err := bundler.CountCommitsNotIn(repo, []string{currentID}, targets)
+		if err != nil {
+			return nil, nil, err
+		}
+		if count > 0 {
+			diverged = append(diverged, ref)
+		}
+	}
+	if len(diverged) > 0 {
+		slices.Sort(diverged)
+		return nil, nil, fmt.Errorf("%w on %s", errBundleDiverged, strings.Join(diverged, ", "))
+	}
+	return old, deleted, nil
+}
+
+// bundleObjectID matches sha1 and sha256 object ids
+var bundleObjectID = regexp.MustCompile(`^[0-9a-f]{40}([0-9a-f]{24})?$`)
+
+// validateBundleRefs checks the ref names and ids of a manifest before they are passed to git, manifests are read
+// from other machines. Refs the repo already has are known to be valid.
+func validateBundleRefs(bundler git.Bundler, current map[string]string, entry bundleManifestRepo) error {
+	validRef := func(ref string) bool {
+		if _, ok := current[ref]; ok {
+			return true
+		}
+		return strings.HasPrefix(ref, "refs/") && bundler.IsValidRefName(ref)
+	}
+
+	for ref, id := range entry.Refs {
+		if !validRef(ref) {
+			return fmt.Errorf("invalid ref in the manifest: %q", ref)
+		}
+		if !bundleObjectID.MatchString(id) {
+			return fmt.Errorf("invalid object id in the manifest for %s: %q", ref, id)
+		}
+	}
+	for _, ref := range entry.DeletedRefs {
+		if !validRef(ref) {
+			return fmt.Errorf("invalid ref in the manifest: %q", ref)
+		}
+	}
+	if entry.Head != "" && !validRef("refs/heads/"+entry.Head) {
+		return fmt.Errorf("invalid head branch in the manifest: %q", entry.Head)
+	}
+	return nil
+}
+
+// bundleRefsApplied reports whether a repo already has the refs of a bundle and none of its deleted refs
+func bundleRefsApplied(current map[string]string, entry bundleManifestRepo) bool {
+	for ref, id := range entry.Refs {
+		if current[ref] != id {
+			return false
+		}
+	}
+	for _, ref := range entry.DeletedRefs {
+		if _, ok := current[ref]; ok {
+			return false
+		}
+	}
+	return true
+}
+
+// bundleRelPath converts a path from a manifest to a local path, manifests are read from other machines so paths
+// leaving the directory they are relative to are rejected
+func bundleRelPath(path string) (string, error) {
+	cleaned := filepath.Clean(filepath.FromSlash(path))
+	if path == "" || filepath.IsAbs(cleaned) || cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid path in the manifest: %q", path)
+	}
+	return cleaned, nil
+}
+
+func sortBundleRepos(repos []bundleManifestRepo) {
+	slices.SortFunc(repos, func(a, b bundleManifestRepo) int { return strings.Compare(a.Path, b.Path) })
+}
+
+func readBundleManifest(path string) (*bundleManifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &bundleManifest{}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, err
+	}
+	if manifest.Version > bundleManifestVersion {
+		return nil, fmt.Errorf("%s was written by a newer version of ghorg", path)
+	}
+	return manifest, nil
+}
+
+func writeBundleManifest(path string, manifest bundleManifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// fileSHA256 returns the hex encoded sha256 checksum and the size of a file
+func fileSHA256(path string) (string, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer func() { _ = file.Close() }()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), size, nil
+}
+
+func copyFile(src string, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// copyDir copies the files in src into dst, creating directories on the way and overwriting the files dst already has
+func copyDir(src string, dst string) error {
+	return filepath.WalkDir(src, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if entry.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		return copyFile(path, target)
+	})
+}
@@ -68,7 +68,7 @@ func syncBoolFlagToEnv(cmd *cobra.Command, flagName, envVar string) {
 }
 
 var cloneCmd = &cobra.Command{
-	Use:   "clone [org/user]",
+	Use:   "clone [org/user]...",
 	Short: "Clone user or org repos from GitHub, GitLab, Gitea or Bitbucket",
 	Long: `Clone user or org repos from GitHub, GitLab, Gitea or Bitbucket.
 
@@ -94,6 +94,12 @@ COMMON USAGE:
   # Clone a user's repos instead of an org
   $ ghorg clone username --clone-type=user --token=YOUR_TOKEN
 
+  # Clone multiple orgs and users in one run, each into its own directory
+  $ ghorg clone org-a org-b user:username --token=YOUR_TOKEN
+
+  # Clone every org your token is a member of, each into its own directory
+  $ ghorg clone --clone-type=memberships --token=YOUR_TOKEN
+
   # Clone with SSH protocol instead of HTTPS
   $ ghorg clone my-org --protocol=ssh --token=YOUR_TOKEN
 
@@ -216,6 +222,38 @@ func cloneFunc(cmd *cobra.Command, argz []string) {
 		_ = os.Setenv("GHORG_TOPICS", topics)
 	}
 
+	if cmd.Flags().Changed("pushed-since") {
+		_ = os.Setenv("GHORG_PUSHED_SINCE", cmd.Flag("pushed-since").Value.String())
+	}
+
+	if cmd.Flags().Changed("pushed-before") {
+		_ = os.Setenv("GHORG_PUSHED_BEFORE", cmd.Flag("pushed-before").Value.String())
+	}
+
+	if cmd.Flags().Changed("filter-language") {
+		_ = os.Setenv("GHORG_FILTER_LANGUAGE", cmd.Flag("filter-language").Value.String())
+	}
+
+	if cmd.Flags().Changed("filter-language-threshold") {
+		_ = os.Setenv("GHORG_FILTER_LANGUAGE_THRESHOLD", cmd.Flag("filter-language-threshold").Value.String())
+	}
+
+	if cmd.Flags().Changed("min-repo-size") {
+		_ = os.Setenv("GHORG_MIN_REPO_SIZE", cmd.Flag("min-repo-size").Value.String())
+	}
+
+	if cmd.Flags().Changed("max-repo-size") {
+		_ = os.Setenv("GHORG_MAX_REPO_SIZE", cmd.Flag("max-repo-size").Value.String())
+	}
+
+	if cmd.Flags().Changed("visibility") {
+		_ = os.Setenv("GHORG_VISIBILITY", cmd.Flag("visibility").Value.String())
+	}
+
+	if cmd.Flags().Changed("filter") {
+		_ = os.Setenv("GHORG_FILTER", cmd.Flag("filter").Value.String())
+	}
+
 	if cmd.Flags().Changed("match-prefix") {
 		prefix := cmd.Flag("match-prefix").Value.String()
 		_ = os.Setenv("GHORG_MATCH_PREFIX", prefix)
@@ -256,11 +294,58 @@ func cloneFunc(cmd *cobra.Command, argz []string) {
 		_ = os.Setenv("GHORG_ONLY_PATH", path)
 	}
 
+	if cmd.Flags().Changed("filter-file-syntax") {
+		_ = os.Setenv("GHORG_FILTER_FILE_SYNTAX", cmd.Flag("filter-file-syntax").Value.String())
+	}
+
 	if cmd.Flags().Changed("target-repos-path") {
 		path := cmd.Flag("target-repos-path").Value.String()
 		_ = os.Setenv("GHORG_TARGET_REPOS_PATH", path)
 	}
 
+	if cmd.Flags().Changed("gist-visibility") {
+		_ = os.Setenv("GHORG_GIST_VISIBILITY", cmd.Flag("gist-visibility").Value.String())
+	}
+
+	if cmd.Flags().Changed("gist-description-regex") {
+		_ = os.Setenv("GHORG_GIST_DESCRIPTION_REGEX", cmd.Flag("gist-description-regex").Value.String())
+	}
+
+	if cmd.Flags().Changed("gist-file-extensions") {
+		_ = os.Setenv("GHORG_GIST_FILE_EXTENSIONS", cmd.Flag("gist-file-extensions").Value.String())
+	}
+
+	if cmd.Flags().Changed("gist-updated-since") {
+		_ = os.Setenv("GHORG_GIST_UPDATED_SINCE", cmd.Flag("gist-updated-since").Value.String())
+	}
+
+	if cmd.Flags().Changed("gist-folder-name") {
+		_ = os.Setenv("GHORG_GIST_FOLDER_NAME", cmd.Flag("gist-folder-name").Value.String())
+	}
+
+	if cmd.Flags().Changed("sourcehut-hg-base-url") {
+		_ = os.Setenv("GHORG_SOURCEHUT_HG_BASE_URL", cmd.Flag("sourcehut-hg-base-url").Value.String())
+	}
+
+	if cmd.Flags().Changed("sparse-paths") {
+		_ = os.Setenv("GHORG_SPARSE_PATHS", cmd.Flag("sparse-paths").Value.String())
+	}
+
+	if cmd.Flags().Changed("sparse-paths-file") {
+		_ = os.Setenv("GHORG_SPARSE_PATHS_FILE", cmd.Flag("sparse-paths-file").Value.String())
+	}
+
+	syncBoolFlagToEnv(cmd, "reference-store", "GHORG_REFERENCE_STORE")
+	syncBoolFlagToEnv(cmd, "reference-store-dissociate", "GHORG_REFERENCE_STORE_DISSOCIATE")
+
+	if cmd.Flags().Changed("reference-store-path") {
+		_ = os.Setenv("GHORG_REFERENCE_STORE_PATH", cmd.Flag("reference-store-path").Value.String())
+	}
+
+	if cmd.Flags().Changed("worktree-branches") {
+		_ = os.Setenv("GHORG_WORKTREE_BRANCHES", cmd.Flag("worktree-branches").Value.String())
+	}
+
 	if cmd.Flags().Changed("git-filter") {
 		filter := cmd.Flag("git-filter").Value.String()
 		_ = os.Setenv("GHORG_GIT_FILTER", filter)
@@ -277,6 +362,9 @@ func cloneFunc(cmd *cobra.Command, argz []string) {
 	syncBoolFlagToEnv(cmd, "fetch-all", "GHORG_FETCH_ALL")
 	syncBoolFlagToEnv(cmd, "fetch-git-lfs", "GHORG_FETCH_GIT_LFS")
 	syncBoolFlagToEnv(cmd, "fetch-prune", "GHORG_FETCH_PRUNE")
+	syncBoolFlagToEnv(cmd, "fetch-review-refs", "GHORG_FETCH_REVIEW_REFS")
+	syncBoolFlagToEnv(cmd, "review-refs-include-closed", "GHORG_REVIEW_REFS_INCLUDE_CLOSED")
+	syncBoolFlagToEnv(cmd, "backup-metadata", "GHORG_BACKUP_METADATA")
 	syncBoolFlagToEnv(cmd, "include-submodules", "GHORG_INCLUDE_SUBMODULES")
 	syncBoolFlagToEnv(cmd, "dry-run", "GHORG_DRY_RUN")
 	syncBoolFlagToEnv(cmd, "clone-wiki", "GHORG_CLONE_WIKI")
@@ -288,6 +376,7 @@ func cloneFunc(cmd *cobra.Command, argz []string) {
 	syncBoolFlagToEnv(cmd, "insecure-codeberg-client", "GHORG_INSECURE_CODEBERG_CLIENT")
 	syncBoolFlagToEnv(cmd, "insecure-bitbucket-client", "GHORG_INSECURE_BITBUCKET_CLIENT")
 	syncBoolFlagToEnv(cmd, "insecure-sourcehut-client", "GHORG_INSECURE_SOURCEHUT_CLIENT")
+	syncBoolFlagToEnv(cmd, "sourcehut-hg", "GHORG_SOURCEHUT_HG")
 	syncBoolFlagToEnv(cmd, "skip-forks", "GHORG_SKIP_FORKS")
 	syncBoolFlagToEnv(cmd, "quiet", "GHORG_QUIET")
 	syncBoolFlagToEnv(cmd, "no-token", "GHORG_NO_TOKEN")
@@ -305,8 +394,26 @@ func cloneFunc(cmd *cobra.Command, argz []string) {
 		_ = os.Setenv("GHORG_SSH_HOSTNAME", cmd.Flag("ssh-hostname").Value.String())
 	}
 
-	if len(argz) < 1 {
-		if os.Getenv("GHORG_SCM_TYPE") == "github" && os.Getenv("GHORG_CLONE_TYPE") == "user" {
+	if cmd.Flags().Changed("output") {
+		_ = os.Setenv("GHORG_OUTPUT", cmd.Flag("output").Value.String())
+	}
+
+	switch os.Getenv("GHORG_OUTPUT") {
+	case "text":
+	case "json":
+		if os.Getenv("GHORG_DRY_RUN") != "true" {
+			colorlog.PrintErrorAndExit("GHORG_OUTPUT=json is only supported with --dry-run")
+		}
+		// the plan is the only thing written to stdout so it can be piped into jq
+		dryRunPlanOutput = sendLogsToStderr()
+	default:
+		colorlog.PrintErrorAndExit("GHORG_OUTPUT must be text or json, got: " + os.Getenv("GHORG_OUTPUT"))
+	}
+
+	// memberships discovers its own targets so does not take an argument
+	if len(argz) < 1 && os.Getenv("GHORG_CLONE_TYPE") != "memberships" {
+		// starred and watched default to the authenticated user
+		if (os.Getenv("GHORG_SCM_TYPE") == "github" && os.Getenv("GHORG_CLONE_TYPE") == "user") || isOwnerOrganizedCloneType(os.Getenv("GHORG_CLONE_TYPE")) {
 			argz = append(argz, "")
 		} else {
 			colorlog.PrintError("You must provide an org or user to clone")
@@ -364,20 +471,124 @@ func cloneFunc(cmd *cobra.Command, argz []string) {
 		os.Exit(1)
 	}
 
+	if threshold := os.Getenv("GHORG_FILTER_LANGUAGE_THRESHOLD"); threshold != "" {
+		if pct, err := strconv.ParseFloat(threshold, 64); err != nil || pct <= 0 || pct > 100 {
+			colorlog.PrintErrorAndExit("GHORG_FILTER_LANGUAGE_THRESHOLD must be a percentage between 0 and 100, got: " + threshold)
+		}
+	}
+
+	// parse the filter before any api calls so a typo doesn't fail after listing every repo
+	if expr := os.Getenv("GHORG_FILTER"); expr != "" {
+		if _, err := parseRepoFilter(expr, time.Now()); err != nil {
+			colorlog.PrintErrorAndExit(fmt.Sprintf("Invalid GHORG_FILTER: %v", err))
+		}
+	}
+
+	for _, env := range []string{"GHORG_PUSHED_SINCE", "GHORG_PUSHED_BEFORE"} {
+		if value := os.Getenv(env); value != "" {
+			if _, err := parseActivityTime(value, time.Now()); err != nil {
+				colorlog.PrintErrorAndExit(fmt.Sprintf("Invalid %s: %v", env, err))
+			}
+		}
+	}
+
+	for _, env := range []string{"GHORG_MIN_REPO_SIZE", "GHORG_MAX_REPO_SIZE"} {
+		if value := os.Getenv(env); value != "" {
+			if _, err := parseRepoSize(value); err != nil {
+				colorlog.PrintErrorAndExit(fmt.Sprintf("Invalid %s: %v", env, err))
+			}
+		}
+	}
+
+	// Gitea and Forgejo have no snippets or gists, so there is nothing for --clone-snippets to list
+	if scmType := os.Getenv("GHORG_SCM_TYPE"); (scmType == "gitea" || scmType == "codeberg") && os.Getenv("GHORG_CLONE_SNIPPETS") == "true" {
+		colorlog.PrintInfo("Gitea and Codeberg do not support snippets, --clone-snippets will be ignored")
+	}
+
+	if branches := os.Getenv("GHORG_WORKTREE_BRANCHES"); branches != "" {
+		if os.Getenv("GHORG_BACKUP") == "true" {
+			colorlog.PrintErrorAndExit("GHORG_WORKTREE_BRANCHES cannot be used with GHORG_BACKUP, backups are bare clones without a working copy")
+		}
+		if _, err := parseWorktreeBranches(branches); err != nil {
+			colorlog.PrintErrorAndExit(fmt.Sprintf("Invalid GHORG_WORKTREE_BRANCHES: %v", err))
+		}
+	}
+
+	if os.Getenv("GHORG_SPARSE_PATHS") != "" || os.Getenv("GHORG_SPARSE_PATHS_FILE") != "" {
+		if os.Getenv("GHORG_BACKUP") == "true" {
+			colorlog.PrintErrorAndExit("GHORG_SPARSE_PATHS and GHORG_SPARSE_PATHS_FILE cannot be used with GHORG_BACKUP, backups are bare clones without a working copy")
+		}
+		if _, err := loadRepoSparsePaths(); err != nil {
+			colorlog.PrintErrorAndExit(fmt.Sprintf("Invalid sparse paths: %v", err))
+		}
+	}
+
+	if os.Getenv("GHORG_REFERENCE_STORE") == "true" {
+		if os.Getenv("GHORG_BACKUP") == "true" && os.Getenv("GHORG_REFERENCE_STORE_DISSOCIATE") != "true" {
+			colorlog.PrintErrorAndExit("GHORG_REFERENCE_STORE cannot be used with GHORG_BACKUP without GHORG_REFERENCE_STORE_DISSOCIATE, a backup must not depend on objects kept outside of it")
+		}
+		if os.Getenv("GHORG_CLONE_DEPTH") != "" || os.Getenv("GHORG_GIT_FILTER") != "" {
+			colorlog.PrintErrorAndExit("GHORG_REFERENCE_STORE cannot be used with GHORG_CLONE_DEPTH or GHORG_GIT_FILTER, the reference store keeps the full history of every repo")
+		}
+	}
+
 	if os.Getenv("GHORG_PRESERVE_SCM_HOSTNAME") == "true" {
 		updateAbsolutePathToCloneToWithHostname()
 	}
 
-	setOutputDirName(argz)
-	setOuputDirAbsolutePath()
-	targetCloneSource = argz[0]
-
 	// Auto-adjust concurrency for clone delay before setup (silently)
 	if _, _, shouldAdjust := shouldAutoAdjustConcurrency(); shouldAdjust {
 		_ = os.Setenv("GHORG_CONCURRENCY", "1")
 		_ = os.Setenv("GHORG_CONCURRENCY_AUTO_ADJUSTED", "true")
 	}
 
+	if os.Getenv("GHORG_CLONE_TYPE") == "memberships" {
+		if len(argz) > 0 {
+			colorlog.PrintErrorAndExit("--clone-type=memberships clones every org the token is a member of and does not take an org or user argument")
+		}
+		if os.Getenv("GHORG_GITHUB_USER_GISTS") == "true" {
+			colorlog.PrintErrorAndExit("GHORG_GITHUB_USER_GISTS is only supported for user clones, please set --clone-type=user")
+		}
+
+		targets, err := getMembershipCloneTargets()
+		if err != nil {
+			colorlog.PrintError("Encountered an error fetching org memberships, aborting")
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		if len(targets) == 0 {
+			colorlog.PrintInfo("No org memberships found for " + os.Getenv("GHORG_SCM_TYPE") + ", please verify your token has sufficient permissions and try again.")
+			os.Exit(0)
+		}
+
+		targetCloneSource = cloneTargetNames(targets)
+		setupMultiTargetClone(targets)
+		return
+	}
+
+	if len(argz) > 1 {
+		if os.Getenv("GHORG_OUTPUT_DIR") != "" {
+			colorlog.PrintErrorAndExit("GHORG_OUTPUT_DIR cannot be used when cloning multiple orgs or users, each target is cloned into its own directory")
+		}
+		if os.Getenv("GHORG_GITHUB_USER_GISTS") == "true" {
+			colorlog.PrintErrorAndExit("GHORG_GITHUB_USER_GISTS only supports cloning a single user")
+		}
+
+		targets, err := parseCloneTargets(argz)
+		if err != nil {
+			colorlog.PrintErrorAndExit(err.Error())
+		}
+
+		targetCloneSource = cloneTargetNames(targets)
+		setupMultiTargetClone(targets)
+		return
+	}
+
+	setOutputDirName(argz)
+	setOuputDirAbsolutePath()
+	targetCloneSource = argz[0]
+
 	setupRepoClone()
 }
 
@@ -421,11 +632,10 @@ func setupRepoClone() {
 	var cloneTargets []scm.Repo
 	var err error
 
-	if os.Getenv("GHORG_CLONE_TYPE") == "org" {
-		cloneTargets, err = getAllOrgCloneUrls()
-	} else if os.Getenv("GHORG_CLONE_TYPE") == "user" {
-		cloneTargets, err = getAllUserCloneUrls()
-	} else {
+	switch os.Getenv("GHORG_CLONE_TYPE") {
+	case "org", "user", "starred", "watched":
+		cloneTargets, err = getCloneUrls(os.Getenv("GHORG_CLONE_TYPE"))
+	default:
 		colorlog.PrintError("GHORG_CLONE_TYPE not set or unsupported")
 		os.Exit(1)
 	}
@@ -440,16 +650,16 @@ func setupRepoClone() {
 		colorlog.PrintInfo("No repos found for " + os.Getenv("GHORG_SCM_TYPE") + " " + os.Getenv("GHORG_CLONE_TYPE") + ": " + targetCloneSource + ", please verify you have sufficient permissions to clone target repos, double check spelling and try again.")
 		os.Exit(0)
 	}
-	g := git.NewGit()
+	g := git.NewVCS()
 	CloneAllRepos(g, cloneTargets)
 }
 
 func getAllOrgCloneUrls() ([]scm.Repo, error) {
-	return getCloneUrls(true)
+	return getCloneUrls("org")
 }
 
 func getAllUserCloneUrls() ([]scm.Repo, error) {
-	return getCloneUrls(false)
+	return getCloneUrls("user")
 }
 
 func getAllUserGistCloneUrls() ([]scm.Repo, error) {
@@ -469,7 +679,7 @@ func getAllUserGistCloneUrls() ([]scm.Repo, error) {
 	return githubClient.GetUserGists(targetCloneSource)
 }
 
-func getCloneUrls(isOrg bool) ([]scm.Repo, error) {
+func getCloneUrls(cloneType string) ([]scm.Repo, error) {
 	asciiTime()
 	PrintConfigs()
 	scmType := strings.ToLower(os.Getenv("GHORG_SCM_TYPE"))
@@ -483,11 +693,43 @@ func getCloneUrls(isOrg bool) ([]scm.Repo, error) {
 		os.Exit(1)
 	}
 
-	if isOrg {
-		return client.GetOrgRepos(targetCloneSource)
+	return listRepos(client, cloneType, targetCloneSource)
+}
+
+// listRepos lists the repos of target for the given clone type
+func listRepos(client scm.Client, cloneType string, target string) ([]scm.Repo, error) {
+	switch cloneType {
+	case "org":
+		return client.GetOrgRepos(target)
+	case "user":
+		return client.GetUserRepos(target)
+	case "starred":
+		lister, ok := client.(scm.StarredLister)
+		if !ok {
+			return nil, fmt.Errorf("--clone-type=starred is not supported for %s", client.GetType())
+		}
+		return lister.GetUserStarredRepos(target)
+	case "watched":
+		lister, ok := client.(scm.WatchedLister)
+		if !ok {
+			return nil, fmt.Errorf("--clone-type=watched is not supported for %s", client.GetType())
+		}
+		return lister.GetUserWatchedRepos(target)
 	}
 
-	return client.GetUserRepos(targetCloneSource)
+	return nil, fmt.Errorf("unsupported clone type %q", cloneType)
+}
+
+// isOwnerOrganizedCloneType reports whether repos of cloneType are cloned into
+// owner/repo directories. Starred and watched repos come from many owners so
+// keeping the owner in the path stops repos with the same name from colliding.
+func isOwnerOrganizedCloneType(cloneType string) bool {
+	return cloneType == "starred" || cloneType == "watched"
+}
+
+// usesRepoPathAsSlug reports whether repos of cloneType are cloned into their scm path
+func usesRepoPathAsSlug(cloneType string) bool {
+	return os.Getenv("GHORG_PRESERVE_DIRECTORY_STRUCTURE") == "true" || isOwnerOrganizedCloneType(cloneType)
 }
 
 func createDirIfNotExist() {
@@ -525,7 +767,7 @@ func initialRepoSlug(repo scm.Repo) string {
 		repoSlug = repo.Name
 	} else if repo.IsGitLabSnippet && !repo.IsGitLabRootLevelSnippet {
 		repoSlug = getAppNameFromURL(repo.GitLabSnippetInfo.URLOfRepo)
-	} else if repo.IsGitLabRootLevelSnippet {
+	} else if repo.IsGitLabRootLevelSnippet || repo.IsSnippet {
 		repoSlug = repo.Name
 	} else {
 		repoSlug = getAppNameFromURL(repo.URL)
@@ -578,6 +820,7 @@ func readTargetReposFile() ([]string, error) {
 	return lines, scanner.Err()
 }
 
+// readGhorgIgnore returns every line of the ghorgignore, blank lines are kept so rules know their line number
 func readGhorgIgnore() ([]string, error) {
 	file, err := os.Open(configs.GhorgIgnoreLocation())
 	if err != nil {
@@ -588,13 +831,12 @@ func readGhorgIgnore() ([]string, error) {
 	var lines []string
 	scanner := bufio.NewScanner(file)
 	for scanner.Scan() {
-		if scanner.Text() != "" {
-			lines = append(lines, scanner.Text())
-		}
+		lines = append(lines, scanner.Text())
 	}
 	return lines, scanner.Err()
 }
 
+// readGhorgOnly returns every line of the ghorgonly, blank lines are kept so rules know their line number
 func readGhorgOnly() ([]string, error) {
 	file, err := os.Open(configs.GhorgOnlyLocation())
 	if err != nil {
@@ -605,9 +847,7 @@ func readGhorgOnly() ([]string, error) {
 	var lines []string
 	scanner := bufio.NewScanner(file)
 	for scanner.Scan() {
-		if scanner.Text() != "" {
-			lines = append(lines, scanner.Text())
-		}
+		lines = append(lines, scanner.Text())
 	}
 	return lines, scanner.Err()
 }
@@ -620,7 +860,7 @@ func hasRepoNameCollisions(repos []scm.Repo) (map[string]bool, bool) {
 		return repoNameWithCollisions, false
 	}
 
-	if os.Getenv("GHORG_PRESERVE_DIRECTORY_STRUCTURE") == "true" {
+	if usesRepoPathAsSlug(os.Getenv("GHORG_CLONE_TYPE")) {
 		return repoNameWithCollisions, false
 	}
 
@@ -629,7 +869,7 @@ func hasRepoNameCollisions(repos []scm.Repo) (map[string]bool, bool) {
 	for _, repo := range repos {
 
 		// Snippets should never have collions because we append the snippet id to the directory name
-		if repo.IsGitLabSnippet {
+		if repo.IsGitLabSnippet || repo.IsSnippet {
 			continue
 		}
 
@@ -648,43 +888,6 @@ func hasRepoNameCollisions(repos []scm.Repo) (map[string]bool, bool) {
 	return repoNameWithCollisions, hasCollisions
 }
 
-func printDryRun(gitter git.Gitter, repos []scm.Repo) {
-	for _, repo := range repos {
-		colorlog.PrintSubtleInfo(repo.URL)
-	}
-	count := len(repos)
-	colorlog.PrintSuccess(fmt.Sprintf("%v repos to be cloned into: %s", count, outputDirAbsolutePath))
-
-	if shouldPreviewProtectLocal() {
-		_ = previewProtectLocal(gitter, repos)
-	}
-
-	if os.Getenv("GHORG_PRUNE") == "true" {
-
-		if stat, err := os.Stat(outputDirAbsolutePath); err == nil && stat.IsDir() {
-			// We check that the clone path exists, otherwise there would definitely be no pruning
-			// to do.
-			colorlog.PrintInfo("\nScanning for local clones that have been removed on remote...")
-
-			repositories, err := getRelativePathRepositories(outputDirAbsolutePath)
-			if err != nil {
-				log.Fatal(err)
-			}
-
-			eligibleForPrune := 0
-			for _, repository := range repositories {
-				// for each item in the org's clone directory, let's make sure we found a
-				// corresponding repo on the remote.
-				if !sliceContainsNamedRepo(repos, repository) {
-					eligibleForPrune++
-					colorlog.PrintSubtleInfo(fmt.Sprintf("%s not found in remote.", repository))
-				}
-			}
-			colorlog.PrintSuccess(fmt.Sprintf("Local clones eligible for pruning: %d", eligibleForPrune))
-		}
-	}
-}
-
 // shouldPreviewProtectLocal reports whether the dry-run should inspect local
 // repositories for uncommitted changes or unpushed commits. This mirrors the
 // non-dry-run check in handleExistingRepository: protect-local only applies to
@@ -699,54 +902,6 @@ func shouldPreviewProtectLocal() bool {
 	return true
 }
 
-// previewProtectLocal walks the clone targets, resolves each one's HostPath the
-// same way the real clone does (via RepositoryProcessor), and for any target
-// that already exists locally reports whether it would be skipped due to local
-// changes. Output matches the real-run "Skipping ..." line but uses
-// "Would skip ..." to make the dry-run semantics clear. Returns the number of
-// repos that would be skipped so callers (including tests) can inspect the
-// result without parsing stdout.
-func previewProtectLocal(gitter git.Gitter, repos []scm.Repo) int {
-	if len(repos) == 0 {
-		return 0
-	}
-
-	colorlog.PrintInfo("\nScanning for local changes that would be protected by --protect-local...")
-
-	repoNameWithCollisions, hasCollisions := hasRepoNameCollisions(repos)
-	processor := NewRepositoryProcessor(gitter)
-
-	protectedCount := 0
-	for i := range repos {
-		repo := repos[i]
-
-		repoSlug := initialRepoSlug(repo)
-		if repo.Path != "" && os.Getenv("GHORG_PRESERVE_DIRECTORY_STRUCTURE") == "true" {
-			repoSlug = repo.Path
-		}
-
-		finalRepoSlug := processor.handleNameCollisions(repo, repoNameWithCollisions, hasCollisions, repoSlug, i)
-		repo.HostPath = processor.buildHostPath(repo, finalRepoSlug)
-
-		if !repoExistsLocally(repo) {
-			continue
-		}
-
-		hasChanges, reason, err := processor.hasLocalChanges(&repo)
-		if err != nil {
-			colorlog.PrintInfo(fmt.Sprintf("Could not check for local changes on %s: %v", repo.Name, err))
-			continue
-		}
-		if hasChanges {
-			protectedCount++
-			colorlog.PrintSubtleInfo(fmt.Sprintf("Would skip %s: %s (--protect-local)", repo.URL, reason))
-		}
-	}
-
-	colorlog.PrintSuccess(fmt.Sprintf("Repos that would be skipped due to --protect-local: %d", protectedCount))
-	return protectedCount
-}
-
 func trimCollisionFilename(filename string) string {
 	maxLen := 248
 	if len(filename) > maxLen {
@@ -759,7 +914,7 @@ func trimCollisionFilename(filename string) string {
 func getCloneableInventory(allRepos []scm.Repo) (int, int, int, int, int) {
 	var wikis, snippets, repos, gists, total int
 	for _, repo := range allRepos {
-		if repo.IsGitLabSnippet {
+		if repo.IsGitLabSnippet || repo.IsSnippet {
 			snippets++
 		} else if repo.IsWiki {
 			wikis++
@@ -773,17 +928,29 @@ func getCloneableInventory(allRepos []scm.Repo) (int, int, int, int, int) {
 	return total, repos, snippets, wikis, gists
 }
 
+// isGitRepository reports whether path is a working copy, mercurial repos count so they are pruned like any other
 func isGitRepository(path string) bool {
-	stat, err := os.Stat(filepath.Join(path, ".git"))
-	return err == nil && stat.IsDir()
+	for _, dir := range []string{".git", ".hg"} {
+		if stat, err := os.Stat(filepath.Join(path, dir)); err == nil && stat.IsDir() {
+			return true
+		}
+	}
+	return false
 }
 
+// getRelativePathRepositories returns the repos in root relative to outputDirAbsolutePath, repos nested in other
+// repos are included
 func getRelativePathRepositories(root string) ([]string, error) {
 	var relativePaths []string
 	err := filepath.WalkDir(root, func(path string, file fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
+		// the object stores of large repos hold thousands of directories and never a working copy, neither do
+		// metadata exports
+		if file.IsDir() && (file.Name() == ".git" || file.Name() == ".hg" || isMetadataDir(path)) {
+			return filepath.SkipDir
+		}
 		if path != outputDirAbsolutePath && file.IsDir() && isGitRepository(path) {
 			rel, err := filepath.Rel(outputDirAbsolutePath, path)
 			if err != nil {
@@ -802,34 +969,13 @@ func CloneAllRepos(git git.Gitter, cloneTargets []scm.Repo) {
 	filter := NewRepositoryFilter()
 	cloneTargets = filter.ApplyAllFilters(cloneTargets)
 
-	totalResourcesToClone, reposToCloneCount, snippetToCloneCount, wikisToCloneCount, gistsToCloneCount := getCloneableInventory(cloneTargets)
-
-	if os.Getenv("GHORG_GITHUB_USER_GISTS") == "true" {
-		colorlog.PrintInfo(fmt.Sprintf("%v gists found for %v\n", gistsToCloneCount, targetCloneSource))
-	} else if os.Getenv("GHORG_CLONE_WIKI") == "true" && os.Getenv("GHORG_CLONE_SNIPPETS") == "true" {
-		m := fmt.Sprintf("%v resources to clone found in %v, %v repos, %v snippets, and %v wikis\n", totalResourcesToClone, targetCloneSource, reposToCloneCount, snippetToCloneCount, wikisToCloneCount)
-		colorlog.PrintInfo(m)
-	} else if os.Getenv("GHORG_CLONE_WIKI") == "true" {
-		m := fmt.Sprintf("%v resources to clone found in %v, %v repos and %v wikis\n", totalResourcesToClone, targetCloneSource, reposToCloneCount, wikisToCloneCount)
-		colorlog.PrintInfo(m)
-	} else if os.Getenv("GHORG_CLONE_SNIPPETS") == "true" {
-		m := fmt.Sprintf("%v resources to clone found in %v, %v repos and %v snippets\n", totalResourcesToClone, targetCloneSource, reposToCloneCount, snippetToCloneCount)
-		colorlog.PrintInfo(m)
-	} else {
-		colorlog.PrintInfo(strconv.Itoa(reposToCloneCount) + " repos found in " + targetCloneSource + "\n")
-	}
+	printResourcesFound(cloneTargets)
 
 	// Show concurrency adjustment message if it was auto-adjusted
-	if os.Getenv("GHORG_CONCURRENCY_AUTO_ADJUSTED") == "true" {
-		if delaySeconds, hasDelay := getCloneDelaySeconds(); hasDelay {
-			colorlog.PrintInfo(fmt.Sprintf("GHORG_CLONE_DELAY_SECONDS is set to %d seconds. Automatically setting GHORG_CONCURRENCY to 1 for predictable rate limiting.", delaySeconds))
-		}
-		// Clear the tracking variable
-		_ = os.Unsetenv("GHORG_CONCURRENCY_AUTO_ADJUSTED")
-	}
+	printConcurrencyAutoAdjusted()
 
 	if os.Getenv("GHORG_DRY_RUN") == "true" {
-		printDryRun(git, cloneTargets)
+		printDryRunPlans([]dryRunPlan{buildDryRunPlan(git, cloneTargets, filter.Skipped())})
 		return
 	}
 
@@ -847,6 +993,8 @@ func CloneAllRepos(git git.Gitter, cloneTargets []scm.Repo) {
 
 	// Initialize repository processor
 	processor := NewRepositoryProcessor(git)
+	repoPathAsSlug := usesRepoPathAsSlug(os.Getenv("GHORG_CLONE_TYPE"))
+	processor.RelocateRenamedRepos(cloneTargets, repoPathAsSlug)
 
 	for i := range cloneTargets {
 		repo := cloneTargets[i]
@@ -854,7 +1002,7 @@ func CloneAllRepos(git git.Gitter, cloneTargets []scm.Repo) {
 		repoSlug := initialRepoSlug(repo)
 
 		_, _ = limit.Execute(func() {
-			if repo.Path != "" && os.Getenv("GHORG_PRESERVE_DIRECTORY_STRUCTURE") == "true" {
+			if repo.Path != "" && repoPathAsSlug {
 				repoSlug = repo.Path
 			}
 
@@ -870,30 +1018,10 @@ func CloneAllRepos(git git.Gitter, cloneTargets []scm.Repo) {
 	totalDurationSeconds := int(totalDuration.Seconds() + 0.5) // Round to nearest second
 	processor.SetTotalDuration(totalDurationSeconds)
 
-	// Get statistics and untouched repos from processor
+	// Get statistics from processor
 	stats := processor.GetStats()
-	untouchedReposToPrune := processor.GetUntouchedRepos()
-	var untouchedPrunes int
-
-	if os.Getenv("GHORG_PRUNE_UNTOUCHED") == "true" && len(untouchedReposToPrune) > 0 {
-		if os.Getenv("GHORG_PRUNE_UNTOUCHED_NO_CONFIRM") != "true" {
-			colorlog.PrintSuccess(fmt.Sprintf("PLEASE CONFIRM: The following %d untouched repositories will be deleted. Press enter to confirm: ", len(untouchedReposToPrune)))
-			for _, repoPath := range untouchedReposToPrune {
-				colorlog.PrintInfo(fmt.Sprintf("- %s", repoPath))
-			}
-			_, _ = fmt.Scanln()
-		}
-
-		for _, repoPath := range untouchedReposToPrune {
-			err := os.RemoveAll(repoPath)
-			if err != nil {
-				colorlog.PrintError(fmt.Sprintf("Failed to prune repository at %s: %v", repoPath, err))
-			} else {
-				untouchedPrunes++
-				colorlog.PrintSuccess(fmt.Sprintf("Successfully deleted %s", repoPath))
-			}
-		}
-	}
+	processor.SaveIndex()
+	untouchedPrunes := pruneUntouchedRepos(processor)
 
 	// Update global error/info arrays for backward compatibility
 	cloneInfos = stats.CloneInfos
@@ -901,19 +1029,10 @@ func CloneAllRepos(git git.Gitter, cloneTargets []scm.Repo) {
 
 	printRemainingMessages()
 	printCloneStatsMessage(stats.CloneCount, stats.PulledCount, stats.UpdateRemoteCount, stats.NewCommits, untouchedPrunes, stats.ProtectedCount, stats.TotalDurationSeconds)
+	printSkippedWikisMessage(stats.SkippedWikiCount)
 
 	if hasCollisions {
-		fmt.Println("")
-		colorlog.PrintInfo("ATTENTION: ghorg detected collisions in repo names from the groups that were cloned. This occurs when one or more groups share common repo names trying to be cloned to the same directory. The repos that would have collisions were renamed with the group/subgroup appended.")
-		if os.Getenv("GHORG_DEBUG") != "" {
-			fmt.Println("")
-			colorlog.PrintInfo("Collisions Occured in the following repos...")
-			for repoName, collision := range repoNameWithCollisions {
-				if collision {
-					colorlog.PrintInfo("- " + repoName)
-				}
-			}
-		}
+		printCollisionsNotice(repoNameWithCollisions)
 	}
 
 	var pruneCount int
@@ -922,8 +1041,9 @@ func CloneAllRepos(git git.Gitter, cloneTargets []scm.Repo) {
 	allReposToCloneCount := len(cloneTargets)
 	// Now, clean up local repos that don't exist in remote, if prune flag is set
 	if os.Getenv("GHORG_PRUNE") == "true" {
-		pruneCount = pruneRepos(cloneTargets)
+		pruneCount = pruneRepos(cloneTargets, processor.index)
 	}
+	processor.SaveIndex()
 
 	if os.Getenv("GHORG_QUIET") != "true" {
 		if os.Getenv("GHORG_NO_DIR_SIZE") == "false" {
@@ -939,6 +1059,88 @@ func CloneAllRepos(git git.Gitter, cloneTargets []scm.Repo) {
 		_ = writeGhorgStats(date, allReposToCloneCount, stats.CloneCount, stats.PulledCount, cloneInfosCount, cloneErrorsCount, stats.UpdateRemoteCount, stats.NewCommits, pruneCount, stats.TotalDurationSeconds, hasCollisions)
 	}
 
+	exitWithCloneStatus(cloneInfosCount, cloneErrorsCount)
+}
+
+// printResourcesFound prints how many repos, snippets, wikis and gists were found in targetCloneSource
+func printResourcesFound(cloneTargets []scm.Repo) {
+	totalResourcesToClone, reposToCloneCount, snippetToCloneCount, wikisToCloneCount, gistsToCloneCount := getCloneableInventory(cloneTargets)
+
+	if os.Getenv("GHORG_GITHUB_USER_GISTS") == "true" {
+		colorlog.PrintInfo(fmt.Sprintf("%v gists found for %v\n", gistsToCloneCount, targetCloneSource))
+	} else if os.Getenv("GHORG_CLONE_WIKI") == "true" && os.Getenv("GHORG_CLONE_SNIPPETS") == "true" {
+		m := fmt.Sprintf("%v resources to clone found in %v, %v repos, %v snippets, and %v wikis\n", totalResourcesToClone, targetCloneSource, reposToCloneCount, snippetToCloneCount, wikisToCloneCount)
+		colorlog.PrintInfo(m)
+	} else if os.Getenv("GHORG_CLONE_WIKI") == "true" {
+		m := fmt.Sprintf("%v resources to clone found in %v, %v repos and %v wikis\n", totalResourcesToClone, targetCloneSource, reposToCloneCount, wikisToCloneCount)
+		colorlog.PrintInfo(m)
+	} else if os.Getenv("GHORG_CLONE_SNIPPETS") == "true" {
+		m := fmt.Sprintf("%v resources to clone found in %v, %v repos and %v snippets\n", totalResourcesToClone, targetCloneSource, reposToCloneCount, snippetToCloneCount)
+		colorlog.PrintInfo(m)
+	} else {
+		colorlog.PrintInfo(strconv.Itoa(reposToCloneCount) + " repos found in " + targetCloneSource + "\n")
+	}
+}
+
+func printConcurrencyAutoAdjusted() {
+	if os.Getenv("GHORG_CONCURRENCY_AUTO_ADJUSTED") == "true" {
+		if delaySeconds, hasDelay := getCloneDelaySeconds(); hasDelay {
+			colorlog.PrintInfo(fmt.Sprintf("GHORG_CLONE_DELAY_SECONDS is set to %d seconds. Automatically setting GHORG_CONCURRENCY to 1 for predictable rate limiting.", delaySeconds))
+		}
+		// Clear the tracking variable
+		_ = os.Unsetenv("GHORG_CONCURRENCY_AUTO_ADJUSTED")
+	}
+}
+
+// pruneUntouchedRepos deletes the repos the processor found to be untouched when GHORG_PRUNE_UNTOUCHED is set, returning how many were deleted
+func pruneUntouchedRepos(processor *RepositoryProcessor) int {
+	untouchedReposToPrune := processor.GetUntouchedRepos()
+	var untouchedPrunes int
+
+	if os.Getenv("GHORG_PRUNE_UNTOUCHED") == "true" && len(untouchedReposToPrune) > 0 {
+		if os.Getenv("GHORG_PRUNE_UNTOUCHED_NO_CONFIRM") != "true" {
+			colorlog.PrintSuccess(fmt.Sprintf("PLEASE CONFIRM: The following %d untouched repositories will be deleted. Press enter to confirm: ", len(untouchedReposToPrune)))
+			for _, repoPath := range untouchedReposToPrune {
+				colorlog.PrintInfo(fmt.Sprintf("- %s", repoPath))
+			}
+			_, _ = fmt.Scanln()
+		}
+
+		for _, repoPath := range untouchedReposToPrune {
+			err := os.RemoveAll(repoPath)
+			if err != nil {
+				colorlog.PrintError(fmt.Sprintf("Failed to prune repository at %s: %v", repoPath, err))
+			} else {
+				untouchedPrunes++
+				if processor.index != nil {
+					if rel, err := processor.index.relPath(repoPath); err == nil {
+						processor.index.remove(rel)
+					}
+				}
+				colorlog.PrintSuccess(fmt.Sprintf("Successfully deleted %s", repoPath))
+			}
+		}
+	}
+
+	return untouchedPrunes
+}
+
+func printCollisionsNotice(repoNameWithCollisions map[string]bool) {
+	fmt.Println("")
+	colorlog.PrintInfo("ATTENTION: ghorg detected collisions in repo names from the groups that were cloned. This occurs when one or more groups share common repo names trying to be cloned to the same directory. The repos that would have collisions were renamed with the group/subgroup appended.")
+	if os.Getenv("GHORG_DEBUG") != "" {
+		fmt.Println("")
+		colorlog.PrintInfo("Collisions Occured in the following repos...")
+		for repoName, collision := range repoNameWithCollisions {
+			if collision {
+				colorlog.PrintInfo("- " + repoName)
+			}
+		}
+	}
+}
+
+// exitWithCloneStatus exits with the configured exit codes when clone infos or issues occurred
+func exitWithCloneStatus(cloneInfosCount, cloneErrorsCount int) {
 	if os.Getenv("GHORG_DONT_EXIT_UNDER_TEST") != "true" {
 		if os.Getenv("GHORG_EXIT_CODE_ON_CLONE_INFOS") != "0" && cloneInfosCount > 0 {
 			exitCode, err := strconv.Atoi(os.Getenv("GHORG_EXIT_CODE_ON_CLONE_INFOS"))
@@ -961,7 +1163,6 @@ func CloneAllRepos(git git.Gitter, cloneTargets []scm.Repo) {
 			os.Exit(exitCode)
 		}
 	}
-
 }
 
 func getGhorgStatsFilePath() string {
@@ -1183,7 +1384,9 @@ func filterByTargetReposPath(cloneTargets []scm.Repo) []scm.Repo {
 	return cloneTargets
 }
 
-func pruneRepos(cloneTargets []scm.Repo) int {
+// pruneRepos deletes local repos that are no longer in cloneTargets, index is the index of the output
+// dir and may be nil, directories it knows are matched exactly rather than by their name
+func pruneRepos(cloneTargets []scm.Repo, index *repoIndex) int {
 	count := 0
 	colorlog.PrintInfo("\nScanning for local clones that have been removed on remote...")
 
@@ -1207,7 +1410,7 @@ func pruneRepos(cloneTargets []scm.Repo) int {
 		// For each item in the org's clone directory, let's make sure we found a corresponding
 		// repo on the remote.  We check userAgreesToDelete here too, so that if the user says
 		// "No" at any time, we stop trying to prune things altogether.
-		if userAgreesToDelete && !sliceContainsNamedRepo(cloneTargets, repository) {
+		if userAgreesToDelete && !repoStillInRemote(index, repository, cloneTargets) {
 			// If the user specified --prune-no-confirm, we needn't prompt interactively.
 			userAgreesToDelete = pruneNoConfirm || interactiveYesNoPrompt(
 				fmt.Sprintf("%s was not found in remote.  Do you want to prune it? %s", repository, absolutePathToDelete))
@@ -1219,6 +1422,14 @@ func pruneRepos(cloneTargets []scm.Repo) int {
 				if err != nil {
 					log.Fatal(err)
 				}
+				// the branch worktrees of the repo can't be used without it, its metadata export is kept as the
+				// only copy of what the scm no longer has
+				if err := os.RemoveAll(worktreesDirPath(absolutePathToDelete)); err != nil {
+					log.Fatal(err)
+				}
+				if index != nil {
+					index.remove(repository)
+				}
 			} else {
 				colorlog.PrintError("Pruning cancelled by user.  No more prunes will be considered.")
 			}
@@ -1281,6 +1492,13 @@ func printCloneStatsMessage(cloneCount, pulledCount, updateRemoteCount, newCommi
 	colorlog.PrintSuccess(fmt.Sprintf("New clones: %v, existing resources pulled: %v%s", cloneCount, pulledCount, durationText))
 }
 
+// printSkippedWikisMessage prints how many wikis were enabled but had nothing to clone
+func printSkippedWikisMessage(skippedWikiCount int) {
+	if skippedWikiCount > 0 {
+		colorlog.PrintSubtleInfo(fmt.Sprintf("Skipped wikis that are empty or do not exist: %v", skippedWikiCount))
+	}
+}
+
 func interactiveYesNoPrompt(prompt string) bool {
 	reader := bufio.NewReader(os.Stdin)
 	fmt.Print(strings.TrimSpace(prompt) + " (y/N) ")
@@ -1378,6 +1596,21 @@ func PrintConfigs() {
 	}
 	if os.Getenv("GHORG_GITHUB_USER_GISTS") == "true" {
 		colorlog.PrintInfo("* Gists         : " + os.Getenv("GHORG_GITHUB_USER_GISTS"))
+		if os.Getenv("GHORG_GIST_VISIBILITY") != "" {
+			colorlog.PrintInfo("* Gist Visible  : " + os.Getenv("GHORG_GIST_VISIBILITY"))
+		}
+		if os.Getenv("GHORG_GIST_DESCRIPTION_REGEX") != "" {
+			colorlog.PrintInfo("* Gist Desc     : " + os.Getenv("GHORG_GIST_DESCRIPTION_REGEX"))
+		}
+		if os.Getenv("GHORG_GIST_FILE_EXTENSIONS") != "" {
+			colorlog.PrintInfo("* Gist Exts     : " + os.Getenv("GHORG_GIST_FILE_EXTENSIONS"))
+		}
+		if os.Getenv("GHORG_GIST_UPDATED_SINCE") != "" {
+			colorlog.PrintInfo("* Gist Since    : " + os.Getenv("GHORG_GIST_UPDATED_SINCE"))
+		}
+		if os.Getenv("GHORG_GIST_FOLDER_NAME") != "" {
+			colorlog.PrintInfo("* Gist Folders  : " + os.Getenv("GHORG_GIST_FOLDER_NAME"))
+		}
 	}
 	if strings.EqualFold(os.Getenv("GHORG_SCM_TYPE"), "github") {
 		if ghList := strings.TrimSpace(os.Getenv("GHORG_GITHUB_REPO_LIST_CONCURRENCY")); ghList != "" {
@@ -1390,9 +1623,38 @@ func PrintConfigs() {
 	if configs.GhorgOnlyDetected() {
 		colorlog.PrintInfo("* Ghorgonly     : " + configs.GhorgOnlyLocation())
 	}
+	if (configs.GhorgIgnoreDetected() || configs.GhorgOnlyDetected()) && os.Getenv("GHORG_FILTER_FILE_SYNTAX") != "" {
+		colorlog.PrintInfo("* Filter Syntax : " + os.Getenv("GHORG_FILTER_FILE_SYNTAX"))
+	}
 	if os.Getenv("GHORG_TARGET_REPOS_PATH") != "" {
 		colorlog.PrintInfo("* Target Repos  : " + os.Getenv("GHORG_TARGET_REPOS_PATH"))
 	}
+	if os.Getenv("GHORG_PUSHED_SINCE") != "" {
+		colorlog.PrintInfo("* Pushed Since  : " + os.Getenv("GHORG_PUSHED_SINCE"))
+	}
+	if os.Getenv("GHORG_PUSHED_BEFORE") != "" {
+		colorlog.PrintInfo("* Pushed Before : " + os.Getenv("GHORG_PUSHED_BEFORE"))
+	}
+	if os.Getenv("GHORG_FILTER_LANGUAGE") != "" {
+		colorlog.PrintInfo("* Languages     : " + os.Getenv("GHORG_FILTER_LANGUAGE"))
+	} else if os.Getenv("GHORG_GITHUB_FILTER_LANGUAGE") != "" {
+		colorlog.PrintInfo("* Languages     : " + os.Getenv("GHORG_GITHUB_FILTER_LANGUAGE"))
+	}
+	if os.Getenv("GHORG_FILTER_LANGUAGE_THRESHOLD") != "" {
+		colorlog.PrintInfo("* Lang Threshold: " + os.Getenv("GHORG_FILTER_LANGUAGE_THRESHOLD") + "%")
+	}
+	if os.Getenv("GHORG_MIN_REPO_SIZE") != "" {
+		colorlog.PrintInfo("* Min Size      : " + os.Getenv("GHORG_MIN_REPO_SIZE"))
+	}
+	if os.Getenv("GHORG_MAX_REPO_SIZE") != "" {
+		colorlog.PrintInfo("* Max Size      : " + os.Getenv("GHORG_MAX_REPO_SIZE"))
+	}
+	if os.Getenv("GHORG_VISIBILITY") != "" {
+		colorlog.PrintInfo("* Visibility    : " + os.Getenv("GHORG_VISIBILITY"))
+	}
+	if os.Getenv("GHORG_FILTER") != "" {
+		colorlog.PrintInfo("* Filter        : " + os.Getenv("GHORG_FILTER"))
+	}
 	if os.Getenv("GHORG_MATCH_REGEX") != "" {
 		colorlog.PrintInfo("* Regex Match   : " + os.Getenv("GHORG_MATCH_REGEX"))
 	}
@@ -1445,6 +1707,32 @@ func PrintConfigs() {
 	if os.Getenv("GHORG_FETCH_PRUNE") == "true" {
 		colorlog.PrintInfo("* Fetch Prune   : " + "true")
 	}
+	if os.Getenv("GHORG_FETCH_REVIEW_REFS") == "true" {
+		includeClosedText := ""
+		if os.Getenv("GHORG_REVIEW_REFS_INCLUDE_CLOSED") == "true" {
+			includeClosedText = " (including closed)"
+		}
+		colorlog.PrintInfo("* Review Refs   : " + "true" + includeClosedText)
+	}
+	if os.Getenv("GHORG_BACKUP_METADATA") == "true" {
+		colorlog.PrintInfo("* Backup Meta   : " + "true")
+	}
+	if os.Getenv("GHORG_WORKTREE_BRANCHES") != "" {
+		colorlog.PrintInfo("* Worktrees     : " + os.Getenv("GHORG_WORKTREE_BRANCHES"))
+	}
+	if os.Getenv("GHORG_SPARSE_PATHS") != "" {
+		colorlog.PrintInfo("* Sparse Paths  : " + os.Getenv("GHORG_SPARSE_PATHS"))
+	}
+	if os.Getenv("GHORG_SPARSE_PATHS_FILE") != "" {
+		colorlog.PrintInfo("* Sparse File   : " + os.Getenv("GHORG_SPARSE_PATHS_FILE"))
+	}
+	if os.Getenv("GHORG_REFERENCE_STORE") == "true" {
+		dissociateText := ""
+		if os.Getenv("GHORG_REFERENCE_STORE_DISSOCIATE") == "true" {
+			dissociateText = " (dissociated)"
+		}
+		colorlog.PrintInfo("* Reference     : " + configs.GhorgReferenceStoreLocation() + dissociateText)
+	}
 	if os.Getenv("GHORG_DRY_RUN") == "true" {
 		colorlog.PrintInfo("* Dry Run       : " + "true")
 	}
@@ -1491,70 +1779,56 @@ func setOutputDirName(argz []string) {
 		return
 	}
 
-	outputDirName = strings.ToLower(argz[0])
+	outputDirName = outputDirNameForTarget(argz[0], os.Getenv("GHORG_CLONE_TYPE"))
+}
+
+// outputDirNameForTarget returns the name of the directory the repos of target are cloned into
+func outputDirNameForTarget(target string, cloneType string) string {
+	dirName := strings.ToLower(target)
 
 	// Strip ~ prefix for sourcehut usernames to avoid shell expansion issues
 	if os.Getenv("GHORG_SCM_TYPE") == "sourcehut" {
-		outputDirName = strings.TrimPrefix(outputDirName, "~")
+		dirName = strings.TrimPrefix(dirName, "~")
 	}
 
 	if os.Getenv("GHORG_PRESERVE_SCM_HOSTNAME") != "true" {
 		// If all-group is used set the parent folder to the name of the baseurl
-		if argz[0] == "all-groups" && os.Getenv("GHORG_SCM_BASE_URL") != "" {
+		if target == "all-groups" && os.Getenv("GHORG_SCM_BASE_URL") != "" {
 			u, err := url.Parse(os.Getenv("GHORG_SCM_BASE_URL"))
 			if err != nil {
 				colorlog.PrintError(fmt.Sprintf("Error parsing GHORG_SCM_BASE_URL, clone may be affected, error: %v", err))
 			}
-			outputDirName = u.Hostname()
+			dirName = u.Hostname()
 		}
 
-		if argz[0] == "all-users" && os.Getenv("GHORG_SCM_BASE_URL") != "" {
+		if target == "all-users" && os.Getenv("GHORG_SCM_BASE_URL") != "" {
 			u, err := url.Parse(os.Getenv("GHORG_SCM_BASE_URL"))
 			if err != nil {
 				colorlog.PrintError(fmt.Sprintf("Error parsing GHORG_SCM_BASE_URL, clone may be affected, error: %v", err))
 			}
-			outputDirName = u.Hostname()
+			dirName = u.Hostname()
+		}
+	}
+
+	// Keep starred and watched repos apart from the user's own repos
+	if isOwnerOrganizedCloneType(cloneType) {
+		if dirName == "" {
+			dirName = cloneType
+		} else {
+			dirName = dirName + "_" + cloneType
 		}
 	}
 
 	if os.Getenv("GHORG_BACKUP") == "true" {
-		outputDirName = outputDirName + "_backup"
+		dirName = dirName + "_backup"
 	}
+
+	return dirName
 }
 
 // filter repos down based on ghorgignore if one exists
 func filterByGhorgignore(cloneTargets []scm.Repo) []scm.Repo {
-
-	_, err := os.Stat(configs.GhorgIgnoreLocation())
-	if !os.IsNotExist(err) {
-		// Open the file parse each line and remove cloneTargets containing
-		toIgnore, err := readGhorgIgnore()
-		if err != nil {
-			colorlog.PrintErrorAndExit(fmt.Sprintf("Error parsing your ghorgignore, error: %v", err))
-		}
-
-		colorlog.PrintInfo("Using ghorgignore, filtering repos down...")
-
-		filteredCloneTargets := []scm.Repo{}
-		var flag bool
-		for _, cloned := range cloneTargets {
-			flag = false
-			for _, ignore := range toIgnore {
-				if strings.Contains(cloned.URL, ignore) {
-					flag = true
-				}
-			}
-
-			if !flag {
-				filteredCloneTargets = append(filteredCloneTargets, cloned)
-			}
-		}
-
-		cloneTargets = filteredCloneTargets
-
-	}
-
-	return cloneTargets
+	return NewRepositoryFilter().FilterByGhorgignore(cloneTargets)
 }
 
 func isPathSegmentSafe(seg string) bool {
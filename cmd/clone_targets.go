@@ -0,0 +1,349 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gabrie30/ghorg/colorlog"
+	"github.com/gabrie30/ghorg/git"
+	"github.com/gabrie30/ghorg/scm"
+	"github.com/korovkin/limiter"
+)
+
+// CloneTarget is a single org or user passed to ghorg clone when cloning more than one target at once
+type CloneTarget struct {
+	Name      string
+	CloneType string
+	OutputDir string
+	Repos     []scm.Repo
+}
+
+// cloneTargetResult holds the outcome of cloning a single CloneTarget
+type cloneTargetResult struct {
+	target          CloneTarget
+	stats           CloneStats
+	untouchedPrunes int
+	pruneCount      int
+	dirSize         string
+}
+
+// parseCloneTargets turns the ghorg clone arguments into clone targets. Each
+// argument may be prefixed with org:, user:, starred: or watched: to override
+// GHORG_CLONE_TYPE for that target only, e.g. ghorg clone org:kubernetes user:gabrie30.
+// starred: and watched: without a name use the authenticated user.
+func parseCloneTargets(argz []string) ([]CloneTarget, error) {
+	targets := make([]CloneTarget, 0, len(argz))
+	seenOutputDirs := make(map[string]string)
+
+	for _, arg := range argz {
+		name := arg
+		cloneType := os.Getenv("GHORG_CLONE_TYPE")
+
+		if prefix, rest, ok := strings.Cut(arg, ":"); ok {
+			if prefix != "org" && prefix != "user" && !isOwnerOrganizedCloneType(prefix) {
+				return nil, fmt.Errorf("unsupported clone type %q in target %q, prefix a target with org:, user:, starred: or watched:", prefix, arg)
+			}
+			cloneType = prefix
+			name = rest
+		}
+
+		if name == "" && !isOwnerOrganizedCloneType(cloneType) {
+			return nil, fmt.Errorf("clone target %q is missing an org or user name", arg)
+		}
+
+		outputDir := filepath.Join(os.Getenv("GHORG_ABSOLUTE_PATH_TO_CLONE_TO"), outputDirNameForTarget(name, cloneType))
+		if previous, ok := seenOutputDirs[outputDir]; ok {
+			return nil, fmt.Errorf("clone targets %q and %q would both be cloned into %s", previous, arg, outputDir)
+		}
+		seenOutputDirs[outputDir] = arg
+
+		targets = append(targets, CloneTarget{
+			Name:      name,
+			CloneType: cloneType,
+			OutputDir: outputDir,
+		})
+	}
+
+	return targets, nil
+}
+
+// getMembershipCloneTargets returns a clone target for every org or top level
+// group the token is a member of. Each org is cloned into its own directory
+// under GHORG_OUTPUT_DIR when set, otherwise directly under the clone path.
+func getMembershipCloneTargets() ([]CloneTarget, error) {
+	scmType := strings.ToLower(os.Getenv("GHORG_SCM_TYPE"))
+	client, err := scm.GetClient(scmType)
+	if err != nil {
+		return nil, err
+	}
+
+	lister, ok := client.(scm.MembershipLister)
+	if !ok {
+		return nil, fmt.Errorf("--clone-type=memberships is not supported for %s", scmType)
+	}
+
+	orgs, err := lister.GetMemberOrgs()
+	if err != nil {
+		return nil, err
+	}
+
+	parentDir := filepath.Join(os.Getenv("GHORG_ABSOLUTE_PATH_TO_CLONE_TO"), os.Getenv("GHORG_OUTPUT_DIR"))
+
+	targets := make([]CloneTarget, 0, len(orgs))
+	for _, org := range orgs {
+		targets = append(targets, CloneTarget{
+			Name:      org,
+			CloneType: "org",
+			OutputDir: filepath.Join(parentDir, outputDirNameForTarget(org, "org")),
+		})
+	}
+
+	return targets, nil
+}
+
+// cloneTargetNames returns the names of the targets joined for display
+func cloneTargetNames(targets []CloneTarget) string {
+	names := make([]string, 0, len(targets))
+	for _, t := range targets {
+		names = append(names, t.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// setupMultiTargetClone lists the repos of every target and clones them all in one run
+func setupMultiTargetClone(targets []CloneTarget) {
+	cloneErrors = nil
+	cloneInfos = nil
+	cachedDirSizeMB = 0
+	isDirSizeCached = false
+
+	asciiTime()
+	PrintConfigs()
+
+	listErrors := listCloneTargets(targets)
+	for i, err := range listErrors {
+		if err != nil {
+			cloneErrors = append(cloneErrors, fmt.Sprintf("Could not list repos for %s %s: %v", targets[i].CloneType, targets[i].Name, err))
+		}
+	}
+
+	var found []CloneTarget
+	for i, t := range targets {
+		if listErrors[i] != nil {
+			continue
+		}
+		if len(t.Repos) == 0 {
+			cloneInfos = append(cloneInfos, fmt.Sprintf("No repos found for %s %s: %s, please verify you have sufficient permissions to clone target repos, double check spelling and try again.", os.Getenv("GHORG_SCM_TYPE"), t.CloneType, t.Name))
+			continue
+		}
+		found = append(found, t)
+	}
+
+	CloneAllTargets(git.NewVCS(), found)
+}
+
+// listCloneTargets fetches the repos of each target concurrently. Targets are
+// listed one clone type at a time because some providers read GHORG_CLONE_TYPE
+// while listing. The returned errors are indexed the same as targets.
+func listCloneTargets(targets []CloneTarget) []error {
+	errs := make([]error, len(targets))
+
+	scmType := strings.ToLower(os.Getenv("GHORG_SCM_TYPE"))
+	client, err := scm.GetClient(scmType)
+	if err != nil {
+		colorlog.PrintError(err)
+		os.Exit(1)
+	}
+
+	originalCloneType := os.Getenv("GHORG_CLONE_TYPE")
+	defer func() { _ = os.Setenv("GHORG_CLONE_TYPE", originalCloneType) }()
+
+	for _, cloneType := range []string{"org", "user", "starred", "watched"} {
+		_ = os.Setenv("GHORG_CLONE_TYPE", cloneType)
+
+		var wg sync.WaitGroup
+		for i := range targets {
+			if targets[i].CloneType != cloneType {
+				continue
+			}
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				targets[i].Repos, errs[i] = listRepos(client, cloneType, targets[i].Name)
+			}(i)
+		}
+		wg.Wait()
+	}
+
+	return errs
+}
+
+// withCloneTarget points the per target globals at t while fn runs
+func withCloneTarget(t CloneTarget, fn func()) {
+	originalOutputDir, originalSource, originalCloneType := outputDirAbsolutePath, targetCloneSource, os.Getenv("GHORG_CLONE_TYPE")
+	outputDirAbsolutePath, targetCloneSource = t.OutputDir, t.Name
+	_ = os.Setenv("GHORG_CLONE_TYPE", t.CloneType)
+	defer func() {
+		outputDirAbsolutePath, targetCloneSource = originalOutputDir, originalSource
+		_ = os.Setenv("GHORG_CLONE_TYPE", originalCloneType)
+	}()
+	fn()
+}
+
+// CloneAllTargets clones the repos of every target through one shared
+// concurrency limiter, each target into its own output directory, then prints
+// a combined summary
+func CloneAllTargets(git git.Gitter, targets []CloneTarget) {
+	filter := NewRepositoryFilter()
+	skipped := make([][]skippedRepo, len(targets))
+	for i := range targets {
+		withCloneTarget(targets[i], func() {
+			targets[i].Repos = filter.ApplyAllFilters(targets[i].Repos)
+			skipped[i] = filter.Skipped()
+			printResourcesFound(targets[i].Repos)
+		})
+	}
+
+	printConcurrencyAutoAdjusted()
+
+	if os.Getenv("GHORG_DRY_RUN") == "true" {
+		plans := make([]dryRunPlan, len(targets))
+		for i, t := range targets {
+			withCloneTarget(t, func() {
+				plans[i] = buildDryRunPlan(git, t.Repos, skipped[i])
+			})
+		}
+		printDryRunPlans(plans)
+		return
+	}
+
+	createDirIfNotExist()
+
+	l, err := strconv.Atoi(os.Getenv("GHORG_CONCURRENCY"))
+	if err != nil {
+		log.Fatal("Could not determine GHORG_CONCURRENCY")
+	}
+
+	limit := limiter.NewConcurrencyLimiter(l)
+
+	processors := make([]*RepositoryProcessor, len(targets))
+	collisions := make([]map[string]bool, len(targets))
+	hasCollisions := make([]bool, len(targets))
+
+	for t := range targets {
+		withCloneTarget(targets[t], func() {
+			processors[t] = NewRepositoryProcessor(git)
+			collisions[t], hasCollisions[t] = hasRepoNameCollisions(targets[t].Repos)
+			processors[t].RelocateRenamedRepos(targets[t].Repos, usesRepoPathAsSlug(targets[t].CloneType))
+		})
+		repoPathAsSlug := usesRepoPathAsSlug(targets[t].CloneType)
+
+		for i := range targets[t].Repos {
+			repo := targets[t].Repos[i]
+			processor := processors[t]
+
+			repoSlug := initialRepoSlug(repo)
+
+			_, _ = limit.Execute(func() {
+				if repo.Path != "" && repoPathAsSlug {
+					repoSlug = repo.Path
+				}
+
+				processor.ProcessRepository(&repo, collisions[t], hasCollisions[t], repoSlug, i)
+			})
+		}
+	}
+
+	_ = limit.WaitAndClose()
+
+	totalDurationSeconds := int(time.Since(commandStartTime).Seconds() + 0.5) // Round to nearest second
+
+	results := make([]cloneTargetResult, len(targets))
+	for t := range targets {
+		processors[t].SetTotalDuration(totalDurationSeconds)
+		processors[t].SaveIndex()
+		results[t] = cloneTargetResult{target: targets[t], stats: processors[t].GetStats()}
+		results[t].untouchedPrunes = pruneUntouchedRepos(processors[t])
+		cloneInfos = append(cloneInfos, results[t].stats.CloneInfos...)
+		cloneErrors = append(cloneErrors, results[t].stats.CloneErrors...)
+	}
+
+	printRemainingMessages()
+
+	for t := range targets {
+		if hasCollisions[t] {
+			printCollisionsNotice(collisions[t])
+		}
+
+		withCloneTarget(targets[t], func() {
+			if os.Getenv("GHORG_PRUNE") == "true" {
+				results[t].pruneCount = pruneRepos(targets[t].Repos, processors[t].index)
+			}
+			processors[t].SaveIndex()
+
+			isDirSizeCached = false
+			if os.Getenv("GHORG_NO_DIR_SIZE") == "false" || os.Getenv("GHORG_STATS_ENABLED") == "true" {
+				_, _ = getCachedOrCalculatedOutputDirSizeInMb()
+			}
+
+			if os.Getenv("GHORG_STATS_ENABLED") == "true" {
+				stats := results[t].stats
+				date := time.Now().Format("2006-01-02 15:04:05")
+				_ = writeGhorgStats(date, len(targets[t].Repos), stats.CloneCount, stats.PulledCount, len(stats.CloneInfos), len(stats.CloneErrors), stats.UpdateRemoteCount, stats.NewCommits, results[t].pruneCount, stats.TotalDurationSeconds, hasCollisions[t])
+			}
+			results[t].dirSize = formatDirSize()
+		})
+	}
+
+	printCloneTargetsSummary(results, totalDurationSeconds)
+
+	exitWithCloneStatus(len(cloneInfos), len(cloneErrors))
+}
+
+// formatDirSize returns the cached output dir size for display, or nothing when it was not calculated
+func formatDirSize() string {
+	if !isDirSizeCached || os.Getenv("GHORG_NO_DIR_SIZE") != "false" {
+		return ""
+	}
+	if cachedDirSizeMB > 1000 {
+		return fmt.Sprintf(" (Size: %.2f GB)", cachedDirSizeMB/1000)
+	}
+	return fmt.Sprintf(" (Size: %.2f MB)", cachedDirSizeMB)
+}
+
+// printCloneTargetsSummary prints one line per target followed by the totals across all targets
+func printCloneTargetsSummary(results []cloneTargetResult, totalDurationSeconds int) {
+	var total CloneStats
+	var totalUntouchedPrunes int
+
+	if os.Getenv("GHORG_QUIET") != "true" {
+		colorlog.PrintSuccess("\n============ Summary ============\n")
+	}
+
+	for _, r := range results {
+		total.CloneCount += r.stats.CloneCount
+		total.PulledCount += r.stats.PulledCount
+		total.UpdateRemoteCount += r.stats.UpdateRemoteCount
+		total.NewCommits += r.stats.NewCommits
+		total.ProtectedCount += r.stats.ProtectedCount
+		total.SkippedWikiCount += r.stats.SkippedWikiCount
+		totalUntouchedPrunes += r.untouchedPrunes
+
+		if os.Getenv("GHORG_QUIET") == "true" {
+			continue
+		}
+
+		colorlog.PrintSuccess(fmt.Sprintf("%s: %s%s", r.target.Name, r.target.OutputDir, r.dirSize))
+		colorlog.PrintSubtleInfo(fmt.Sprintf("    new clones: %v, existing resources pulled: %v, new commits: %v, infos: %v, issues: %v", r.stats.CloneCount, r.stats.PulledCount, r.stats.NewCommits, len(r.stats.CloneInfos), len(r.stats.CloneErrors)))
+	}
+
+	fmt.Println("")
+	printCloneStatsMessage(total.CloneCount, total.PulledCount, total.UpdateRemoteCount, total.NewCommits, totalUntouchedPrunes, total.ProtectedCount, totalDurationSeconds)
+	printSkippedWikisMessage(total.SkippedWikiCount)
+}
@@ -0,0 +1,198 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gabrie30/ghorg/scm"
+)
+
+func Test_parseCloneTargets(t *testing.T) {
+	defer UnsetEnv("GHORG_")()
+	_ = os.Setenv("GHORG_ABSOLUTE_PATH_TO_CLONE_TO", "/tmp/ghorg")
+	_ = os.Setenv("GHORG_CLONE_TYPE", "org")
+
+	tests := []struct {
+		name    string
+		argz    []string
+		want    []CloneTarget
+		wantErr string
+	}{
+		{
+			name: "uses GHORG_CLONE_TYPE by default",
+			argz: []string{"OrgA", "orgB"},
+			want: []CloneTarget{
+				{Name: "OrgA", CloneType: "org", OutputDir: filepath.Join("/tmp/ghorg", "orga")},
+				{Name: "orgB", CloneType: "org", OutputDir: filepath.Join("/tmp/ghorg", "orgb")},
+			},
+		},
+		{
+			name: "mixed clone types",
+			argz: []string{"org:orgA", "user:someone"},
+			want: []CloneTarget{
+				{Name: "orgA", CloneType: "org", OutputDir: filepath.Join("/tmp/ghorg", "orga")},
+				{Name: "someone", CloneType: "user", OutputDir: filepath.Join("/tmp/ghorg", "someone")},
+			},
+		},
+		{
+			name: "starred and watched",
+			argz: []string{"starred:", "watched:someone", "org:orgA"},
+			want: []CloneTarget{
+				{Name: "", CloneType: "starred", OutputDir: filepath.Join("/tmp/ghorg", "starred")},
+				{Name: "someone", CloneType: "watched", OutputDir: filepath.Join("/tmp/ghorg", "someone_watched")},
+				{Name: "orgA", CloneType: "org", OutputDir: filepath.Join("/tmp/ghorg", "orga")},
+			},
+		},
+		{
+			name:    "unsupported clone type",
+			argz:    []string{"orgA", "team:orgB"},
+			wantErr: "unsupported clone type",
+		},
+		{
+			name:    "missing name",
+			argz:    []string{"orgA", "user:"},
+			wantErr: "missing an org or user name",
+		},
+		{
+			name:    "targets sharing an output dir",
+			argz:    []string{"orgA", "user:OrgA"},
+			wantErr: "would both be cloned into",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseCloneTargets(tt.argz)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseCloneTargets() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseCloneTargets() unexpected error: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("parseCloneTargets() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i].Name != tt.want[i].Name || got[i].CloneType != tt.want[i].CloneType || got[i].OutputDir != tt.want[i].OutputDir {
+					t.Errorf("parseCloneTargets()[%d] = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+// HostPathMockGit creates the repo directory at its HostPath on clone
+type HostPathMockGit struct {
+	MockGitClient
+}
+
+func (g HostPathMockGit) Clone(repo scm.Repo) error {
+	return os.MkdirAll(filepath.Join(repo.HostPath, ".git"), 0o755)
+}
+
+func TestCloneAllTargets_EachTargetInOwnDir(t *testing.T) {
+	defer UnsetEnv("GHORG_")()
+	dir := t.TempDir()
+
+	_ = os.Setenv("GHORG_ABSOLUTE_PATH_TO_CLONE_TO", dir)
+	_ = os.Setenv("GHORG_CONCURRENCY", "2")
+	_ = os.Setenv("GHORG_DONT_EXIT_UNDER_TEST", "true")
+	_ = os.Setenv("GHORG_NO_DIR_SIZE", "true")
+
+	targets := []CloneTarget{
+		{
+			Name:      "orgA",
+			CloneType: "org",
+			OutputDir: filepath.Join(dir, "orga"),
+			Repos: []scm.Repo{
+				{Name: "shared", URL: "https://github.com/orgA/shared.git", CloneBranch: "main"},
+				{Name: "one", URL: "https://github.com/orgA/one.git", CloneBranch: "main"},
+			},
+		},
+		{
+			Name:      "someone",
+			CloneType: "user",
+			OutputDir: filepath.Join(dir, "someone"),
+			Repos: []scm.Repo{
+				{Name: "shared", URL: "https://github.com/someone/shared.git", CloneBranch: "main"},
+			},
+		},
+	}
+
+	outputDirAbsolutePath = ""
+	commandStartTime = time.Now()
+	CloneAllTargets(HostPathMockGit{}, targets)
+
+	for _, p := range []string{"orga/shared", "orga/one", "someone/shared"} {
+		if _, err := os.Stat(filepath.Join(dir, p)); err != nil {
+			t.Errorf("expected %s to be cloned: %v", p, err)
+		}
+	}
+
+	// the repos are processed after the per target globals are restored, so each index must record its own target
+	for _, target := range targets {
+		index, err := loadRepoIndex(target.OutputDir)
+		if err != nil {
+			t.Fatalf("could not load the index of %s: %v", target.Name, err)
+		}
+		entry, ok := index.lookup("shared")
+		if !ok || entry.Target != target.Name || entry.CloneType != target.CloneType {
+			t.Errorf("expected shared in %s to be recorded with target %s and clone type %s, got %+v", target.OutputDir, target.Name, target.CloneType, entry)
+		}
+	}
+
+	if outputDirAbsolutePath != "" {
+		t.Errorf("outputDirAbsolutePath was not restored, got %q", outputDirAbsolutePath)
+	}
+
+	if len(cloneErrors) != 0 {
+		t.Errorf("unexpected clone errors: %v", cloneErrors)
+	}
+}
+
+func TestGetMembershipCloneTargets_UnsupportedScm(t *testing.T) {
+	defer UnsetEnv("GHORG_")()
+	_ = os.Setenv("GHORG_SCM_TYPE", "sourcehut")
+
+	_, err := getMembershipCloneTargets()
+	if err == nil || !strings.Contains(err.Error(), "not supported for sourcehut") {
+		t.Errorf("Expected memberships to be unsupported for sourcehut, got: %v", err)
+	}
+}
+
+func TestCloneAllTargets_StarredOrganizedByOwner(t *testing.T) {
+	defer UnsetEnv("GHORG_")()
+	dir := t.TempDir()
+
+	_ = os.Setenv("GHORG_ABSOLUTE_PATH_TO_CLONE_TO", dir)
+	_ = os.Setenv("GHORG_CONCURRENCY", "2")
+	_ = os.Setenv("GHORG_DONT_EXIT_UNDER_TEST", "true")
+	_ = os.Setenv("GHORG_NO_DIR_SIZE", "true")
+
+	targets := []CloneTarget{
+		{
+			Name:      "",
+			CloneType: "starred",
+			OutputDir: filepath.Join(dir, "starred"),
+			Repos: []scm.Repo{
+				{Name: "tools", Path: "a/tools", URL: "https://github.com/a/tools.git", CloneBranch: "main"},
+				{Name: "tools", Path: "b/tools", URL: "https://github.com/b/tools.git", CloneBranch: "main"},
+			},
+		},
+	}
+
+	commandStartTime = time.Now()
+	CloneAllTargets(HostPathMockGit{}, targets)
+
+	for _, p := range []string{"starred/a/tools", "starred/b/tools"} {
+		if _, err := os.Stat(filepath.Join(dir, p)); err != nil {
+			t.Errorf("expected %s to be cloned: %v", p, err)
+		}
+	}
+}
@@ -161,6 +161,10 @@ func (g MockGitClient) GetCurrentBranch(repo scm.Repo) (string, error) {
 	return "main", nil
 }
 
+func (g MockGitClient) GetRemoteURL(repo scm.Repo) (string, error) {
+	return repo.URL, nil
+}
+
 func (g MockGitClient) Clean(repo scm.Repo) error {
 	return nil
 }
@@ -205,6 +209,18 @@ func (g MockGitClient) LfsFetchAll(repo scm.Repo) error {
 	return nil
 }
 
+func (g MockGitClient) Maintain(repo scm.Repo, task string) error {
+	return nil
+}
+
+func (g MockGitClient) SparseCheckout(repo scm.Repo) error {
+	return nil
+}
+
+func (g MockGitClient) LastCommitDate(repo scm.Repo) (time.Time, error) {
+	return time.Time{}, nil
+}
+
 func TestInitialClone(t *testing.T) {
 	defer UnsetEnv("GHORG_")()
 	dir, err := os.MkdirTemp("", "ghorg_test_initial")
@@ -745,7 +761,7 @@ func TestPruneRepos(t *testing.T) {
 		t.Fatalf("Failed to create directory: %v", err)
 	}
 
-	pruneRepos(cloneTargets)
+	pruneRepos(cloneTargets, nil)
 
 	if _, err := os.Stat(repository); os.IsNotExist(err) {
 		t.Errorf("Expected '%s' to exist, but it was deleted", repository)
@@ -1264,7 +1280,7 @@ func previewProtectLocalSetup(t *testing.T, createRepoDirs []string) (string, fu
 	return dir, func() { _ = os.RemoveAll(dir) }
 }
 
-func TestPreviewProtectLocal_SkipsDirtyLocalRepo(t *testing.T) {
+func TestDryRunPlan_ProtectLocal_SkipsDirtyLocalRepo(t *testing.T) {
 	defer UnsetEnv("GHORG_")()
 	_ = os.Setenv("GHORG_PROTECT_LOCAL", "true")
 	_ = os.Setenv("GHORG_DRY_RUN", "true")
@@ -1284,13 +1300,13 @@ func TestPreviewProtectLocal_SkipsDirtyLocalRepo(t *testing.T) {
 		},
 	}
 
-	got := previewProtectLocal(mockGit, repos)
+	got := buildDryRunPlan(mockGit, repos, nil).Summary.ProtectLocal
 	if got != 1 {
-		t.Errorf("previewProtectLocal returned %d, want 1 (dirty repo should be reported)", got)
+		t.Errorf("plan has %d protect-local entries, want 1 (dirty repo should be reported)", got)
 	}
 }
 
-func TestPreviewProtectLocal_SkipsUnpushedLocalRepo(t *testing.T) {
+func TestDryRunPlan_ProtectLocal_SkipsUnpushedLocalRepo(t *testing.T) {
 	defer UnsetEnv("GHORG_")()
 	_ = os.Setenv("GHORG_PROTECT_LOCAL", "true")
 	_ = os.Setenv("GHORG_DRY_RUN", "true")
@@ -1310,13 +1326,13 @@ func TestPreviewProtectLocal_SkipsUnpushedLocalRepo(t *testing.T) {
 		},
 	}
 
-	got := previewProtectLocal(mockGit, repos)
+	got := buildDryRunPlan(mockGit, repos, nil).Summary.ProtectLocal
 	if got != 1 {
-		t.Errorf("previewProtectLocal returned %d, want 1 (repo with unpushed commits should be reported)", got)
+		t.Errorf("plan has %d protect-local entries, want 1 (repo with unpushed commits should be reported)", got)
 	}
 }
 
-func TestPreviewProtectLocal_CleanLocalRepoNotReported(t *testing.T) {
+func TestDryRunPlan_ProtectLocal_CleanLocalRepoNotReported(t *testing.T) {
 	defer UnsetEnv("GHORG_")()
 	_ = os.Setenv("GHORG_PROTECT_LOCAL", "true")
 	_ = os.Setenv("GHORG_DRY_RUN", "true")
@@ -1335,13 +1351,13 @@ func TestPreviewProtectLocal_CleanLocalRepoNotReported(t *testing.T) {
 		},
 	}
 
-	got := previewProtectLocal(mockGit, repos)
+	got := buildDryRunPlan(mockGit, repos, nil).Summary.ProtectLocal
 	if got != 0 {
-		t.Errorf("previewProtectLocal returned %d, want 0 (clean repo should not be reported)", got)
+		t.Errorf("plan has %d protect-local entries, want 0 (clean repo should not be reported)", got)
 	}
 }
 
-func TestPreviewProtectLocal_NonexistentRepoNotReported(t *testing.T) {
+func TestDryRunPlan_ProtectLocal_NonexistentRepoNotReported(t *testing.T) {
 	defer UnsetEnv("GHORG_")()
 	_ = os.Setenv("GHORG_PROTECT_LOCAL", "true")
 	_ = os.Setenv("GHORG_DRY_RUN", "true")
@@ -1362,22 +1378,22 @@ func TestPreviewProtectLocal_NonexistentRepoNotReported(t *testing.T) {
 		},
 	}
 
-	got := previewProtectLocal(mockGit, repos)
+	got := buildDryRunPlan(mockGit, repos, nil).Summary.ProtectLocal
 	if got != 0 {
-		t.Errorf("previewProtectLocal returned %d, want 0 (nonexistent local repo must not be inspected)", got)
+		t.Errorf("plan has %d protect-local entries, want 0 (nonexistent local repo must not be inspected)", got)
 	}
 }
 
-func TestPreviewProtectLocal_EmptyRepoListReturnsZero(t *testing.T) {
+func TestDryRunPlan_ProtectLocal_EmptyRepoListReturnsZero(t *testing.T) {
 	defer UnsetEnv("GHORG_")()
 	_ = os.Setenv("GHORG_PROTECT_LOCAL", "true")
 
 	mockGit := NewExtendedMockGit()
-	if got := previewProtectLocal(mockGit, nil); got != 0 {
-		t.Errorf("previewProtectLocal with nil slice returned %d, want 0", got)
+	if got := buildDryRunPlan(mockGit, nil, nil).Summary.ProtectLocal; got != 0 {
+		t.Errorf("plan for nil repos has %d protect-local entries, want 0", got)
 	}
-	if got := previewProtectLocal(mockGit, []scm.Repo{}); got != 0 {
-		t.Errorf("previewProtectLocal with empty slice returned %d, want 0", got)
+	if got := buildDryRunPlan(mockGit, []scm.Repo{}, nil).Summary.ProtectLocal; got != 0 {
+		t.Errorf("plan for empty repos has %d protect-local entries, want 0", got)
 	}
 }
 
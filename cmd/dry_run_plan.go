@@ -0,0 +1,373 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	fatihcolor "github.com/fatih/color"
+	"github.com/gabrie30/ghorg/colorlog"
+	"github.com/gabrie30/ghorg/git"
+	"github.com/gabrie30/ghorg/scm"
+)
+
+// planAction is what a clone would do with a repo or, for prunes, a local directory
+type planAction string
+
+const (
+	planClone        planAction = "clone"
+	planPull         planAction = "pull"
+	planFetch        planAction = "fetch"
+	planUpdateBackup planAction = "update-backup"
+	planSkip         planAction = "skip"
+	planProtectLocal planAction = "protect-local"
+	planPrune        planAction = "prune"
+)
+
+// planEntry is a single line of the dry-run plan
+type planEntry struct {
+	Action planAction `json:"action"`
+	Name   string     `json:"name"`
+	URL    string     `json:"url,omitempty"`
+	// Path is where the repo is or would be cloned to, it's unknown for repos skipped by a filter
+	Path   string `json:"path,omitempty"`
+	Branch string `json:"branch,omitempty"`
+	// CurrentBranch is set when pulling would switch the local clone to Branch
+	CurrentBranch string `json:"current_branch,omitempty"`
+	// CurrentRemoteURL is set when the origin of the local clone would be changed to URL
+	CurrentRemoteURL string `json:"current_remote_url,omitempty"`
+	// MovedFrom is set when the local clone would be moved to Path because the repo was renamed or moved on the scm
+	MovedFrom string `json:"moved_from,omitempty"`
+	// Filter is the filter that skipped the repo
+	Filter string `json:"filter,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// planSummary counts the entries of a plan, branch switches, remote url updates and moves are part of pulls
+type planSummary struct {
+	Clone           int `json:"clone"`
+	Pull            int `json:"pull"`
+	Fetch           int `json:"fetch"`
+	UpdateBackup    int `json:"update_backup"`
+	BranchSwitch    int `json:"branch_switch"`
+	RemoteURLUpdate int `json:"remote_url_update"`
+	Move            int `json:"move"`
+	Skip            int `json:"skip"`
+	ProtectLocal    int `json:"protect_local"`
+	Prune           int `json:"prune"`
+}
+
+func (s *planSummary) add(o planSummary) {
+	s.Clone += o.Clone
+	s.Pull += o.Pull
+	s.Fetch += o.Fetch
+	s.UpdateBackup += o.UpdateBackup
+	s.BranchSwitch += o.BranchSwitch
+	s.RemoteURLUpdate += o.RemoteURLUpdate
+	s.Move += o.Move
+	s.Skip += o.Skip
+	s.ProtectLocal += o.ProtectLocal
+	s.Prune += o.Prune
+}
+
+// dryRunPlan is everything a clone of one target would do
+type dryRunPlan struct {
+	Target    string      `json:"target"`
+	OutputDir string      `json:"output_dir"`
+	Summary   planSummary `json:"summary"`
+	Repos     []planEntry `json:"repos"`
+}
+
+func (p *dryRunPlan) add(e planEntry) {
+	p.Repos = append(p.Repos, e)
+
+	switch e.Action {
+	case planClone:
+		p.Summary.Clone++
+	case planPull:
+		p.Summary.Pull++
+	case planFetch:
+		p.Summary.Fetch++
+	case planUpdateBackup:
+		p.Summary.UpdateBackup++
+	case planSkip:
+		p.Summary.Skip++
+	case planProtectLocal:
+		p.Summary.ProtectLocal++
+	case planPrune:
+		p.Summary.Prune++
+	}
+	if e.CurrentBranch != "" {
+		p.Summary.BranchSwitch++
+	}
+	if e.CurrentRemoteURL != "" {
+		p.Summary.RemoteURLUpdate++
+	}
+	if e.MovedFrom != "" {
+		p.Summary.Move++
+	}
+}
+
+// dryRunPlanOutput is where the plan is written, it stays stdout when everything else is sent to stderr for --output=json
+var dryRunPlanOutput io.Writer = os.Stdout
+
+// isJSONOutput reports whether --output=json was set
+func isJSONOutput() bool {
+	return os.Getenv("GHORG_OUTPUT") == "json"
+}
+
+// sendLogsToStderr moves all other output to stderr so json on stdout can be piped into other tools, it
+// returns the original stdout to write the json to
+func sendLogsToStderr() io.Writer {
+	stdout := os.Stdout
+	os.Stdout = os.Stderr
+	fatihcolor.Output = os.Stderr
+	return stdout
+}
+
+// buildDryRunPlan works out what cloning repos into outputDirAbsolutePath would do. Local clones are
+// inspected the same way a real run does, resolving each HostPath through the RepositoryProcessor.
+func buildDryRunPlan(gitter git.Gitter, repos []scm.Repo, skipped []skippedRepo) dryRunPlan {
+	plan := dryRunPlan{Target: targetCloneSource, OutputDir: outputDirAbsolutePath, Repos: []planEntry{}}
+
+	processor := NewRepositoryProcessor(gitter)
+	hostPaths := processor.plannedHostPaths(repos, usesRepoPathAsSlug(os.Getenv("GHORG_CLONE_TYPE")))
+
+	movedFrom := make(map[int]string)
+	for _, relocation := range processor.index.findRelocations(repos, hostPaths) {
+		movedFrom[relocation.Repo] = relocation.From
+	}
+
+	for i := range repos {
+		repo := repos[i]
+		repo.HostPath = hostPaths[i]
+
+		entry := planEntry{Name: repoDisplayName(repo), URL: repo.URL, Path: repo.HostPath, Branch: repo.CloneBranch}
+
+		// a renamed repo is inspected where it's cloned now, it's moved before it's pulled
+		if from, ok := movedFrom[i]; ok {
+			entry.MovedFrom = from
+			repo.HostPath = from
+		}
+
+		if !repoExistsLocally(repo) {
+			entry.Action = planClone
+			plan.add(entry)
+			continue
+		}
+
+		if shouldPreviewProtectLocal() {
+			hasChanges, reason, err := processor.hasLocalChanges(&repo)
+			if err != nil {
+				colorlog.PrintInfo(fmt.Sprintf("Could not check for local changes on %s: %v", repo.Name, err))
+			} else if hasChanges {
+				entry.Action = planProtectLocal
+				entry.Reason = reason
+				plan.add(entry)
+				continue
+			}
+		}
+
+		switch {
+		case os.Getenv("GHORG_BACKUP") == "true":
+			entry.Action = planUpdateBackup
+		case os.Getenv("GHORG_NO_CLEAN") == "true":
+			entry.Action = planFetch
+		default:
+			entry.Action = planPull
+			// --protect-local checks the original branch out again after pulling
+			if os.Getenv("GHORG_PROTECT_LOCAL") != "true" {
+				if current, err := gitter.GetCurrentBranch(repo); err == nil && current != "" && current != repo.CloneBranch {
+					entry.CurrentBranch = current
+				}
+			}
+		}
+
+		if current, err := gitter.GetRemoteURL(repo); err == nil && current != "" && current != repo.URL {
+			entry.CurrentRemoteURL = redactURL(current)
+		}
+
+		plan.add(entry)
+	}
+
+	for _, s := range skipped {
+		plan.add(planEntry{Action: planSkip, Name: repoDisplayName(s.Repo), URL: s.Repo.URL, Filter: s.Filter, Reason: s.Reason})
+	}
+
+	if os.Getenv("GHORG_PRUNE") == "true" {
+		// there's nothing to prune when the clone directory doesn't exist yet
+		if stat, err := os.Stat(outputDirAbsolutePath); err == nil && stat.IsDir() {
+			repositories, err := getRelativePathRepositories(outputDirAbsolutePath)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			for _, repository := range repositories {
+				if !repoStillInRemote(processor.index, repository, repos) {
+					plan.add(planEntry{Action: planPrune, Name: filepath.ToSlash(repository), Path: filepath.Join(outputDirAbsolutePath, repository), Reason: "not found in remote"})
+				}
+			}
+		}
+	}
+
+	return plan
+}
+
+// repoDisplayName returns the name a repo is shown with in the plan, the path within the scm when known
+func repoDisplayName(repo scm.Repo) string {
+	if repo.Path != "" {
+		return strings.TrimPrefix(repo.Path, "/")
+	}
+	return repo.Name
+}
+
+// redactURL removes credentials from a remote url before it's printed
+func redactURL(remote string) string {
+	u, err := url.Parse(remote)
+	if err != nil || u.User == nil || u.Host == "" {
+		return remote
+	}
+	if u.Scheme == "http" || u.Scheme == "https" {
+		u.User = nil
+	} else if _, hasPassword := u.User.Password(); hasPassword {
+		u.User = url.User(u.User.Username())
+	}
+	return u.String()
+}
+
+// printDryRunPlans prints the plans as a human readable list or, with --output=json, as one json document
+func printDryRunPlans(plans []dryRunPlan) {
+	total := planSummary{}
+	for _, p := range plans {
+		total.add(p.Summary)
+	}
+
+	// repos these filters drop are never returned by the scm, so they can't be listed as skipped
+	listing := listingFilters()
+
+	if isJSONOutput() {
+		doc := struct {
+			Summary        planSummary  `json:"summary"`
+			ListingFilters []string     `json:"listing_filters,omitempty"`
+			Targets        []dryRunPlan `json:"targets"`
+		}{Summary: total, ListingFilters: listing, Targets: plans}
+
+		data, err := json.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			colorlog.PrintErrorAndExit(fmt.Sprintf("Could not encode the dry-run plan: %v", err))
+		}
+		_, _ = fmt.Fprintln(dryRunPlanOutput, string(data))
+		return
+	}
+
+	for _, p := range plans {
+		printDryRunPlan(p)
+	}
+
+	if len(listing) > 0 {
+		colorlog.PrintSubtleInfo(fmt.Sprintf("Repos left out by --%s while listing them from the scm are not shown as skipped", strings.Join(listing, ", --")))
+	}
+}
+
+// listingFilters returns the filters of this clone that scm clients apply while listing repos, named by their flag
+func listingFilters() []string {
+	filters := []string{}
+	if os.Getenv("GHORG_SKIP_ARCHIVED") == "true" {
+		filters = append(filters, "skip-archived")
+	}
+	if os.Getenv("GHORG_SKIP_FORKS") == "true" {
+		filters = append(filters, "skip-forks")
+	}
+	if os.Getenv("GHORG_FILTER_LANGUAGE") != "" || os.Getenv("GHORG_GITHUB_FILTER_LANGUAGE") != "" {
+		filters = append(filters, "filter-language")
+	}
+
+	for _, f := range []struct{ flag, env string }{
+		{"topics", "GHORG_TOPICS"},
+		{"gitlab-group-match-regex", "GHORG_GITLAB_GROUP_MATCH_REGEX"},
+		{"gitlab-group-exclude-match-regex", "GHORG_GITLAB_GROUP_EXCLUDE_MATCH_REGEX"},
+		{"gist-description-regex", "GHORG_GIST_DESCRIPTION_REGEX"},
+		{"gist-file-extensions", "GHORG_GIST_FILE_EXTENSIONS"},
+		{"gist-updated-since", "GHORG_GIST_UPDATED_SINCE"},
+	} {
+		if os.Getenv(f.env) != "" {
+			filters = append(filters, f.flag)
+		}
+	}
+	if visibility := os.Getenv("GHORG_GIST_VISIBILITY"); visibility != "" && visibility != "all" {
+		filters = append(filters, "gist-visibility")
+	}
+
+	return filters
+}
+
+// printDryRunPlan prints the plan of one target, one line per repo
+func printDryRunPlan(plan dryRunPlan) {
+	colorlog.PrintInfo(fmt.Sprintf("\nPlan for %s (%s):", plan.Target, plan.OutputDir))
+
+	for _, e := range plan.Repos {
+		line := fmt.Sprintf("  %-14s %s", e.Action, e.Name)
+
+		var details []string
+		if e.CurrentBranch != "" {
+			details = append(details, fmt.Sprintf("switch branch %s -> %s", e.CurrentBranch, e.Branch))
+		}
+		if e.MovedFrom != "" {
+			details = append(details, fmt.Sprintf("move %s -> %s", e.MovedFrom, e.Path))
+		}
+		if e.CurrentRemoteURL != "" {
+			details = append(details, fmt.Sprintf("update remote url %s -> %s", e.CurrentRemoteURL, e.URL))
+		}
+		if e.Filter != "" {
+			details = append(details, "--"+e.Filter)
+		}
+		if e.Reason != "" {
+			details = append(details, e.Reason)
+		}
+		if len(details) > 0 {
+			line += " (" + strings.Join(details, ", ") + ")"
+		}
+
+		switch e.Action {
+		case planPrune:
+			colorlog.PrintError(line)
+		case planSkip, planProtectLocal:
+			colorlog.PrintSubtleInfo(line)
+		default:
+			colorlog.PrintInfo(line)
+		}
+	}
+
+	colorlog.PrintSuccess(formatPlanSummary(plan.Summary))
+}
+
+// formatPlanSummary describes a summary in one line, leaving out actions that don't happen
+func formatPlanSummary(s planSummary) string {
+	parts := []string{fmt.Sprintf("%d to clone", s.Clone), fmt.Sprintf("%d to pull", s.Pull)}
+
+	optional := []struct {
+		count int
+		text  string
+	}{
+		{s.Fetch, "to fetch"},
+		{s.UpdateBackup, "backups to update"},
+		{s.BranchSwitch, "branch switches"},
+		{s.RemoteURLUpdate, "remote url updates"},
+		{s.Move, "renamed repos to move"},
+		{s.Skip, "skipped by filters"},
+		{s.ProtectLocal, "protected by --protect-local"},
+		{s.Prune, "to prune"},
+	}
+	for _, o := range optional {
+		if o.count > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", o.count, o.text))
+		}
+	}
+
+	return "Plan: " + strings.Join(parts, ", ")
+}
@@ -0,0 +1,592 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gabrie30/ghorg/colorlog"
+	"github.com/gabrie30/ghorg/configs"
+	"github.com/gabrie30/ghorg/git"
+	"github.com/gabrie30/ghorg/scm"
+	"github.com/gabrie30/ghorg/utils"
+	"github.com/spf13/cobra"
+)
+
+var duCmd = &cobra.Command{
+	Use:   "du [dir]",
+	Short: "Show which cloned repos use the most disk and how to reduce it",
+	Long: `Reports the size of every repo in a clone directory, or in GHORG_ABSOLUTE_PATH_TO_CLONE_TO when no dir is given, split into .git objects, LFS files, the checked out worktree and untracked files like build artifacts. The largest repos are listed first along with reductions that would shrink them.
+
+Reductions are only suggested unless they are named in --apply:
+  gc       run git gc to pack loose objects
+  lfs      run git lfs prune to drop LFS files that are no longer checked out
+  partial  convert to a partial clone that downloads blobs on demand, requires git 2.43+
+  shallow  convert to a shallow clone that keeps only the latest commit of each remote branch
+
+partial and shallow are skipped for repos with uncommitted changes or commits that have not been pushed.`,
+	Example: `  ghorg du
+  ghorg du my-org --top=10
+  ghorg du my-org --apply=gc,lfs`,
+	Run: duFunc,
+}
+
+// duReductions are the reductions du can suggest and apply, in the order they are applied
+var duReductions = []string{"gc", "lfs", "partial", "shallow"}
+
+const (
+	// duGcLooseObjects and duGcPacks are how many loose objects or packs it takes for du to suggest git gc
+	duGcLooseObjects = 1000
+	duGcPacks        = 10
+	// duHistoryMinBytes is how large the object store has to be, and be more than twice the worktree, for
+	// du to suggest converting to a partial or shallow clone
+	duHistoryMinBytes = 100 * 1000 * 1000
+)
+
+// duSuggestion is a reduction du suggests for a repo, along with why and the commands it would run
+type duSuggestion struct {
+	Reduction string `json:"reduction"`
+	Reason    string `json:"reason"`
+	Command   string `json:"command"`
+}
+
+// repoDiskUsage is the disk usage of one repo, sizes are in bytes. Git is the .git directory without
+// LFS files, or .hg for mercurial repos, and Worktree only counts tracked files.
+type repoDiskUsage struct {
+	Path         string         `json:"path"`
+	VCS          string         `json:"vcs"`
+	Total        int64          `json:"total_bytes"`
+	Git          int64          `json:"git_bytes"`
+	LFS          int64          `json:"lfs_bytes"`
+	Worktree     int64          `json:"worktree_bytes"`
+	Untracked    int64          `json:"untracked_bytes"`
+	LooseObjects int            `json:"loose_objects"`
+	Packs        int            `json:"packs"`
+	Garbage      int            `json:"-"`
+	Partial      bool           `json:"partial_clone"`
+	Shallow      bool           `json:"shallow_clone"`
+	Suggestions  []duSuggestion `json:"suggestions"`
+	Applied      []string       `json:"applied,omitempty"`
+	Reclaimed    int64          `json:"reclaimed_bytes,omitempty"`
+	err          error
+}
+
+// duOptions are the flags of du
+type duOptions struct {
+	top    int
+	apply  []string
+	output string
+}
+
+func duFunc(cmd *cobra.Command, argz []string) {
+	opts := duOptions{}
+	opts.top, _ = cmd.Flags().GetInt("top")
+	opts.output, _ = cmd.Flags().GetString("output")
+	apply, _ := cmd.Flags().GetStringSlice("apply")
+
+	if opts.output != "text" && opts.output != "json" {
+		colorlog.PrintErrorAndExit("--output must be text or json, got: " + opts.output)
+	}
+
+	var err error
+	opts.apply, err = parseDuApply(apply)
+	if err != nil {
+		colorlog.PrintErrorAndExit(fmt.Sprintf("Invalid --apply: %v", err))
+	}
+
+	out := io.Writer(os.Stdout)
+	if opts.output == "json" {
+		// only the report is written to stdout so it can be piped into other tools
+		out = sendLogsToStderr()
+	}
+
+	dirs := []string{os.Getenv("GHORG_ABSOLUTE_PATH_TO_CLONE_TO")}
+	if len(argz) > 0 {
+		dirs = []string{}
+		for _, arg := range argz {
+			dir, err := resolveCloneDir(arg)
+			if err != nil {
+				colorlog.PrintErrorAndExit(fmt.Sprintf("Could not find the clone directory %s: %v", arg, err))
+			}
+			dirs = append(dirs, dir)
+		}
+	}
+
+	repoDirs := []string{}
+	for _, dir := range dirs {
+		found, err := findLocalRepos(dir)
+		if err != nil {
+			colorlog.PrintError(fmt.Sprintf("Error finding repos in %s: %v", dir, err))
+		}
+		repoDirs = append(repoDirs, found...)
+	}
+	if len(repoDirs) == 0 {
+		colorlog.PrintError("No clones found. Please clone some and try again.")
+		return
+	}
+
+	if opts.output == "text" {
+		spinningSpinner.Start()
+	}
+	usages := measureDiskUsage(git.NewGit(), repoDirs)
+	spinningSpinner.Stop()
+
+	measured := []repoDiskUsage{}
+	for _, usage := range usages {
+		if usage.err != nil {
+			colorlog.PrintError(fmt.Sprintf("Error measuring %s: %v", usage.Path, usage.err))
+			continue
+		}
+		measured = append(measured, usage)
+	}
+	rankDiskUsage(measured)
+
+	if len(opts.apply) > 0 {
+		applyReductions(git.NewGit(), duCloneURLAuthenticator(opts.apply), measured, opts.apply)
+	}
+
+	if err := printDiskUsage(out, measured, opts); err != nil {
+		colorlog.PrintErrorAndExit(fmt.Sprintf("Could not write the report: %v", err))
+	}
+}
+
+// parseDuApply checks the --apply reductions, partial and shallow are alternatives so only one can be applied
+func parseDuApply(apply []string) ([]string, error) {
+	reductions := []string{}
+	for _, reduction := range apply {
+		reduction = strings.ToLower(strings.TrimSpace(reduction))
+		if reduction == "" {
+			continue
+		}
+		if !slices.Contains(duReductions, reduction) {
+			return nil, fmt.Errorf("unknown reduction %q, must be one of %s", reduction, strings.Join(duReductions, ", "))
+		}
+		if !slices.Contains(reductions, reduction) {
+			reductions = append(reductions, reduction)
+		}
+	}
+	if slices.Contains(reductions, "partial") && slices.Contains(reductions, "shallow") {
+		return nil, fmt.Errorf("partial and shallow can't be applied together, choose one")
+	}
+	return reductions, nil
+}
+
+// findLocalRepos returns the git and mercurial repos in dir, repos are not searched for nested repos
+func findLocalRepos(dir string) ([]string, error) {
+	repos := []string{}
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() {
+			return nil
+		}
+		if isMetadataDir(path) {
+			return filepath.SkipDir
+		}
+		if isGitRepository(path) {
+			repos = append(repos, path)
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	return repos, err
+}
+
+// measureDiskUsage measures repos concurrently, GHORG_CONCURRENCY repos are measured at once
+func measureDiskUsage(reclaimer git.SpaceReclaimer, repoDirs []string) []repoDiskUsage {
+	sem := make(chan struct{}, lsConcurrency())
+	usages := make([]repoDiskUsage, len(repoDirs))
+
+	var wg sync.WaitGroup
+	for i, dir := range repoDirs {
+		wg.Add(1)
+		go func(i int, dir string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			usages[i] = measureRepoDiskUsage(reclaimer, dir)
+		}(i, dir)
+	}
+	wg.Wait()
+
+	return usages
+}
+
+// measureRepoDiskUsage walks a repo once and sorts each file into the object store, LFS, untracked or
+// tracked worktree files, then works out which reductions would help
+func measureRepoDiskUsage(reclaimer git.SpaceReclaimer, dir string) repoDiskUsage {
+	usage := repoDiskUsage{Path: dir, VCS: scm.VCSGit, Suggestions: []duSuggestion{}}
+	repo := scm.Repo{HostPath: dir}
+
+	metaDir := ".git"
+	if _, err := os.Stat(filepath.Join(dir, ".hg")); err == nil {
+		usage.VCS = scm.VCSHg
+		metaDir = ".hg"
+	}
+
+	untracked := duUntrackedSet{files: map[string]bool{}, dirs: map[string]bool{}}
+	if usage.VCS == scm.VCSGit {
+		paths, err := reclaimer.UntrackedPaths(repo)
+		if err != nil {
+			return repoDiskUsage{Path: dir, err: err}
+		}
+		untracked.add(paths)
+	}
+
+	var gitBytes, lfsBytes, worktreeBytes, untrackedBytes atomic.Int64
+	err := utils.WalkFileSizes(dir, func(path string, size int64) {
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return
+		}
+		rel = filepath.ToSlash(rel)
+
+		switch {
+		case strings.HasPrefix(rel, metaDir+"/lfs/"):
+			lfsBytes.Add(size)
+		case rel == metaDir || strings.HasPrefix(rel, metaDir+"/"):
+			gitBytes.Add(size)
+		case untracked.contains(rel):
+			untrackedBytes.Add(size)
+		default:
+			worktreeBytes.Add(size)
+		}
+	})
+	if err != nil {
+		return repoDiskUsage{Path: dir, err: err}
+	}
+
+	usage.Git = gitBytes.Load()
+	usage.LFS = lfsBytes.Load()
+	usage.Worktree = worktreeBytes.Load()
+	usage.Untracked = untrackedBytes.Load()
+	usage.Total = usage.Git + usage.LFS + usage.Worktree + usage.Untracked
+
+	if usage.VCS != scm.VCSGit {
+		return usage
+	}
+
+	counts, err := reclaimer.CountObjects(repo)
+	if err != nil {
+		return repoDiskUsage{Path: dir, err: err}
+	}
+	usage.LooseObjects = counts.LooseObjects
+	usage.Packs = counts.Packs
+	usage.Garbage = counts.Garbage
+
+	usage.Partial, err = reclaimer.IsPartialClone(repo)
+	if err != nil {
+		return repoDiskUsage{Path: dir, err: err}
+	}
+	usage.Shallow = reclaimer.IsShallowClone(repo)
+
+	usage.Suggestions = suggestReductions(usage)
+	return usage
+}
+
+// duUntrackedSet holds the untracked paths git reported, directories end in a slash
+type duUntrackedSet struct {
+	files map[string]bool
+	dirs  map[string]bool
+}
+
+func (s duUntrackedSet) add(paths []string) {
+	for _, path := range paths {
+		if strings.HasSuffix(path, "/") {
+			s.dirs[path] = true
+		} else {
+			s.files[path] = true
+		}
+	}
+}
+
+// contains reports whether the slash separated path is untracked or inside an untracked directory
+func (s duUntrackedSet) contains(path string) bool {
+	if s.files[path] {
+		return true
+	}
+	for i := 0; i < len(path); i++ {
+		if path[i] == '/' && s.dirs[path[:i+1]] {
+			return true
+		}
+	}
+	return false
+}
+
+// suggestReductions works out which reductions would shrink a git repo
+func suggestReductions(usage repoDiskUsage) []duSuggestion {
+	suggestions := []duSuggestion{}
+
+	// the reason only names what crossed its threshold
+	gcReasons := []string{}
+	if usage.LooseObjects >= duGcLooseObjects {
+		gcReasons = append(gcReasons, fmt.Sprintf("%d loose objects", usage.LooseObjects))
+	}
+	if usage.Packs >= duGcPacks {
+		gcReasons = append(gcReasons, fmt.Sprintf("%d packs", usage.Packs))
+	}
+	if usage.Garbage > 0 {
+		gcReasons = append(gcReasons, "garbage files in the object store")
+	}
+	if len(gcReasons) > 0 {
+		suggestions = append(suggestions, duSuggestion{
+			Reduction: "gc",
+			Reason:    strings.Join(gcReasons, " and "),
+			Command:   "git gc",
+		})
+	}
+
+	if usage.LFS > 0 {
+		suggestions = append(suggestions, duSuggestion{
+			Reduction: "lfs",
+			Reason:    fmt.Sprintf("%s of cached LFS files", formatBytes(usage.LFS)),
+			Command:   "git lfs prune",
+		})
+	}
+
+	if !usage.Partial && !usage.Shallow && usage.Git >= duHistoryMinBytes && usage.Git > 2*usage.Worktree {
+		reason := fmt.Sprintf("history is %s, more than twice the %s checkout", formatBytes(usage.Git), formatBytes(usage.Worktree))
+		suggestions = append(suggestions,
+			duSuggestion{
+				Reduction: "partial",
+				Reason:    reason,
+				Command:   "git config remote.origin.promisor true && git config remote.origin.partialclonefilter blob:none && git repack -a -d --filter=blob:none",
+			},
+			duSuggestion{
+				Reduction: "shallow",
+				Reason:    reason,
+				Command:   "git fetch --depth=1 origin && git reflog expire --expire=now --all && git gc --prune=now",
+			},
+		)
+	}
+
+	return suggestions
+}
+
+// rankDiskUsage sorts repos from largest to smallest
+func rankDiskUsage(usages []repoDiskUsage) {
+	slices.SortStableFunc(usages, func(a, b repoDiskUsage) int {
+		if a.Total != b.Total {
+			return compareInts(b.Total, a.Total)
+		}
+		return strings.Compare(a.Path, b.Path)
+	})
+}
+
+// applyReductions applies the suggested reductions named in apply and measures how much was reclaimed.
+// partial and shallow drop local objects, so repos with uncommitted changes or unpushed commits are skipped.
+func applyReductions(reclaimer git.SpaceReclaimer, authenticator scm.CloneURLAuthenticator, usages []repoDiskUsage, apply []string) {
+	sem := make(chan struct{}, lsConcurrency())
+
+	var wg sync.WaitGroup
+	for i := range usages {
+		wg.Add(1)
+		go func(usage *repoDiskUsage) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			applyRepoReductions(reclaimer, authenticator, usage, apply)
+		}(&usages[i])
+	}
+	wg.Wait()
+}
+
+func applyRepoReductions(reclaimer git.SpaceReclaimer, authenticator scm.CloneURLAuthenticator, usage *repoDiskUsage, apply []string) {
+	repo := scm.Repo{HostPath: usage.Path}
+
+	for _, reduction := range duReductions {
+		if !slices.Contains(apply, reduction) || !slices.ContainsFunc(usage.Suggestions, func(s duSuggestion) bool { return s.Reduction == reduction }) {
+			continue
+		}
+
+		var err error
+		switch reduction {
+		case "gc":
+			err = reclaimer.Gc(repo)
+		case "lfs":
+			err = reclaimer.LfsPrune(repo)
+		case "partial", "shallow":
+			if reason := unsafeToDropHistory(reclaimer, repo); reason != "" {
+				colorlog.PrintSubtleInfo(fmt.Sprintf("Skipped converting %s to a %s clone, %s", usage.Path, reduction, reason))
+				continue
+			}
+			if reduction == "partial" {
+				err = reclaimer.ConvertToPartialClone(repo)
+			} else {
+				err = convertToShallowClone(reclaimer, authenticator, repo)
+			}
+		}
+
+		if err != nil {
+			colorlog.PrintError(fmt.Sprintf("Could not apply %s to %s: %v", reduction, usage.Path, err))
+			continue
+		}
+		usage.Applied = append(usage.Applied, reduction)
+	}
+
+	if len(usage.Applied) == 0 {
+		return
+	}
+
+	after := measureRepoDiskUsage(reclaimer, usage.Path)
+	if after.err != nil {
+		return
+	}
+	usage.Reclaimed = usage.Total - after.Total
+}
+
+// duCloneURLAuthenticator returns the client of GHORG_SCM_TYPE when apply converts repos to shallow clones, which
+// fetches from origin. It's nil when no token is configured, fetches then rely on ssh or a git credential helper.
+func duCloneURLAuthenticator(apply []string) scm.CloneURLAuthenticator {
+	if !slices.Contains(apply, "shallow") || os.Getenv("GHORG_NO_TOKEN") == "true" {
+		return nil
+	}
+
+	configs.GetOrSetToken()
+	if configs.VerifyTokenSet() != nil {
+		return nil
+	}
+
+	client, err := scm.GetClient(os.Getenv("GHORG_SCM_TYPE"))
+	if err != nil {
+		return nil
+	}
+	authenticator, _ := client.(scm.CloneURLAuthenticator)
+	return authenticator
+}
+
+// convertToShallowClone converts repo with the credentials of the scm temporarily added to its origin
+func convertToShallowClone(reclaimer git.SpaceReclaimer, authenticator scm.CloneURLAuthenticator, repo scm.Repo) error {
+	if authenticator == nil {
+		return reclaimer.ConvertToShallowClone(repo)
+	}
+
+	url, err := reclaimer.GetRemoteURL(repo)
+	if err != nil {
+		return fmt.Errorf("could not read the origin url: %w", err)
+	}
+	repo.URL = url
+	repo.CloneURL = authenticator.AuthenticateCloneURL(url)
+
+	// Temporarily restore credentials to fetch from private repos
+	if err := reclaimer.SetOriginWithCredentials(repo); err != nil {
+		return fmt.Errorf("could not set remote with credentials: %w", err)
+	}
+
+	convertErr := reclaimer.ConvertToShallowClone(repo)
+
+	// Always strip credentials again for security, even if the conversion failed
+	if err := reclaimer.SetOrigin(repo); err != nil {
+		return fmt.Errorf("could not reset remote after fetching: %w", err)
+	}
+	return convertErr
+}
+
+// unsafeToDropHistory returns why local objects can't be dropped from a repo, or "" when everything has been pushed.
+// Stashes, tags and other refs outside of refs/heads count as well as branches.
+func unsafeToDropHistory(reclaimer git.SpaceReclaimer, repo scm.Repo) string {
+	status, err := reclaimer.ShortStatus(repo)
+	if err != nil {
+		return fmt.Sprintf("could not check for local changes: %v", err)
+	}
+	if status != "" {
+		return "it has uncommitted changes"
+	}
+
+	count, err := reclaimer.LocalOnlyCommitCount(repo)
+	if err != nil {
+		return fmt.Sprintf("could not check for unpushed commits: %v", err)
+	}
+	if count > 0 {
+		return fmt.Sprintf("it has %d commits that have not been pushed", count)
+	}
+
+	refs, err := reclaimer.LocalOnlyRefs(repo)
+	if err != nil {
+		return fmt.Sprintf("could not check for unpushed stashes and tags: %v", err)
+	}
+	if len(refs) > 0 {
+		return fmt.Sprintf("%s point to commits that have not been pushed", strings.Join(refs, ", "))
+	}
+
+	return ""
+}
+
+// printDiskUsage writes the --top largest repos with their suggestions, followed by the totals of every repo
+func printDiskUsage(out io.Writer, usages []repoDiskUsage, opts duOptions) error {
+	listed := usages
+	if opts.top > 0 && len(listed) > opts.top {
+		listed = listed[:opts.top]
+	}
+
+	if opts.output == "json" {
+		data, err := json.MarshalIndent(listed, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(out, string(data))
+		return err
+	}
+
+	colorlog.PrintInfo(fmt.Sprintf("%10s %10s %10s %10s %10s  %s", "TOTAL", "GIT", "LFS", "WORKTREE", "UNTRACKED", "REPO"))
+	for _, usage := range listed {
+		colorlog.PrintInfo(fmt.Sprintf("%10s %10s %10s %10s %10s  %s", formatBytes(usage.Total), formatBytes(usage.Git), formatBytes(usage.LFS), formatBytes(usage.Worktree), formatBytes(usage.Untracked), usage.Path))
+	}
+
+	suggested := false
+	for _, usage := range listed {
+		for _, suggestion := range usage.Suggestions {
+			if !suggested {
+				fmt.Println()
+				colorlog.PrintInfo("Suggested reductions, apply them with --apply=<reduction>:")
+				suggested = true
+			}
+			applied := ""
+			if slices.Contains(usage.Applied, suggestion.Reduction) {
+				applied = " (applied)"
+			}
+			colorlog.PrintSubtleInfo(fmt.Sprintf("%s  %s: %s, run %s%s", usage.Path, suggestion.Reduction, suggestion.Reason, suggestion.Command, applied))
+		}
+	}
+
+	var total, gitBytes, lfsBytes, worktreeBytes, untrackedBytes, reclaimed int64
+	for _, usage := range usages {
+		total += usage.Total
+		gitBytes += usage.Git
+		lfsBytes += usage.LFS
+		worktreeBytes += usage.Worktree
+		untrackedBytes += usage.Untracked
+		reclaimed += usage.Reclaimed
+	}
+
+	fmt.Println()
+	colorlog.PrintSuccess(fmt.Sprintf("Total: %d repos, %s (git %s, lfs %s, worktree %s, untracked %s)", len(usages), formatBytes(total), formatBytes(gitBytes), formatBytes(lfsBytes), formatBytes(worktreeBytes), formatBytes(untrackedBytes)))
+	if len(opts.apply) > 0 {
+		colorlog.PrintSuccess(fmt.Sprintf("Reclaimed %s", formatBytes(reclaimed)))
+	}
+
+	return nil
+}
+
+// formatBytes formats a size with the largest unit that keeps it above 1, using powers of 1000 like ghorg ls
+func formatBytes(size int64) string {
+	units := []string{"B", "KB", "MB", "GB", "TB"}
+	value := float64(size)
+	unit := 0
+	for value >= 1000 && unit < len(units)-1 {
+		value /= 1000
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%d B", size)
+	}
+	return fmt.Sprintf("%.2f %s", value, units[unit])
+}
@@ -1,15 +1,22 @@
 package cmd
 
 import (
+	"encoding/csv"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/briandowns/spinner"
 	"github.com/gabrie30/ghorg/colorlog"
+	"github.com/gabrie30/ghorg/git"
+	"github.com/gabrie30/ghorg/scm"
 	"github.com/gabrie30/ghorg/utils"
 	"github.com/spf13/cobra"
 )
@@ -17,8 +24,13 @@ import (
 var lsCmd = &cobra.Command{
 	Use:   "ls [dir]",
 	Short: "List contents of your ghorg home or ghorg directories",
-	Long:  `If no dir is specified it will list contents of GHORG_ABSOLUTE_PATH_TO_CLONE_TO`,
-	Run:   lsFunc,
+	Long: `If no dir is specified it will list the clone directories in GHORG_ABSOLUTE_PATH_TO_CLONE_TO, otherwise it lists the repos cloned into each dir.
+
+Use --long to show details, --sort and --filter to pick the order and the rows, and --output=json or --output=csv to script inventory reports.`,
+	Example: `  ghorg ls -l
+  ghorg ls --sort=-size --output=csv
+  ghorg ls my-org --filter 'dirty || last_commit < now-1y' --output=json`,
+	Run: lsFunc,
 }
 
 var spinningSpinner *spinner.Spinner
@@ -27,131 +39,263 @@ func init() {
 	spinningSpinner = spinner.New(spinner.CharSets[14], 100*time.Millisecond)
 }
 
+// lsRow is one line of ghorg ls, a clone directory when listing the ghorg home or a repo when listing a
+// clone directory. Branch and Dirty are only known for repos, Repos is only counted for clone directories.
+type lsRow struct {
+	Path       string    `json:"path"`
+	SizeMB     float64   `json:"size_mb"`
+	Repos      int       `json:"repos,omitempty"`
+	Provider   string    `json:"provider,omitempty"`
+	LastSync   time.Time `json:"last_sync,omitzero"`
+	LastCommit time.Time `json:"last_commit,omitzero"`
+	Branch     string    `json:"branch,omitempty"`
+	Dirty      bool      `json:"dirty"`
+	err        error
+}
+
+// lsFields are the fields available to the --filter and --sort flags of ls
+var lsFields = filterFieldSet[lsRow]{
+	fields: map[string]filterField[lsRow]{
+		"path":        {filterString, func(r lsRow) any { return r.Path }},
+		"name":        {filterString, func(r lsRow) any { return filepath.Base(r.Path) }},
+		"provider":    {filterString, func(r lsRow) any { return r.Provider }},
+		"branch":      {filterString, func(r lsRow) any { return r.Branch }},
+		"dirty":       {filterBool, func(r lsRow) any { return r.Dirty }},
+		"repos":       {filterNumber, func(r lsRow) any { return int64(r.Repos) }},
+		"size":        {filterSize, func(r lsRow) any { return lsSizeKB(r) }},
+		"last_sync":   {filterTime, func(r lsRow) any { return r.LastSync }},
+		"last_commit": {filterTime, func(r lsRow) any { return r.LastCommit }},
+	},
+	names: "path, name, provider, branch, dirty, repos, size, last_sync or last_commit",
+}
+
+// lsSizeKB converts the size of a row to the 1024 based kilobytes parseRepoSize uses, so a size > 1GB filter
+// means the same for ls as it does for the repos of a clone
+func lsSizeKB(r lsRow) int64 {
+	const bytesInMegabyte = 1000 * 1000
+	return int64(r.SizeMB * bytesInMegabyte / 1024)
+}
+
+// lsOptions are the flags of ls
+type lsOptions struct {
+	long   bool
+	total  bool
+	sortBy string
+	filter string
+	output string
+}
+
+// details reports whether the rows need sizes and repo details, plain ls only prints paths so it stays fast
+func (o lsOptions) details() bool {
+	return o.long || o.total || o.sortBy != "" || o.filter != "" || o.output != "text"
+}
+
 func lsFunc(cmd *cobra.Command, argz []string) {
-	if len(argz) == 0 {
-		listGhorgHome()
+	opts := lsOptions{}
+	opts.long, _ = cmd.Flags().GetBool("long")
+	opts.total, _ = cmd.Flags().GetBool("total")
+	opts.sortBy, _ = cmd.Flags().GetString("sort")
+	opts.filter, _ = cmd.Flags().GetString("filter")
+	opts.output, _ = cmd.Flags().GetString("output")
+
+	if opts.output != "text" && opts.output != "json" && opts.output != "csv" {
+		colorlog.PrintErrorAndExit("--output must be text, json or csv, got: " + opts.output)
+	}
+
+	var filter filterExpr[lsRow]
+	if opts.filter != "" {
+		var err error
+		filter, err = parseFilter(opts.filter, time.Now(), lsFields)
+		if err != nil {
+			colorlog.PrintErrorAndExit(fmt.Sprintf("Invalid --filter: %v", err))
+		}
+	}
+
+	if err := sortLsRows(nil, opts.sortBy); err != nil {
+		colorlog.PrintErrorAndExit(fmt.Sprintf("Invalid --sort: %v", err))
+	}
+
+	out := io.Writer(os.Stdout)
+	if opts.output != "text" {
+		// only the report is written to stdout so it can be piped into other tools
+		out = sendLogsToStderr()
+	} else if opts.details() {
+		spinningSpinner.Start()
 	}
 
-	if len(argz) >= 1 {
+	home := len(argz) == 0
+	var rows []lsRow
+	if home {
+		rows = listGhorgHome(os.Getenv("GHORG_ABSOLUTE_PATH_TO_CLONE_TO"), opts.details())
+	} else {
 		for _, arg := range argz {
-			listGhorgDir(arg)
+			rows = append(rows, listGhorgDir(arg, opts.details())...)
 		}
 	}
 
+	spinningSpinner.Stop()
+
+	listed := []lsRow{}
+	for _, row := range rows {
+		if row.err != nil {
+			colorlog.PrintError(fmt.Sprintf("Error processing directory %s: %v", row.Path, row.err))
+			continue
+		}
+		if filter == nil || filter.matches(row) {
+			listed = append(listed, row)
+		}
+	}
+	_ = sortLsRows(listed, opts.sortBy)
+
+	if err := printLsRows(out, listed, opts, home); err != nil {
+		colorlog.PrintErrorAndExit(fmt.Sprintf("Could not write the listing: %v", err))
+	}
 }
 
-func listGhorgHome() {
-	path := os.Getenv("GHORG_ABSOLUTE_PATH_TO_CLONE_TO")
+// listGhorgHome returns a row for each clone directory in the ghorg home
+func listGhorgHome(path string, details bool) []lsRow {
 	files, err := os.ReadDir(path)
 	if err != nil {
 		colorlog.PrintError("No clones found. Please clone some and try again.")
 	}
 
-	longFormat := false
-	totalFormat := false
-	for _, arg := range os.Args {
-		if arg == "-l" || arg == "--long" {
-			longFormat = true
-		}
-		if arg == "-t" || arg == "--total" {
-			totalFormat = true
+	dirs := []string{}
+	for _, f := range files {
+		if f.IsDir() {
+			dirs = append(dirs, filepath.Join(path, f.Name()))
 		}
 	}
 
-	if !longFormat && !totalFormat {
-		for _, f := range files {
-			if f.IsDir() {
-				colorlog.PrintInfo(path + f.Name())
-			}
+	if !details {
+		rows := make([]lsRow, len(dirs))
+		for i, dir := range dirs {
+			rows[i] = lsRow{Path: dir}
 		}
-		return
+		return rows
 	}
 
-	spinningSpinner.Start()
+	syncs := lastSyncsFromStats(getGhorgStatsFilePath())
 
-	var totalDirs int
-	var totalSizeMB float64
-	var totalRepos int
-
-	type result struct {
-		dirPath     string
-		dirSizeMB   float64
-		subDirCount int
-		err         error
+	rows := make([]lsRow, len(dirs))
+	var wg sync.WaitGroup
+	for i, dir := range dirs {
+		wg.Add(1)
+		go func(i int, dir string) {
+			defer wg.Done()
+			rows[i] = cloneDirRow(dir, syncs[filepath.Clean(dir)])
+		}(i, dir)
 	}
+	wg.Wait()
 
-	results := make(chan result, len(files))
-	var wg sync.WaitGroup
+	return rows
+}
 
-	for _, f := range files {
-		if f.IsDir() {
-			totalDirs++
-			wg.Add(1)
-			go func(f os.DirEntry) {
-				defer wg.Done()
-				dirPath := filepath.Join(path, f.Name())
-				dirSizeMB, err := utils.CalculateDirSizeInMb(dirPath)
-				if err != nil {
-					results <- result{dirPath: dirPath, err: err}
-					return
-				}
-
-				subDirCount := 0
-				subFiles, err := os.ReadDir(dirPath)
-				if err != nil {
-					results <- result{dirPath: dirPath, err: err}
-					return
-				}
-				for _, subFile := range subFiles {
-					if subFile.IsDir() {
-						subDirCount++
-					}
-				}
-				results <- result{dirPath: dirPath, dirSizeMB: dirSizeMB, subDirCount: subDirCount}
-			}(f)
-		}
+// cloneDirRow sums up a clone directory, the repos and dates of its repos come from its index when it has one
+func cloneDirRow(dir string, statsSync lsStatsSync) lsRow {
+	row := lsRow{Path: dir, Provider: statsSync.provider, LastSync: statsSync.at}
+
+	sizeMB, err := utils.CalculateDirSizeInMb(dir)
+	if err != nil {
+		return lsRow{Path: dir, err: err}
 	}
+	row.SizeMB = sizeMB
 
-	go func() {
-		wg.Wait()
-		close(results)
-	}()
+	repoDirs, entries := cloneDirRepos(dir)
+	row.Repos = len(repoDirs)
 
-	for res := range results {
-		if res.err != nil {
-			colorlog.PrintError(fmt.Sprintf("Error processing directory %s: %v", res.dirPath, res.err))
-			continue
+	providers := []string{}
+	for _, entry := range entries {
+		if entry.UpdatedAt.After(row.LastSync) {
+			row.LastSync = entry.UpdatedAt
 		}
-		totalSizeMB += res.dirSizeMB
-		totalRepos += res.subDirCount
-		if !totalFormat || longFormat {
-			spinningSpinner.Stop()
-			if longFormat {
-				if res.dirSizeMB > 1000 {
-					dirSizeGB := res.dirSizeMB / 1000
-					colorlog.PrintInfo(fmt.Sprintf("%-90s %10.2f GB %10d repos", res.dirPath, dirSizeGB, res.subDirCount))
-				} else {
-					colorlog.PrintInfo(fmt.Sprintf("%-90s %10.2f MB %10d repos", res.dirPath, res.dirSizeMB, res.subDirCount))
-				}
-			} else {
-				colorlog.PrintInfo(res.dirPath)
-			}
+		if entry.Provider != "" && !slices.Contains(providers, entry.Provider) {
+			providers = append(providers, entry.Provider)
 		}
 	}
+	if len(providers) > 0 {
+		slices.Sort(providers)
+		row.Provider = strings.Join(providers, ",")
+	}
 
-	spinningSpinner.Stop()
-	if totalFormat {
-		if totalSizeMB > 1000 {
-			totalSizeGB := totalSizeMB / 1000
-			colorlog.PrintSuccess(fmt.Sprintf("Total: %d directories, %.2f GB, %d repos", totalDirs, totalSizeGB, totalRepos))
-		} else {
-			colorlog.PrintSuccess(fmt.Sprintf("Total: %d directories, %.2f MB, %d repos", totalDirs, totalSizeMB, totalRepos))
+	return row
+}
+
+// listGhorgDir returns a row for each repo cloned into the clone directory arg
+func listGhorgDir(arg string, details bool) []lsRow {
+	path, err := resolveCloneDir(arg)
+	if err != nil {
+		colorlog.PrintError("No clones found. Please clone some and try again.")
+		return nil
+	}
+
+	repoDirs, entries := cloneDirRepos(path)
+	if !details {
+		rows := make([]lsRow, len(repoDirs))
+		for i, dir := range repoDirs {
+			rows[i] = lsRow{Path: dir}
 		}
+		return rows
+	}
+
+	byDir := map[string]repoIndexEntry{}
+	for _, entry := range entries {
+		byDir[filepath.Join(path, filepath.FromSlash(entry.Path))] = entry
+	}
+
+	gitter := git.NewVCS()
+	limit := lsConcurrency()
+	sem := make(chan struct{}, limit)
+
+	rows := make([]lsRow, len(repoDirs))
+	var wg sync.WaitGroup
+	for i, dir := range repoDirs {
+		wg.Add(1)
+		go func(i int, dir string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			rows[i] = repoDirRow(gitter, dir, byDir[dir])
+		}(i, dir)
 	}
+	wg.Wait()
+
+	return rows
 }
 
-func listGhorgDir(arg string) {
+// repoDirRow describes a cloned repo, the provider and last sync come from its index entry. Repos that
+// can't be read by git, like plain directories, are still listed with their size.
+func repoDirRow(gitter git.Gitter, dir string, entry repoIndexEntry) lsRow {
+	row := lsRow{Path: dir, Provider: entry.Provider, LastSync: entry.UpdatedAt}
 
+	sizeMB, err := utils.CalculateDirSizeInMb(dir)
+	if err != nil {
+		return lsRow{Path: dir, err: err}
+	}
+	row.SizeMB = sizeMB
+
+	repo := scm.Repo{HostPath: dir, VCS: entry.VCS}
+	if repo.VCS == "" {
+		if _, err := os.Stat(filepath.Join(dir, ".hg")); err == nil {
+			repo.VCS = scm.VCSHg
+		}
+	}
+
+	if branch, err := gitter.GetCurrentBranch(repo); err == nil {
+		row.Branch = branch
+	}
+	if status, err := gitter.ShortStatus(repo); err == nil {
+		row.Dirty = status != ""
+	}
+	if lastCommit, err := gitter.LastCommitDate(repo); err == nil {
+		row.LastCommit = lastCommit
+	}
+
+	return row
+}
+
+// resolveCloneDir returns the path of a clone directory named on the command line, it's relative to
+// GHORG_ABSOLUTE_PATH_TO_CLONE_TO and an error is returned when it can't be read
+func resolveCloneDir(arg string) (string, error) {
 	path := os.Getenv("GHORG_ABSOLUTE_PATH_TO_CLONE_TO") + arg
 
 	_, err := os.ReadDir(path)
@@ -162,110 +306,295 @@ func listGhorgDir(arg string) {
 		path = os.Getenv("GHORG_ABSOLUTE_PATH_TO_CLONE_TO") + arg
 	}
 
-	files, err := os.ReadDir(path)
-	if err != nil {
-		colorlog.PrintError("No clones found. Please clone some and try again.")
+	if _, err := os.ReadDir(path); err != nil {
+		return "", err
 	}
+	return path, nil
+}
 
-	longFormat := false
-	totalFormat := false
-	for _, arg := range os.Args {
-		if arg == "-l" || arg == "--long" {
-			longFormat = true
+// cloneDirRepos returns the repo directories of a clone directory along with its index entries, the
+// directories are the indexed repos that still exist and every other subdirectory, like repos cloned
+// before the index was written
+func cloneDirRepos(dir string) ([]string, []repoIndexEntry) {
+	var entries []repoIndexEntry
+	if index, err := loadRepoIndex(dir); err == nil {
+		entries = index.list()
+	}
+	dirs := existingRepoDirs(dir, entries)
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return dirs, entries
+	}
+	indexed := len(dirs)
+	for _, f := range files {
+		subdir := filepath.Join(dir, f.Name())
+		if f.IsDir() && !ownedByRepoDir(subdir, dirs[:indexed]) && !isMetadataDir(subdir) {
+			dirs = append(dirs, subdir)
 		}
-		if arg == "-t" || arg == "--total" {
-			totalFormat = true
+	}
+	slices.Sort(dirs)
+	return dirs, entries
+}
+
+// ownedByRepoDir reports whether subdir is one of repoDirs, holds one of them or holds the branch worktrees or
+// metadata export of one
+func ownedByRepoDir(subdir string, repoDirs []string) bool {
+	for _, repoDir := range repoDirs {
+		if subdir == repoDir || subdir == worktreesDirPath(repoDir) || subdir == metadataDirPath(repoDir) || strings.HasPrefix(repoDir, subdir+string(filepath.Separator)) {
+			return true
 		}
 	}
+	return false
+}
 
-	if !longFormat && !totalFormat {
-		for _, f := range files {
-			if f.IsDir() {
-				str := filepath.Join(path, f.Name())
-				colorlog.PrintInfo(str)
-			}
+// existingRepoDirs returns the directories of the index entries that still exist
+func existingRepoDirs(dir string, entries []repoIndexEntry) []string {
+	dirs := []string{}
+	for _, entry := range entries {
+		repoDir := filepath.Join(dir, filepath.FromSlash(entry.Path))
+		if _, err := os.Stat(repoDir); err == nil {
+			dirs = append(dirs, repoDir)
 		}
-		return
 	}
+	return dirs
+}
+
+// lsConcurrency is how many repos ls inspects at once, it follows GHORG_CONCURRENCY
+func lsConcurrency() int {
+	if n, err := strconv.Atoi(os.Getenv("GHORG_CONCURRENCY")); err == nil && n > 0 {
+		return n
+	}
+	return 25
+}
 
-	spinningSpinner.Start()
+// lsStatsSync is the latest clone of a clone directory recorded in _ghorg_stats.csv
+type lsStatsSync struct {
+	at       time.Time
+	provider string
+}
 
-	var totalDirs int
-	var totalSizeMB float64
+// lastSyncsFromStats reads when each clone directory was last cloned from the stats file, it's keyed by
+// the cleaned clonePath and is empty when stats are not enabled
+func lastSyncsFromStats(statsFilePath string) map[string]lsStatsSync {
+	syncs := map[string]lsStatsSync{}
 
-	type result struct {
-		dirPath     string
-		dirSizeMB   float64
-		subDirCount int
-		err         error
+	file, err := os.Open(statsFilePath)
+	if err != nil {
+		return syncs
 	}
+	defer func() { _ = file.Close() }()
 
-	results := make(chan result)
-	var wg sync.WaitGroup
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil || len(records) == 0 {
+		return syncs
+	}
 
-	for _, f := range files {
-		if f.IsDir() {
-			wg.Add(1)
-			go func(f os.DirEntry) {
-				defer wg.Done()
-				dirPath := filepath.Join(path, f.Name())
-				dirSizeMB, err := utils.CalculateDirSizeInMb(dirPath)
-				if err != nil {
-					results <- result{dirPath: dirPath, err: err}
-					return
-				}
-
-				// Count the number of directories with a depth of 1 inside
-				subDirCount := 0
-				subFiles, err := os.ReadDir(dirPath)
-				if err != nil {
-					results <- result{dirPath: dirPath, err: err}
-					return
-				}
-				for _, subFile := range subFiles {
-					if subFile.IsDir() {
-						subDirCount++
-					}
-				}
-				results <- result{dirPath: dirPath, dirSizeMB: dirSizeMB, subDirCount: subDirCount}
-			}(f)
+	columns := map[string]int{}
+	for i, name := range records[0] {
+		columns[name] = i
+	}
+	dateColumn, hasDate := columns["datetime"]
+	pathColumn, hasPath := columns["clonePath"]
+	scmColumn, hasSCM := columns["scm"]
+	typeColumn, hasType := columns["cloneType"]
+	if !hasDate || !hasPath {
+		return syncs
+	}
+
+	for _, record := range records[1:] {
+		if len(record) <= dateColumn || len(record) <= pathColumn {
+			continue
 		}
+		// ghorg maintain records its runs too, they don't sync anything
+		if hasType && len(record) > typeColumn && record[typeColumn] == "maintain" {
+			continue
+		}
+		at, err := time.ParseInLocation("2006-01-02 15:04:05", record[dateColumn], time.Local)
+		if err != nil {
+			continue
+		}
+		clonePath := filepath.Clean(record[pathColumn])
+		if !at.After(syncs[clonePath].at) {
+			continue
+		}
+		s := lsStatsSync{at: at}
+		if hasSCM && len(record) > scmColumn {
+			s.provider = record[scmColumn]
+		}
+		syncs[clonePath] = s
 	}
 
-	go func() {
-		wg.Wait()
-		close(results)
-	}()
+	return syncs
+}
 
-	for res := range results {
-		if res.err != nil {
-			colorlog.PrintError(fmt.Sprintf("Error processing directory %s: %v", res.dirPath, res.err))
-			continue
+// sortLsRows sorts rows by a --sort field, a leading - sorts in descending order. Rows that compare
+// equal keep their path order. Passing nil rows only validates the field.
+func sortLsRows(rows []lsRow, sortBy string) error {
+	if sortBy == "" {
+		return nil
+	}
+
+	descending := strings.HasPrefix(sortBy, "-")
+	name := strings.TrimPrefix(sortBy, "-")
+	field, ok := lsFields.fields[name]
+	if !ok {
+		return fmt.Errorf("unknown field %q, must be one of %s", name, lsFields.names)
+	}
+
+	slices.SortStableFunc(rows, func(a, b lsRow) int {
+		c := compareLsValues(field.value(a), field.value(b))
+		if descending {
+			c = -c
 		}
-		totalSizeMB += res.dirSizeMB
-		totalDirs++
-		if !totalFormat || longFormat {
-			spinningSpinner.Stop()
-			if longFormat {
-				if res.dirSizeMB > 1000 {
-					dirSizeGB := res.dirSizeMB / 1000
-					colorlog.PrintInfo(fmt.Sprintf("%-90s %10.2f GB ", res.dirPath, dirSizeGB))
-				} else {
-					colorlog.PrintInfo(fmt.Sprintf("%-90s %10.2f MB", res.dirPath, res.dirSizeMB))
-				}
+		if c == 0 {
+			c = strings.Compare(a.Path, b.Path)
+		}
+		return c
+	})
+
+	return nil
+}
+
+func compareLsValues(a, b any) int {
+	switch a := a.(type) {
+	case string:
+		return strings.Compare(strings.ToLower(a), strings.ToLower(b.(string)))
+	case int64:
+		return compareInts(a, b.(int64))
+	case time.Time:
+		return a.Compare(b.(time.Time))
+	case bool:
+		return compareInts(boolToInt(a), boolToInt(b.(bool)))
+	}
+	return 0
+}
+
+func compareInts(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func boolToInt(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// printLsRows writes the listing in the --output format, home rows are clone directories and the rest are repos
+func printLsRows(out io.Writer, rows []lsRow, opts lsOptions, home bool) error {
+	switch opts.output {
+	case "json":
+		data, err := json.MarshalIndent(rows, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(out, string(data))
+		return err
+	case "csv":
+		return writeLsCSV(out, rows)
+	}
+
+	if opts.long || !opts.total {
+		for _, row := range rows {
+			if opts.long {
+				colorlog.PrintInfo(formatLsRow(row, home))
 			} else {
-				colorlog.PrintInfo(res.dirPath)
+				colorlog.PrintInfo(row.Path)
 			}
 		}
 	}
 
-	spinningSpinner.Stop()
-	if totalFormat {
-		if totalSizeMB > 1000 {
-			totalSizeGB := totalSizeMB / 1000
-			colorlog.PrintSuccess(fmt.Sprintf("Total: %d repos, %.2f GB", totalDirs, totalSizeGB))
+	if opts.total {
+		var totalSizeMB float64
+		var totalRepos int
+		for _, row := range rows {
+			totalSizeMB += row.SizeMB
+			totalRepos += row.Repos
+		}
+		if home {
+			colorlog.PrintSuccess(fmt.Sprintf("Total: %d directories, %s, %d repos", len(rows), formatLsSize(totalSizeMB), totalRepos))
 		} else {
-			colorlog.PrintSuccess(fmt.Sprintf("Total: %d repos, %.2f MB", totalDirs, totalSizeMB))
+			colorlog.PrintSuccess(fmt.Sprintf("Total: %d repos, %s", len(rows), formatLsSize(totalSizeMB)))
+		}
+	}
+
+	return nil
+}
+
+// formatLsRow is the --long line of a row, dates that aren't known are shown as -
+func formatLsRow(row lsRow, home bool) string {
+	provider := row.Provider
+	if provider == "" {
+		provider = "-"
+	}
+
+	if home {
+		return fmt.Sprintf("%-90s %13s %10d repos  %-10s synced %s", row.Path, formatLsSize(row.SizeMB), row.Repos, provider, formatLsTime(row.LastSync))
+	}
+
+	branch := row.Branch
+	if branch == "" {
+		branch = "-"
+	}
+	if row.Dirty {
+		branch += "*"
+	}
+	return fmt.Sprintf("%-90s %13s  %-20s %-10s committed %s  synced %s", row.Path, formatLsSize(row.SizeMB), branch, provider, formatLsTime(row.LastCommit), formatLsTime(row.LastSync))
+}
+
+func formatLsSize(sizeMB float64) string {
+	if sizeMB > 1000 {
+		return fmt.Sprintf("%.2f GB", sizeMB/1000)
+	}
+	return fmt.Sprintf("%.2f MB", sizeMB)
+}
+
+func formatLsTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+// writeLsCSV writes the rows with a header, times are RFC 3339 and empty when they aren't known
+func writeLsCSV(out io.Writer, rows []lsRow) error {
+	w := csv.NewWriter(out)
+	if err := w.Write([]string{"path", "size_mb", "repos", "provider", "last_sync", "last_commit", "branch", "dirty"}); err != nil {
+		return err
+	}
+
+	formatTime := func(t time.Time) string {
+		if t.IsZero() {
+			return ""
 		}
+		return t.Format(time.RFC3339)
 	}
+
+	for _, row := range rows {
+		record := []string{
+			row.Path,
+			strconv.FormatFloat(row.SizeMB, 'f', 2, 64),
+			strconv.Itoa(row.Repos),
+			row.Provider,
+			formatTime(row.LastSync),
+			formatTime(row.LastCommit),
+			row.Branch,
+			strconv.FormatBool(row.Dirty),
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+	return w.Error()
 }
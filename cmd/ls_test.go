@@ -0,0 +1,234 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gabrie30/ghorg/scm"
+)
+
+// lsMockGitClient reports a dirty checkout with a known last commit
+type lsMockGitClient struct {
+	MockGitClient
+}
+
+func (g lsMockGitClient) ShortStatus(repo scm.Repo) (string, error) {
+	return " M README.md", nil
+}
+
+func (g lsMockGitClient) LastCommitDate(repo scm.Repo) (time.Time, error) {
+	return time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC), nil
+}
+
+func TestListGhorgDir_UsesIndex(t *testing.T) {
+	defer UnsetEnv("GHORG_")()
+	_ = os.Setenv("GHORG_SCM_TYPE", "gitlab")
+	targetCloneSource = "group"
+
+	home := t.TempDir() + string(os.PathSeparator)
+	_ = os.Setenv("GHORG_ABSOLUTE_PATH_TO_CLONE_TO", home)
+	dir := filepath.Join(home, "group")
+
+	index, err := loadRepoIndex(dir)
+	if err != nil {
+		t.Fatalf("unexpected error loading the index: %v", err)
+	}
+	for _, name := range []string{"sub/a", "b"} {
+		repoDir := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(repoDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := index.record(scm.Repo{ID: name, Name: filepath.Base(name), URL: "https://gitlab.com/group/" + name, HostPath: repoDir}, "group", "org"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := index.save(); err != nil {
+		t.Fatal(err)
+	}
+	// repos cloned before the index was written are only found on disk, worktrees and metadata exports belong to their repo
+	for _, name := range []string{"notes", "b" + worktreesDirSuffix, "b" + metadataDirSuffix} {
+		if err := os.MkdirAll(filepath.Join(dir, name), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rows := listGhorgDir("group", false)
+	got := []string{}
+	for _, row := range rows {
+		got = append(got, row.Path)
+	}
+	want := []string{filepath.Join(dir, "b"), filepath.Join(dir, "notes"), filepath.Join(dir, "sub", "a")}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	homeRows := listGhorgHome(home, true)
+	if len(homeRows) != 1 || homeRows[0].Repos != 3 || homeRows[0].Provider != "gitlab" || homeRows[0].LastSync.IsZero() {
+		t.Errorf("expected one gitlab clone directory with 3 repos and a last sync, got %+v", homeRows)
+	}
+}
+
+func TestRepoDirRow(t *testing.T) {
+	dir := t.TempDir()
+	synced := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	row := repoDirRow(lsMockGitClient{}, dir, repoIndexEntry{Provider: "github", UpdatedAt: synced})
+
+	if row.err != nil {
+		t.Fatalf("unexpected error: %v", row.err)
+	}
+	if row.Branch != "main" || !row.Dirty || row.Provider != "github" || !row.LastSync.Equal(synced) {
+		t.Errorf("unexpected row %+v", row)
+	}
+	if !row.LastCommit.Equal(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected the last commit from git, got %v", row.LastCommit)
+	}
+}
+
+func TestLastSyncsFromStats(t *testing.T) {
+	statsFile := filepath.Join(t.TempDir(), "_ghorg_stats.csv")
+	stats := "datetime,clonePath,scm,cloneType\n" +
+		"2024-01-01 10:00:00,/ghorg/org/,github,org\n" +
+		"2024-03-01 10:00:00,/ghorg/org,github,org\n" +
+		"2024-02-01 10:00:00,/ghorg/org,gitlab,org\n" +
+		"2024-04-01 10:00:00,/ghorg/org,github,maintain\n" +
+		"not a date,/ghorg/other,github,org\n"
+	if err := os.WriteFile(statsFile, []byte(stats), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	syncs := lastSyncsFromStats(statsFile)
+
+	want := time.Date(2024, 3, 1, 10, 0, 0, 0, time.Local)
+	if got := syncs["/ghorg/org"]; !got.at.Equal(want) || got.provider != "github" {
+		t.Errorf("expected the latest github sync at %v, got %+v", want, got)
+	}
+	if _, ok := syncs["/ghorg/other"]; ok {
+		t.Errorf("expected rows with an invalid date to be skipped")
+	}
+	if len(lastSyncsFromStats(filepath.Join(t.TempDir(), "missing.csv"))) != 0 {
+		t.Errorf("expected no syncs without a stats file")
+	}
+}
+
+func TestSortLsRows(t *testing.T) {
+	old := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	recent := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	rows := []lsRow{
+		{Path: "/ghorg/org/c", SizeMB: 10, LastCommit: old},
+		{Path: "/ghorg/org/a", SizeMB: 30, LastCommit: recent},
+		{Path: "/ghorg/org/b", SizeMB: 10},
+	}
+
+	testCases := []struct {
+		sortBy string
+		want   []string
+	}{
+		{"name", []string{"a", "b", "c"}},
+		{"-size", []string{"a", "b", "c"}},
+		{"size", []string{"b", "c", "a"}},
+		{"last_commit", []string{"b", "c", "a"}},
+		{"-last_commit", []string{"a", "c", "b"}},
+	}
+
+	for _, tc := range testCases {
+		sorted := append([]lsRow{}, rows...)
+		if err := sortLsRows(sorted, tc.sortBy); err != nil {
+			t.Fatalf("sortLsRows(%q): unexpected error %v", tc.sortBy, err)
+		}
+		got := []string{}
+		for _, row := range sorted {
+			got = append(got, filepath.Base(row.Path))
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("sortLsRows(%q): expected %v, got %v", tc.sortBy, tc.want, got)
+		}
+	}
+
+	if err := sortLsRows(nil, "stars"); err == nil || !strings.Contains(err.Error(), "unknown field") {
+		t.Errorf("expected an unknown field error, got %v", err)
+	}
+}
+
+func TestLsFilter(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	rows := []lsRow{
+		{Path: "/ghorg/org/stale", LastCommit: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC), Branch: "main"},
+		{Path: "/ghorg/org/dirty", LastCommit: now, Branch: "feature", Dirty: true},
+		{Path: "/ghorg/org/big", LastCommit: now, Branch: "main", SizeMB: 2000, Repos: 3},
+		// 1050 decimal megabytes is just under 1GB in the 1024 based units of the size filter
+		{Path: "/ghorg/org/medium", LastCommit: now, Branch: "main", SizeMB: 1050},
+	}
+
+	testCases := []struct {
+		expr string
+		want []string
+	}{
+		{"dirty || last_commit < now-1y", []string{"stale", "dirty"}},
+		{"size > 1GB", []string{"big"}},
+		{"size > 1000MB", []string{"big", "medium"}},
+		{"repos >= 3", []string{"big"}},
+		{"branch != main", []string{"dirty"}},
+		{"name =~ '^s'", []string{"stale"}},
+	}
+
+	for _, tc := range testCases {
+		expr, err := parseFilter(tc.expr, now, lsFields)
+		if err != nil {
+			t.Fatalf("parseFilter(%q): unexpected error %v", tc.expr, err)
+		}
+		got := []string{}
+		for _, row := range rows {
+			if expr.matches(row) {
+				got = append(got, filepath.Base(row.Path))
+			}
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("parseFilter(%q): expected %v, got %v", tc.expr, tc.want, got)
+		}
+	}
+
+	if _, err := parseFilter("archived", now, lsFields); err == nil || !strings.Contains(err.Error(), "last_commit") {
+		t.Errorf("expected an unknown field error listing the ls fields, got %v", err)
+	}
+	if _, err := parseFilter("repos > many", now, lsFields); err == nil {
+		t.Errorf("expected an error for a repos count that isn't a number")
+	}
+}
+
+func TestPrintLsRows_Formats(t *testing.T) {
+	rows := []lsRow{
+		{Path: "/ghorg/org/repo", SizeMB: 1.5, Provider: "github", Branch: "main", Dirty: true, LastCommit: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)},
+	}
+
+	var out bytes.Buffer
+	if err := printLsRows(&out, rows, lsOptions{output: "json"}, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var decoded []map[string]any
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatalf("expected valid json, got %v: %s", err, out.String())
+	}
+	if len(decoded) != 1 || decoded[0]["branch"] != "main" || decoded[0]["dirty"] != true || decoded[0]["last_commit"] != "2024-05-01T12:00:00Z" {
+		t.Errorf("unexpected json %s", out.String())
+	}
+	if _, ok := decoded[0]["last_sync"]; ok {
+		t.Errorf("expected an unknown last sync to be left out, got %s", out.String())
+	}
+
+	out.Reset()
+	if err := printLsRows(&out, rows, lsOptions{output: "csv"}, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "path,size_mb,repos,provider,last_sync,last_commit,branch,dirty\n" +
+		"/ghorg/org/repo,1.50,0,github,,2024-05-01T12:00:00Z,main,true\n"
+	if out.String() != want {
+		t.Errorf("expected csv\n%s\ngot\n%s", want, out.String())
+	}
+}
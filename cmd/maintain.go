@@ -0,0 +1,223 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gabrie30/ghorg/colorlog"
+	"github.com/gabrie30/ghorg/git"
+	"github.com/gabrie30/ghorg/scm"
+	"github.com/spf13/cobra"
+)
+
+var maintainCmd = &cobra.Command{
+	Use:   "maintain [dir]...",
+	Short: "Run git maintenance and integrity checks on cloned repos",
+	Long: `Runs maintenance tasks on every repo in the given clone directories, or in every clone directory in GHORG_ABSOLUTE_PATH_TO_CLONE_TO when no dir is given. Repos are maintained concurrently, up to GHORG_CONCURRENCY at a time.
+
+Tasks are set with --tasks or GHORG_MAINTAIN_TASKS (default: gc,fsck):
+  gc                  git gc, packs loose objects, removes unreachable ones and writes the commit-graph
+  repack              git repack -a -d -l, repacks everything into one pack
+  commit-graph        git commit-graph write --reachable, speeds up log and merge-base
+  pack-refs           git pack-refs --all
+  loose-objects       git maintenance run --task=loose-objects
+  incremental-repack  git maintenance run --task=incremental-repack
+  fsck                git fsck, checks the integrity of the repo (hg verify for mercurial repos)
+
+fsck always runs first and the other tasks are skipped for repos that fail it, so a damaged repo is not repacked. Failed integrity checks are reported as issues and the other failed tasks as info, they follow GHORG_EXIT_CODE_ON_CLONE_ISSUES and GHORG_EXIT_CODE_ON_CLONE_INFOS and are recorded in _ghorg_stats.csv when GHORG_STATS_ENABLED is set.`,
+	Example: `  ghorg maintain
+  ghorg maintain my-org --tasks=fsck
+  ghorg maintain my-org --tasks=commit-graph,pack-refs,incremental-repack`,
+	Run: maintainFunc,
+}
+
+// maintainResult is what happened to the repos of one clone directory
+type maintainResult struct {
+	infos  []string
+	errors []string
+}
+
+func maintainFunc(cmd *cobra.Command, argz []string) {
+	if cmd.Flags().Changed("tasks") {
+		_ = os.Setenv("GHORG_MAINTAIN_TASKS", cmd.Flag("tasks").Value.String())
+	}
+
+	if cmd.Flags().Changed("concurrency") {
+		_ = os.Setenv("GHORG_CONCURRENCY", cmd.Flag("concurrency").Value.String())
+	}
+
+	syncBoolFlagToEnv(cmd, "stats-enabled", "GHORG_STATS_ENABLED")
+
+	tasks, err := parseMaintenanceTasks(os.Getenv("GHORG_MAINTAIN_TASKS"))
+	if err != nil {
+		colorlog.PrintErrorAndExit(fmt.Sprintf("Invalid GHORG_MAINTAIN_TASKS: %v", err))
+	}
+
+	dirs, err := maintainDirs(argz)
+	if err != nil {
+		colorlog.PrintErrorAndExit(err.Error())
+	}
+
+	// maintenance runs are told apart from clones in _ghorg_stats.csv by their clone type
+	_ = os.Setenv("GHORG_CLONE_TYPE", "maintain")
+
+	cloneErrors = nil
+	cloneInfos = nil
+	gitter := git.NewVCS()
+	totalRepos := 0
+	integrityFailures := 0
+	start := time.Now()
+
+	for _, dir := range dirs {
+		repoDirs, err := findLocalRepos(dir)
+		if err != nil {
+			cloneErrors = append(cloneErrors, fmt.Sprintf("Could not find the repos in %s: %v", dir, err))
+			continue
+		}
+		if len(repoDirs) == 0 {
+			continue
+		}
+
+		dirStart := time.Now()
+		colorlog.PrintInfo(fmt.Sprintf("Maintaining %d repos in %s, tasks: %s", len(repoDirs), dir, strings.Join(tasks, ",")))
+		result := maintainRepos(gitter, repoDirs, tasks)
+		totalRepos += len(repoDirs)
+
+		cloneInfos = append(cloneInfos, result.infos...)
+		cloneErrors = append(cloneErrors, result.errors...)
+		integrityFailures += len(result.errors)
+
+		if os.Getenv("GHORG_STATS_ENABLED") == "true" {
+			writeMaintainStats(dir, len(repoDirs), result, int(time.Since(dirStart).Seconds()+0.5))
+		}
+	}
+
+	if totalRepos == 0 {
+		colorlog.PrintError("No clones found. Please clone some and try again.")
+		return
+	}
+
+	printRemainingMessages()
+	colorlog.PrintSuccess(fmt.Sprintf("\nFinished maintaining %d repos, %d failed integrity checks%s", totalRepos, integrityFailures, formatDurationText(int(time.Since(start).Seconds()+0.5))))
+
+	exitWithCloneStatus(len(cloneInfos), len(cloneErrors))
+}
+
+// parseMaintenanceTasks splits a comma separated list of tasks, fsck is moved to the front so repos are checked
+// before anything rewrites their object store
+func parseMaintenanceTasks(value string) ([]string, error) {
+	tasks := []string{}
+	for _, task := range strings.Split(value, ",") {
+		task = strings.ToLower(strings.TrimSpace(task))
+		if task == "" || slices.Contains(tasks, task) {
+			continue
+		}
+		if !slices.Contains(git.MaintenanceTasks, task) {
+			return nil, fmt.Errorf("unknown task %q, must be one of %s", task, strings.Join(git.MaintenanceTasks, ", "))
+		}
+		tasks = append(tasks, task)
+	}
+
+	if len(tasks) == 0 {
+		return nil, fmt.Errorf("no tasks were given")
+	}
+
+	if i := slices.Index(tasks, "fsck"); i > 0 {
+		tasks = append([]string{"fsck"}, slices.Delete(tasks, i, i+1)...)
+	}
+
+	return tasks, nil
+}
+
+// maintainDirs returns the clone directories named on the command line, or every directory in the ghorg home
+func maintainDirs(argz []string) ([]string, error) {
+	if len(argz) > 0 {
+		dirs := []string{}
+		for _, arg := range argz {
+			dir, err := resolveCloneDir(arg)
+			if err != nil {
+				return nil, fmt.Errorf("could not find the clone directory %s: %v", arg, err)
+			}
+			dirs = append(dirs, dir)
+		}
+		return dirs, nil
+	}
+
+	home := os.Getenv("GHORG_ABSOLUTE_PATH_TO_CLONE_TO")
+	files, err := os.ReadDir(home)
+	if err != nil {
+		return nil, fmt.Errorf("no clones found in %s: %v", home, err)
+	}
+
+	dirs := []string{}
+	for _, f := range files {
+		if f.IsDir() {
+			dirs = append(dirs, filepath.Join(home, f.Name()))
+		}
+	}
+	return dirs, nil
+}
+
+// maintainRepos runs the tasks on every repo, GHORG_CONCURRENCY repos are maintained at once. Failed
+// integrity checks are errors and the other failed tasks are infos.
+func maintainRepos(gitter git.Gitter, repoDirs []string, tasks []string) maintainResult {
+	sem := make(chan struct{}, lsConcurrency())
+	result := maintainResult{}
+	var mu sync.Mutex
+
+	var wg sync.WaitGroup
+	for _, dir := range repoDirs {
+		wg.Add(1)
+		go func(dir string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			repo := scm.Repo{HostPath: dir, VCS: scm.VCSGit}
+			if _, err := os.Stat(filepath.Join(dir, ".hg")); err == nil {
+				repo.VCS = scm.VCSHg
+			}
+
+			for _, task := range tasks {
+				err := gitter.Maintain(repo, task)
+				if err == nil {
+					continue
+				}
+
+				mu.Lock()
+				if task == "fsck" {
+					result.errors = append(result.errors, fmt.Sprintf("Integrity check failed, skipped the remaining maintenance, repo: %s, error: %v", dir, err))
+				} else {
+					result.infos = append(result.infos, fmt.Sprintf("Could not run maintenance task %s, repo: %s, error: %v", task, dir, err))
+				}
+				mu.Unlock()
+
+				if task == "fsck" {
+					return
+				}
+			}
+		}(dir)
+	}
+	wg.Wait()
+
+	slices.Sort(result.infos)
+	slices.Sort(result.errors)
+	return result
+}
+
+// writeMaintainStats records a maintenance run of a clone directory in _ghorg_stats.csv, the repos maintained
+// are recorded as the total count and nothing is counted as cloned or pulled
+func writeMaintainStats(dir string, repoCount int, result maintainResult, durationSeconds int) {
+	outputDirAbsolutePath = dir
+	targetCloneSource = filepath.Base(dir)
+	isDirSizeCached = false
+	_, _ = getCachedOrCalculatedOutputDirSizeInMb()
+
+	date := time.Now().Format("2006-01-02 15:04:05")
+	_ = writeGhorgStats(date, repoCount, 0, 0, len(result.infos), len(result.errors), 0, 0, 0, durationSeconds, false)
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gabrie30/ghorg/scm"
+)
+
+// maintainMockGitClient fails the tasks listed for a repo and records every task it runs
+type maintainMockGitClient struct {
+	MockGitClient
+	failures map[string]string
+	mu       *sync.Mutex
+	ran      map[string][]string
+}
+
+func (g maintainMockGitClient) Maintain(repo scm.Repo, task string) error {
+	name := filepath.Base(repo.HostPath)
+	g.mu.Lock()
+	g.ran[name] = append(g.ran[name], task)
+	g.mu.Unlock()
+	if g.failures[name] == task {
+		return errors.New("error: object file is empty")
+	}
+	return nil
+}
+
+func TestParseMaintenanceTasks(t *testing.T) {
+	testCases := []struct {
+		value   string
+		want    []string
+		wantErr string
+	}{
+		{"gc,fsck", []string{"fsck", "gc"}, ""},
+		{" GC , commit-graph,gc", []string{"gc", "commit-graph"}, ""},
+		{"fsck,repack", []string{"fsck", "repack"}, ""},
+		{"pack-refs,incremental-repack,fsck", []string{"fsck", "pack-refs", "incremental-repack"}, ""},
+		{"gc,prune", nil, "unknown task \"prune\""},
+		{" , ", nil, "no tasks"},
+	}
+
+	for _, tc := range testCases {
+		got, err := parseMaintenanceTasks(tc.value)
+		if tc.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("parseMaintenanceTasks(%q): expected error containing %q, got %v", tc.value, tc.wantErr, err)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("parseMaintenanceTasks(%q): expected %v, got %v %v", tc.value, tc.want, got, err)
+		}
+	}
+}
+
+func TestMaintainRepos(t *testing.T) {
+	defer UnsetEnv("GHORG_")()
+	_ = os.Setenv("GHORG_CONCURRENCY", "2")
+
+	dir := t.TempDir()
+	repoDirs := []string{}
+	for _, name := range []string{"healthy", "corrupt", "busy"} {
+		repoDirs = append(repoDirs, filepath.Join(dir, name))
+	}
+
+	gitter := maintainMockGitClient{
+		failures: map[string]string{"corrupt": "fsck", "busy": "gc"},
+		mu:       &sync.Mutex{},
+		ran:      map[string][]string{},
+	}
+	result := maintainRepos(gitter, repoDirs, []string{"fsck", "gc", "commit-graph"})
+
+	wantRan := map[string][]string{
+		"healthy": {"fsck", "gc", "commit-graph"},
+		"corrupt": {"fsck"},
+		"busy":    {"fsck", "gc", "commit-graph"},
+	}
+	if !reflect.DeepEqual(gitter.ran, wantRan) {
+		t.Errorf("expected tasks %v, got %v", wantRan, gitter.ran)
+	}
+
+	if len(result.errors) != 1 || !strings.Contains(result.errors[0], "Integrity check failed") || !strings.Contains(result.errors[0], "corrupt") {
+		t.Errorf("expected the failed fsck to be an error, got %v", result.errors)
+	}
+	if len(result.infos) != 1 || !strings.Contains(result.infos[0], "task gc") || !strings.Contains(result.infos[0], "busy") {
+		t.Errorf("expected the failed gc to be an info, got %v", result.infos)
+	}
+}
+
+func TestWriteMaintainStats(t *testing.T) {
+	defer UnsetEnv("GHORG_")()
+	home := t.TempDir()
+	_ = os.Setenv("GHORG_ABSOLUTE_PATH_TO_CLONE_TO", home)
+	_ = os.Setenv("GHORG_CLONE_TYPE", "maintain")
+
+	originalOutputDir, originalTarget := outputDirAbsolutePath, targetCloneSource
+	defer func() { outputDirAbsolutePath, targetCloneSource = originalOutputDir, originalTarget }()
+
+	dir := filepath.Join(home, "org")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	writeMaintainStats(dir, 3, maintainResult{errors: []string{"corrupt"}}, 5)
+
+	data, err := os.ReadFile(filepath.Join(home, "_ghorg_stats.csv"))
+	if err != nil {
+		t.Fatalf("expected a stats file: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected a header and one row, got %q", data)
+	}
+	fields := strings.Split(lines[1], ",")
+	if fields[1] != dir || fields[3] != "maintain" || fields[4] != "org" || fields[5] != "3" || fields[11] != "1" || fields[17] != "5" {
+		t.Errorf("unexpected stats row %q", lines[1])
+	}
+
+	if _, ok := lastSyncsFromStats(filepath.Join(home, "_ghorg_stats.csv"))[dir]; ok {
+		t.Errorf("expected maintenance runs not to count as a sync")
+	}
+}
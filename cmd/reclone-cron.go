@@ -13,8 +13,10 @@ import (
 )
 
 var (
-	recloneRunning bool
-	recloneMutex   sync.Mutex
+	// cronRunning is set while the reclone or maintain command started by the cron is running, so the two
+	// never run at the same time
+	cronRunning bool
+	cronMutex   sync.Mutex
 )
 
 var recloneCronCmd = &cobra.Command{
@@ -26,6 +28,10 @@ var recloneCronCmd = &cobra.Command{
 			_ = os.Setenv("GHORG_CRON_TIMER_MINUTES", cmd.Flag("minutes").Value.String())
 		}
 
+		if cmd.Flags().Changed("maintain-minutes") {
+			_ = os.Setenv("GHORG_CRON_MAINTAIN_MINUTES", cmd.Flag("maintain-minutes").Value.String())
+		}
+
 		startReCloneCron()
 	},
 }
@@ -48,35 +54,61 @@ func startReCloneCron() {
 	ticker := time.NewTicker(time.Duration(minutes) * time.Minute)
 	defer ticker.Stop()
 
-	for range ticker.C {
-		recloneMutex.Lock()
-		if recloneRunning {
-			recloneMutex.Unlock()
-			continue
+	// maintenance is optional, a nil channel never fires
+	var maintainTicks <-chan time.Time
+	if maintainTimer := os.Getenv("GHORG_CRON_MAINTAIN_MINUTES"); maintainTimer != "" {
+		maintainMinutes, err := strconv.Atoi(maintainTimer)
+		if err != nil || maintainMinutes <= 0 {
+			colorlog.PrintError("Invalid GHORG_CRON_MAINTAIN_MINUTES: " + maintainTimer)
+			return
 		}
-		recloneRunning = true
-		recloneMutex.Unlock()
-
-		colorlog.PrintInfo("Starting reclone cron, time: " + time.Now().Format(time.RFC1123))
-		cmd := exec.Command("ghorg", "reclone")
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-
-		if err := cmd.Start(); err != nil {
-			colorlog.PrintError("Failed to start ghorg reclone: " + err.Error())
-			recloneMutex.Lock()
-			recloneRunning = false
-			recloneMutex.Unlock()
-			continue
+		colorlog.PrintInfo("Maintenance cron activated and will first run after " + maintainTimer + " minutes ")
+		maintainTicker := time.NewTicker(time.Duration(maintainMinutes) * time.Minute)
+		defer maintainTicker.Stop()
+		maintainTicks = maintainTicker.C
+	}
+
+	for {
+		select {
+		case <-ticker.C:
+			runCronCommand("reclone")
+		case <-maintainTicks:
+			runCronCommand("maintain")
 		}
+	}
+}
 
-		go func() {
-			if err := cmd.Wait(); err != nil {
-				colorlog.PrintError("ghorg reclone command failed: " + err.Error())
-			}
-			recloneMutex.Lock()
-			recloneRunning = false
-			recloneMutex.Unlock()
-		}()
+// runCronCommand starts ghorg with the subcommand unless the cron is already running one, a tick that
+// comes while a reclone or maintain is running is skipped
+func runCronCommand(subcommand string) {
+	cronMutex.Lock()
+	if cronRunning {
+		cronMutex.Unlock()
+		colorlog.PrintInfo("Skipping ghorg " + subcommand + ", the previous cron command is still running")
+		return
 	}
+	cronRunning = true
+	cronMutex.Unlock()
+
+	colorlog.PrintInfo("Starting " + subcommand + " cron, time: " + time.Now().Format(time.RFC1123))
+	cmd := exec.Command("ghorg", subcommand)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Start(); err != nil {
+		colorlog.PrintError("Failed to start ghorg " + subcommand + ": " + err.Error())
+		cronMutex.Lock()
+		cronRunning = false
+		cronMutex.Unlock()
+		return
+	}
+
+	go func() {
+		if err := cmd.Wait(); err != nil {
+			colorlog.PrintError("ghorg " + subcommand + " command failed: " + err.Error())
+		}
+		cronMutex.Lock()
+		cronRunning = false
+		cronMutex.Unlock()
+	}()
 }
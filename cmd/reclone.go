@@ -4,11 +4,13 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"sort"
 	"strings"
 
 	"github.com/gabrie30/ghorg/colorlog"
 	"github.com/gabrie30/ghorg/configs"
+	"github.com/mitchellh/go-homedir"
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v2"
 )
@@ -25,6 +27,27 @@ type ReClone struct {
 	Description    string `yaml:"description"`
 	PostExecScript string `yaml:"post_exec_script"` // optional
 	TokenCmd       string `yaml:"token_cmd"`        // optional
+	Source         string `yaml:"-"`                // file the entry was loaded from
+}
+
+// reCloneIncludeKey is the reserved top level key in a reclone file that lists
+// other reclone files to merge in
+const reCloneIncludeKey = "include"
+
+// reCloneNode is a single top level value of a reclone file, either a reclone
+// entry or, under the include key, a list of file paths
+type reCloneNode struct {
+	rc        ReClone
+	include   []string
+	isInclude bool
+}
+
+func (n *reCloneNode) UnmarshalYAML(unmarshal func(any) error) error {
+	if err := unmarshal(&n.include); err == nil {
+		n.isInclude = true
+		return nil
+	}
+	return unmarshal(&n.rc)
 }
 
 func isQuietReClone() bool {
@@ -41,17 +64,14 @@ func reCloneFunc(cmd *cobra.Command, argz []string) {
 	syncBoolFlagToEnv(cmd, "quiet", "GHORG_RECLONE_QUIET")
 	syncBoolFlagToEnv(cmd, "env-config-only", "GHORG_RECLONE_ENV_CONFIG_ONLY")
 
-	path := configs.GhorgReCloneLocation()
-	yamlBytes, err := os.ReadFile(path)
-	if err != nil {
-		colorlog.PrintErrorAndExit(fmt.Sprintf("ERROR: parsing reclone.yaml, error: %v", err))
+	if cmd.Flags().Changed("reclone-dir") {
+		dir := cmd.Flag("reclone-dir").Value.String()
+		_ = os.Setenv("GHORG_RECLONE_DIR", dir)
 	}
 
-	mapOfReClones := make(map[string]ReClone)
-
-	err = yaml.Unmarshal(yamlBytes, &mapOfReClones)
+	mapOfReClones, err := loadReCloneConfig(configs.GhorgReCloneLocation(), configs.GhorgReCloneDirLocation())
 	if err != nil {
-		colorlog.PrintErrorAndExit(fmt.Sprintf("ERROR: unmarshaling reclone.yaml, error:%v", err))
+		colorlog.PrintErrorAndExit(fmt.Sprintf("ERROR: parsing reclone.yaml, error: %v", err))
 	}
 
 	if cmd.Flags().Changed("list") {
@@ -66,6 +86,7 @@ func reCloneFunc(cmd *cobra.Command, argz []string) {
 				colorlog.PrintSubtleInfo(fmt.Sprintf("    description: %s", value.Description))
 			}
 			colorlog.PrintSubtleInfo(fmt.Sprintf("    cmd: %s", value.Cmd))
+			colorlog.PrintSubtleInfo(fmt.Sprintf("    source: %s", value.Source))
 			fmt.Println("")
 		}
 		os.Exit(0)
@@ -102,6 +123,162 @@ func printFinalOutput(argz []string, reCloneMap map[string]ReClone) {
 	}
 }
 
+// loadReCloneConfig reads the reclone.yaml at path and merges in every file it
+// includes as well as the *.yaml fragments found in fragmentDir. Fragments are
+// treated as if they were listed, sorted by filename, in the include list of
+// reclone.yaml. Entries defined directly in a file take precedence over entries
+// pulled in through its includes, while the same key coming from two included
+// files is an error so a shared file can never silently shadow another. The
+// reclone.yaml itself may be absent as long as fragments are present.
+func loadReCloneConfig(path string, fragmentDir string) (map[string]ReClone, error) {
+	fragments, err := reCloneFragments(fragmentDir)
+	if err != nil {
+		return nil, err
+	}
+
+	l := reCloneLoader{seen: make(map[string]bool)}
+
+	if _, err := os.Stat(path); os.IsNotExist(err) && len(fragments) > 0 {
+		return l.loadAll(fragments)
+	}
+
+	return l.loadFile(path, fragments)
+}
+
+// reCloneFragments returns the sorted *.yaml and *.yml files in dir, a missing
+// dir has no fragments
+func reCloneFragments(dir string) ([]string, error) {
+	if dir == "" {
+		return nil, nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var fragments []string
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		fragments = append(fragments, filepath.Join(dir, entry.Name()))
+	}
+	sort.Strings(fragments)
+	return fragments, nil
+}
+
+type reCloneLoader struct {
+	// seen holds the absolute paths of files already loaded so include cycles
+	// and files included from more than one place are only read once
+	seen map[string]bool
+}
+
+// loadAll loads each file and merges the results, erroring on duplicate keys
+func (l *reCloneLoader) loadAll(paths []string) (map[string]ReClone, error) {
+	merged := make(map[string]ReClone)
+	for _, p := range paths {
+		entries, err := l.loadFile(p, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range sortedReCloneKeys(entries) {
+			if existing, ok := merged[key]; ok {
+				return nil, fmt.Errorf("reclone entry %q is defined in both %s and %s", key, existing.Source, entries[key].Source)
+			}
+			merged[key] = entries[key]
+		}
+	}
+	return merged, nil
+}
+
+// loadFile loads a single reclone file along with its includes. extraIncludes
+// are merged in after the includes listed in the file itself.
+func (l *reCloneLoader) loadFile(path string, extraIncludes []string) (map[string]ReClone, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if l.seen[absPath] {
+		return map[string]ReClone{}, nil
+	}
+	l.seen[absPath] = true
+
+	yamlBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make(map[string]reCloneNode)
+	if err := yaml.Unmarshal(yamlBytes, &nodes); err != nil {
+		return nil, fmt.Errorf("unmarshaling %s: %v", path, err)
+	}
+
+	own := make(map[string]ReClone)
+	var includes []string
+	for key, node := range nodes {
+		if key == reCloneIncludeKey {
+			if !node.isInclude {
+				return nil, fmt.Errorf("%s: %s must be a list of file paths", path, reCloneIncludeKey)
+			}
+			resolved, err := resolveReCloneIncludes(filepath.Dir(path), node.include)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", path, err)
+			}
+			includes = resolved
+			continue
+		}
+		if node.isInclude {
+			return nil, fmt.Errorf("%s: reclone entry %q must be a mapping with a cmd, not a list", path, key)
+		}
+		node.rc.Source = path
+		own[key] = node.rc
+	}
+
+	merged, err := l.loadAll(append(includes, extraIncludes...))
+	if err != nil {
+		return nil, err
+	}
+
+	for key, rc := range own {
+		merged[key] = rc
+	}
+
+	return merged, nil
+}
+
+// resolveReCloneIncludes expands ~ and globs in include paths, relative paths are
+// resolved against baseDir which is the directory of the including file
+func resolveReCloneIncludes(baseDir string, includes []string) ([]string, error) {
+	var resolved []string
+	for _, include := range includes {
+		p, err := homedir.Expand(include)
+		if err != nil {
+			return nil, err
+		}
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(baseDir, p)
+		}
+
+		if !strings.ContainsAny(p, "*?[") {
+			resolved = append(resolved, p)
+			continue
+		}
+
+		matches, err := filepath.Glob(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid include pattern %q: %v", include, err)
+		}
+		sort.Strings(matches)
+		resolved = append(resolved, matches...)
+	}
+	return resolved, nil
+}
+
 // sortedReCloneKeys returns the keys of the given reclone map sorted
 // alphabetically, providing deterministic iteration order for both `--list`
 // output and the order in which reclones are executed when no arguments are
@@ -1,7 +1,10 @@
 package cmd
 
 import (
+	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -233,3 +236,141 @@ func Test_sortedReCloneKeys(t *testing.T) {
 		})
 	}
 }
+
+func Test_loadReCloneConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		want    map[string]string // key -> cmd
+		wantErr string
+	}{
+		{
+			name: "single reclone.yaml",
+			files: map[string]string{
+				"reclone.yaml": "a:\n  cmd: ghorg clone a\n",
+			},
+			want: map[string]string{"a": "ghorg clone a"},
+		},
+		{
+			name: "include with relative path and glob",
+			files: map[string]string{
+				"reclone.yaml":       "include:\n  - shared/*.yaml\n  - other.yaml\na:\n  cmd: ghorg clone a\n",
+				"shared/one.yaml":    "b:\n  cmd: ghorg clone b\n",
+				"shared/two.yaml":    "c:\n  cmd: ghorg clone c\n",
+				"other.yaml":         "d:\n  cmd: ghorg clone d\n",
+				"shared/ignored.txt": "e:\n  cmd: ghorg clone e\n",
+			},
+			want: map[string]string{"a": "ghorg clone a", "b": "ghorg clone b", "c": "ghorg clone c", "d": "ghorg clone d"},
+		},
+		{
+			name: "fragments are merged",
+			files: map[string]string{
+				"reclone.yaml":        "a:\n  cmd: ghorg clone a\n",
+				"reclone.d/b.yaml":    "b:\n  cmd: ghorg clone b\n",
+				"reclone.d/c.yml":     "c:\n  cmd: ghorg clone c\n",
+				"reclone.d/notes.txt": "not yaml",
+			},
+			want: map[string]string{"a": "ghorg clone a", "b": "ghorg clone b", "c": "ghorg clone c"},
+		},
+		{
+			name: "fragments without reclone.yaml",
+			files: map[string]string{
+				"reclone.d/b.yaml": "b:\n  cmd: ghorg clone b\n",
+			},
+			want: map[string]string{"b": "ghorg clone b"},
+		},
+		{
+			name: "own entries override included entries",
+			files: map[string]string{
+				"reclone.yaml":     "include:\n  - shared.yaml\na:\n  cmd: ghorg clone mine\n",
+				"shared.yaml":      "a:\n  cmd: ghorg clone shared\n",
+				"reclone.d/x.yaml": "x:\n  cmd: ghorg clone x\n",
+				"reclone.d/y.yaml": "include:\n  - ../nested.yaml\n",
+				"nested.yaml":      "n:\n  cmd: ghorg clone n\n",
+			},
+			want: map[string]string{"a": "ghorg clone mine", "x": "ghorg clone x", "n": "ghorg clone n"},
+		},
+		{
+			name: "include cycle is loaded once",
+			files: map[string]string{
+				"reclone.yaml": "include:\n  - other.yaml\na:\n  cmd: ghorg clone a\n",
+				"other.yaml":   "include:\n  - reclone.yaml\nb:\n  cmd: ghorg clone b\n",
+			},
+			want: map[string]string{"a": "ghorg clone a", "b": "ghorg clone b"},
+		},
+		{
+			name: "duplicate key between includes",
+			files: map[string]string{
+				"reclone.yaml": "include:\n  - one.yaml\n  - two.yaml\n",
+				"one.yaml":     "a:\n  cmd: ghorg clone one\n",
+				"two.yaml":     "a:\n  cmd: ghorg clone two\n",
+			},
+			wantErr: `reclone entry "a" is defined in both`,
+		},
+		{
+			name: "duplicate key between include and fragment",
+			files: map[string]string{
+				"reclone.yaml":     "include:\n  - one.yaml\n",
+				"one.yaml":         "a:\n  cmd: ghorg clone one\n",
+				"reclone.d/a.yaml": "a:\n  cmd: ghorg clone fragment\n",
+			},
+			wantErr: `reclone entry "a" is defined in both`,
+		},
+		{
+			name: "missing include",
+			files: map[string]string{
+				"reclone.yaml": "include:\n  - missing.yaml\n",
+			},
+			wantErr: "missing.yaml",
+		},
+		{
+			name: "include must be a list",
+			files: map[string]string{
+				"reclone.yaml": "include:\n  cmd: ghorg clone a\n",
+			},
+			wantErr: "include must be a list of file paths",
+		},
+		{
+			name:    "nothing to load",
+			files:   map[string]string{},
+			wantErr: "reclone.yaml",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				p := filepath.Join(dir, name)
+				if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := loadReCloneConfig(filepath.Join(dir, "reclone.yaml"), filepath.Join(dir, "reclone.d"))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("loadReCloneConfig() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadReCloneConfig() unexpected error: %v", err)
+			}
+
+			gotCmds := make(map[string]string, len(got))
+			for key, rc := range got {
+				gotCmds[key] = rc.Cmd
+				if rc.Source == "" {
+					t.Errorf("entry %q has no source", key)
+				}
+			}
+			if !reflect.DeepEqual(gotCmds, tt.want) {
+				t.Errorf("loadReCloneConfig() = %v, want %v", gotCmds, tt.want)
+			}
+		})
+	}
+}
@@ -5,14 +5,28 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gabrie30/ghorg/colorlog"
 	"github.com/gabrie30/ghorg/scm"
 )
 
 // RepositoryFilter handles filtering of repositories based on various criteria
-type RepositoryFilter struct{}
+type RepositoryFilter struct {
+	// skipped are the repos removed by the last ApplyAllFilters, reported in the dry-run plan
+	skipped []skippedRepo
+	// reasons explains why a filter removed a repo, keyed by filteredRepoKey
+	reasons map[string]string
+}
+
+// skippedRepo is a repo removed by a filter
+type skippedRepo struct {
+	Repo   scm.Repo
+	Filter string
+	Reason string
+}
 
 // NewRepositoryFilter creates a new repository filter
 func NewRepositoryFilter() *RepositoryFilter {
@@ -21,45 +35,111 @@ func NewRepositoryFilter() *RepositoryFilter {
 
 // ApplyAllFilters applies all configured filters to the repository list
 func (rf *RepositoryFilter) ApplyAllFilters(cloneTargets []scm.Repo) []scm.Repo {
+	rf.skipped = nil
+	rf.reasons = make(map[string]string)
+
+	// Apply filter expression
+	if os.Getenv("GHORG_FILTER") != "" {
+		colorlog.PrintInfo("Filtering repos down by filter expression...")
+		cloneTargets = rf.apply("filter", cloneTargets, rf.FilterByExpression)
+	}
+
 	// Apply regex match filter
 	if os.Getenv("GHORG_MATCH_REGEX") != "" {
 		colorlog.PrintInfo("Filtering repos down by including regex matches...")
-		cloneTargets = rf.FilterByRegexMatch(cloneTargets)
+		cloneTargets = rf.apply("match-regex", cloneTargets, rf.FilterByRegexMatch)
 	}
 
 	// Apply exclude regex match filter
 	if os.Getenv("GHORG_EXCLUDE_MATCH_REGEX") != "" {
 		colorlog.PrintInfo("Filtering repos down by excluding regex matches...")
-		cloneTargets = rf.FilterByExcludeRegexMatch(cloneTargets)
+		cloneTargets = rf.apply("exclude-match-regex", cloneTargets, rf.FilterByExcludeRegexMatch)
 	}
 
 	// Apply prefix match filter
 	if os.Getenv("GHORG_MATCH_PREFIX") != "" {
 		colorlog.PrintInfo("Filtering repos down by including prefix matches...")
-		cloneTargets = rf.FilterByMatchPrefix(cloneTargets)
+		cloneTargets = rf.apply("match-prefix", cloneTargets, rf.FilterByMatchPrefix)
 	}
 
 	// Apply exclude prefix match filter
 	if os.Getenv("GHORG_EXCLUDE_MATCH_PREFIX") != "" {
 		colorlog.PrintInfo("Filtering repos down by excluding prefix matches...")
-		cloneTargets = rf.FilterByExcludeMatchPrefix(cloneTargets)
+		cloneTargets = rf.apply("exclude-match-prefix", cloneTargets, rf.FilterByExcludeMatchPrefix)
+	}
+
+	// Apply last activity filter
+	if os.Getenv("GHORG_PUSHED_SINCE") != "" || os.Getenv("GHORG_PUSHED_BEFORE") != "" {
+		colorlog.PrintInfo("Filtering repos down by last activity...")
+		cloneTargets = rf.apply("last-activity", cloneTargets, rf.FilterByLastActivity)
+	}
+
+	// Apply size filter
+	if os.Getenv("GHORG_MIN_REPO_SIZE") != "" || os.Getenv("GHORG_MAX_REPO_SIZE") != "" {
+		colorlog.PrintInfo("Filtering repos down by size...")
+		cloneTargets = rf.apply("repo-size", cloneTargets, rf.FilterBySize)
+	}
+
+	// Apply visibility filter
+	if os.Getenv("GHORG_VISIBILITY") != "" {
+		colorlog.PrintInfo("Filtering repos down by visibility...")
+		cloneTargets = rf.apply("visibility", cloneTargets, rf.FilterByVisibility)
 	}
 
 	// Apply target repos path filter
 	if os.Getenv("GHORG_TARGET_REPOS_PATH") != "" {
 		colorlog.PrintInfo("Filtering repos down by target repos path...")
-		cloneTargets = rf.FilterByTargetReposPath(cloneTargets)
+		cloneTargets = rf.apply("target-repos-path", cloneTargets, rf.FilterByTargetReposPath)
 	}
 
 	// Apply ghorgonly filter (must be applied before ghorgignore)
-	cloneTargets = rf.FilterByGhorgonly(cloneTargets)
+	cloneTargets = rf.apply("ghorgonly", cloneTargets, rf.FilterByGhorgonly)
 
 	// Apply ghorgignore filter
-	cloneTargets = rf.FilterByGhorgignore(cloneTargets)
+	cloneTargets = rf.apply("ghorgignore", cloneTargets, rf.FilterByGhorgignore)
 
 	return cloneTargets
 }
 
+// Skipped returns the repos removed by the last ApplyAllFilters and the filter that removed them
+func (rf *RepositoryFilter) Skipped() []skippedRepo {
+	return rf.skipped
+}
+
+// filteredRepoKey identifies a repo while filtering, the url alone isn't enough for snippets and wikis
+func filteredRepoKey(repo scm.Repo) string {
+	return repo.URL + "\x00" + repo.Path + "\x00" + repo.Name
+}
+
+// apply runs a single filter and records the repos it removed
+func (rf *RepositoryFilter) apply(name string, repos []scm.Repo, filter func([]scm.Repo) []scm.Repo) []scm.Repo {
+	kept := filter(repos)
+	if len(kept) == len(repos) {
+		return kept
+	}
+
+	keptKeys := make(map[string]bool, len(kept))
+	for _, repo := range kept {
+		keptKeys[filteredRepoKey(repo)] = true
+	}
+
+	for _, repo := range repos {
+		key := filteredRepoKey(repo)
+		if !keptKeys[key] {
+			rf.skipped = append(rf.skipped, skippedRepo{Repo: repo, Filter: name, Reason: rf.reasons[key]})
+		}
+	}
+
+	return kept
+}
+
+// explain records why a filter removed a repo
+func (rf *RepositoryFilter) explain(repo scm.Repo, reason string) {
+	if rf.reasons != nil {
+		rf.reasons[filteredRepoKey(repo)] = reason
+	}
+}
+
 // FilterByRegexMatch filters repositories that match the regex pattern
 func (rf *RepositoryFilter) FilterByRegexMatch(repos []scm.Repo) []scm.Repo {
 	regex := os.Getenv("GHORG_MATCH_REGEX")
@@ -71,7 +151,8 @@ func (rf *RepositoryFilter) FilterByRegexMatch(repos []scm.Repo) []scm.Repo {
 	re := regexp.MustCompile(regex)
 
 	for _, repo := range repos {
-		if re.FindString(repo.Name) != "" {
+		// gists are matched by the scm client before collisions change their folder names
+		if repo.IsGitHubGist || re.FindString(repo.Name) != "" {
 			filteredRepos = append(filteredRepos, repo)
 		}
 	}
@@ -90,7 +171,7 @@ func (rf *RepositoryFilter) FilterByExcludeRegexMatch(repos []scm.Repo) []scm.Re
 	re := regexp.MustCompile(regex)
 
 	for _, repo := range repos {
-		if re.FindString(repo.Name) == "" {
+		if repo.IsGitHubGist || re.FindString(repo.Name) == "" {
 			filteredRepos = append(filteredRepos, repo)
 		}
 	}
@@ -146,6 +227,181 @@ func (rf *RepositoryFilter) FilterByExcludeMatchPrefix(repos []scm.Repo) []scm.R
 	return filteredRepos
 }
 
+// FilterByLastActivity keeps repositories last pushed to within GHORG_PUSHED_SINCE and GHORG_PUSHED_BEFORE.
+// Repos whose provider doesn't report any activity, like snippets and gists, are always kept.
+func (rf *RepositoryFilter) FilterByLastActivity(repos []scm.Repo) []scm.Repo {
+	now := time.Now()
+
+	var since, before time.Time
+	if value := os.Getenv("GHORG_PUSHED_SINCE"); value != "" {
+		t, err := parseActivityTime(value, now)
+		if err != nil {
+			colorlog.PrintErrorAndExit(fmt.Sprintf("Invalid GHORG_PUSHED_SINCE: %v", err))
+		}
+		since = t
+	}
+	if value := os.Getenv("GHORG_PUSHED_BEFORE"); value != "" {
+		t, err := parseActivityTime(value, now)
+		if err != nil {
+			colorlog.PrintErrorAndExit(fmt.Sprintf("Invalid GHORG_PUSHED_BEFORE: %v", err))
+		}
+		before = t
+	}
+
+	filteredRepos := []scm.Repo{}
+
+	for _, repo := range repos {
+		if repo.LastActivityAt.IsZero() {
+			filteredRepos = append(filteredRepos, repo)
+			continue
+		}
+		if !since.IsZero() && repo.LastActivityAt.Before(since) {
+			continue
+		}
+		if !before.IsZero() && !repo.LastActivityAt.Before(before) {
+			continue
+		}
+		filteredRepos = append(filteredRepos, repo)
+	}
+
+	return filteredRepos
+}
+
+// relativeActivityTime matches durations like 36h, 90d, 2w, 12mo and 1y
+var relativeActivityTime = regexp.MustCompile(`^(\d+)(h|d|w|mo|y)$`)
+
+// parseActivityTime parses a date (2006-01-02), an RFC 3339 timestamp or a duration counted back from now
+func parseActivityTime(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	match := relativeActivityTime.FindStringSubmatch(strings.ToLower(value))
+	if match == nil {
+		return time.Time{}, fmt.Errorf("%q is not a date like 2024-01-31, an RFC 3339 timestamp or a duration like 90d, 2w, 12mo or 1y", value)
+	}
+
+	n, err := strconv.Atoi(match[1])
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	switch match[2] {
+	case "h":
+		return now.Add(-time.Duration(n) * time.Hour), nil
+	case "d":
+		return now.AddDate(0, 0, -n), nil
+	case "w":
+		return now.AddDate(0, 0, -7*n), nil
+	case "mo":
+		return now.AddDate(0, -n, 0), nil
+	default:
+		return now.AddDate(-n, 0, 0), nil
+	}
+}
+
+// FilterBySize keeps repositories between GHORG_MIN_REPO_SIZE and GHORG_MAX_REPO_SIZE. Repos whose
+// provider doesn't report a size, like wikis and snippets, are always kept.
+func (rf *RepositoryFilter) FilterBySize(repos []scm.Repo) []scm.Repo {
+	var minKB, maxKB int64
+	if value := os.Getenv("GHORG_MIN_REPO_SIZE"); value != "" {
+		kb, err := parseRepoSize(value)
+		if err != nil {
+			colorlog.PrintErrorAndExit(fmt.Sprintf("Invalid GHORG_MIN_REPO_SIZE: %v", err))
+		}
+		minKB = kb
+	}
+	if value := os.Getenv("GHORG_MAX_REPO_SIZE"); value != "" {
+		kb, err := parseRepoSize(value)
+		if err != nil {
+			colorlog.PrintErrorAndExit(fmt.Sprintf("Invalid GHORG_MAX_REPO_SIZE: %v", err))
+		}
+		maxKB = kb
+	}
+
+	filteredRepos := []scm.Repo{}
+
+	for _, repo := range repos {
+		if repo.SizeKB == 0 {
+			filteredRepos = append(filteredRepos, repo)
+			continue
+		}
+		if minKB > 0 && repo.SizeKB < minKB {
+			continue
+		}
+		if maxKB > 0 && repo.SizeKB > maxKB {
+			continue
+		}
+		filteredRepos = append(filteredRepos, repo)
+	}
+
+	return filteredRepos
+}
+
+// repoSizeUnits are the units accepted by parseRepoSize in kilobytes
+var repoSizeUnits = map[string]int64{
+	"kb": 1,
+	"mb": 1024,
+	"gb": 1024 * 1024,
+}
+
+// repoSize matches sizes like 500, 500MB, 1.5GB or 100kb
+var repoSize = regexp.MustCompile(`^(\d+(?:\.\d+)?)\s*(kb|mb|gb)?$`)
+
+// parseRepoSize parses a size in kilobytes, sizes without a unit are megabytes
+func parseRepoSize(value string) (int64, error) {
+	match := repoSize.FindStringSubmatch(strings.ToLower(strings.TrimSpace(value)))
+	if match == nil {
+		return 0, fmt.Errorf("%q is not a size like 500MB, 2GB or 100KB", value)
+	}
+
+	n, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0, err
+	}
+
+	unit := match[2]
+	if unit == "" {
+		unit = "mb"
+	}
+
+	return int64(n * float64(repoSizeUnits[unit])), nil
+}
+
+// FilterByVisibility keeps repositories whose visibility is listed in GHORG_VISIBILITY. Repos whose
+// visibility isn't known are skipped so a public only clone never includes them by accident.
+func (rf *RepositoryFilter) FilterByVisibility(repos []scm.Repo) []scm.Repo {
+	visibilities := os.Getenv("GHORG_VISIBILITY")
+	if visibilities == "" {
+		return repos
+	}
+
+	allowed := make(map[string]bool)
+	for _, v := range strings.Split(visibilities, ",") {
+		allowed[strings.ToLower(strings.TrimSpace(v))] = true
+	}
+
+	filteredRepos := []scm.Repo{}
+
+	for _, repo := range repos {
+		visibility := strings.ToLower(repo.Visibility)
+		// secret gists aren't listed on the profile of their owner, so they count as private
+		if repo.IsGitHubGist && visibility == "secret" {
+			visibility = "private"
+		}
+		if allowed[visibility] {
+			filteredRepos = append(filteredRepos, repo)
+		}
+	}
+
+	return filteredRepos
+}
+
 // FilterByTargetReposPath filters repositories based on a file containing target repo names
 func (rf *RepositoryFilter) FilterByTargetReposPath(cloneTargets []scm.Repo) []scm.Repo {
 	targetReposPath := os.Getenv("GHORG_TARGET_REPOS_PATH")
@@ -235,7 +491,11 @@ func (rf *RepositoryFilter) FilterByGhorgonly(cloneTargets []scm.Repo) []scm.Rep
 	}
 
 	// Read ghorgonly patterns
-	toInclude, err := readGhorgOnly()
+	lines, err := readGhorgOnly()
+	if err != nil {
+		colorlog.PrintErrorAndExit(fmt.Sprintf("Error parsing your ghorgonly, error: %v", err))
+	}
+	toInclude, err := parseRepoPatternRules(lines, filterFileSyntax(lines))
 	if err != nil {
 		colorlog.PrintErrorAndExit(fmt.Sprintf("Error parsing your ghorgonly, error: %v", err))
 	}
@@ -244,15 +504,13 @@ func (rf *RepositoryFilter) FilterByGhorgonly(cloneTargets []scm.Repo) []scm.Rep
 
 	filteredCloneTargets := []scm.Repo{}
 	for _, repo := range cloneTargets {
-		included := false
-		for _, includePattern := range toInclude {
-			if strings.Contains(repo.URL, includePattern) {
-				included = true
-				break
-			}
-		}
+		rule, included := toInclude.match(repo)
 		if included {
 			filteredCloneTargets = append(filteredCloneTargets, repo)
+		} else if rule.pattern == "" {
+			rf.explain(repo, "no ghorgonly rule matches it")
+		} else {
+			rf.explain(repo, "excluded by ghorgonly rule "+rule.String())
 		}
 	}
 
@@ -277,7 +535,11 @@ func (rf *RepositoryFilter) FilterByGhorgignore(cloneTargets []scm.Repo) []scm.R
 	}
 
 	// Read ghorgignore patterns
-	toIgnore, err := readGhorgIgnore()
+	lines, err := readGhorgIgnore()
+	if err != nil {
+		colorlog.PrintErrorAndExit(fmt.Sprintf("Error parsing your ghorgignore, error: %v", err))
+	}
+	toIgnore, err := parseRepoPatternRules(lines, filterFileSyntax(lines))
 	if err != nil {
 		colorlog.PrintErrorAndExit(fmt.Sprintf("Error parsing your ghorgignore, error: %v", err))
 	}
@@ -286,14 +548,10 @@ func (rf *RepositoryFilter) FilterByGhorgignore(cloneTargets []scm.Repo) []scm.R
 
 	filteredCloneTargets := []scm.Repo{}
 	for _, repo := range cloneTargets {
-		ignored := false
-		for _, ignorePattern := range toIgnore {
-			if strings.Contains(repo.URL, ignorePattern) {
-				ignored = true
-				break
-			}
-		}
-		if !ignored {
+		rule, ignored := toIgnore.match(repo)
+		if ignored {
+			rf.explain(repo, "excluded by ghorgignore rule "+rule.String())
+		} else {
 			filteredCloneTargets = append(filteredCloneTargets, repo)
 		}
 	}
@@ -0,0 +1,456 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gabrie30/ghorg/colorlog"
+	"github.com/gabrie30/ghorg/scm"
+)
+
+// filterFieldKind is the type of a field that can be used in a --filter expression, it decides
+// which operators and literals the field accepts
+type filterFieldKind int
+
+const (
+	filterString filterFieldKind = iota
+	filterList
+	filterBool
+	filterSize
+	filterTime
+	filterNumber
+)
+
+// filterField is a field of T that can be used in a --filter expression
+type filterField[T any] struct {
+	kind  filterFieldKind
+	value func(item T) any
+}
+
+// filterFieldSet is every field a --filter expression over T can use, names lists them in error messages
+type filterFieldSet[T any] struct {
+	fields map[string]filterField[T]
+	names  string
+}
+
+// repoFilterFields are the fields available to the --filter expressions of clone
+var repoFilterFields = filterFieldSet[scm.Repo]{
+	fields: map[string]filterField[scm.Repo]{
+		"name":       {filterString, func(r scm.Repo) any { return r.Name }},
+		"path":       {filterString, func(r scm.Repo) any { return r.Path }},
+		"full_name":  {filterString, func(r scm.Repo) any { return r.FullName }},
+		"language":   {filterString, func(r scm.Repo) any { return r.Language }},
+		"visibility": {filterString, func(r scm.Repo) any { return r.Visibility }},
+		"topics":     {filterList, func(r scm.Repo) any { return r.Topics }},
+		"archived":   {filterBool, func(r scm.Repo) any { return r.Archived }},
+		"fork":       {filterBool, func(r scm.Repo) any { return r.Fork }},
+		"wiki":       {filterBool, func(r scm.Repo) any { return r.IsWiki }},
+		"size":       {filterSize, func(r scm.Repo) any { return r.SizeKB }},
+		"pushed_at":  {filterTime, func(r scm.Repo) any { return r.LastActivityAt }},
+	},
+	names: "name, path, full_name, language, visibility, topics, archived, fork, wiki, size or pushed_at",
+}
+
+// filterOperators are the comparison operators each kind of field accepts
+var filterOperators = map[filterFieldKind][]string{
+	filterString: {"==", "!=", "=~", "!~"},
+	filterList:   {"has"},
+	filterBool:   {"==", "!="},
+	filterSize:   {"==", "!=", "<", "<=", ">", ">="},
+	filterTime:   {"<", "<=", ">", ">="},
+	filterNumber: {"==", "!=", "<", "<=", ">", ">="},
+}
+
+// filterExpr is a parsed --filter expression over T
+type filterExpr[T any] interface {
+	matches(item T) bool
+}
+
+// repoFilterExpr is a parsed --filter expression of clone
+type repoFilterExpr = filterExpr[scm.Repo]
+
+type filterAnd[T any] struct{ left, right filterExpr[T] }
+
+func (e filterAnd[T]) matches(item T) bool { return e.left.matches(item) && e.right.matches(item) }
+
+type filterOr[T any] struct{ left, right filterExpr[T] }
+
+func (e filterOr[T]) matches(item T) bool { return e.left.matches(item) || e.right.matches(item) }
+
+type filterNot[T any] struct{ expr filterExpr[T] }
+
+func (e filterNot[T]) matches(item T) bool { return !e.expr.matches(item) }
+
+// filterComparison compares a field of the item with a literal, only the literal matching the kind of the field is set
+type filterComparison[T any] struct {
+	field   filterField[T]
+	op      string
+	str     string
+	re      *regexp.Regexp
+	boolean bool
+	sizeKB  int64
+	number  int64
+	time    time.Time
+}
+
+func (e filterComparison[T]) matches(item T) bool {
+	value := e.field.value(item)
+
+	switch e.field.kind {
+	case filterString:
+		s := value.(string)
+		switch e.op {
+		case "==":
+			return strings.EqualFold(s, e.str)
+		case "!=":
+			return !strings.EqualFold(s, e.str)
+		case "=~":
+			return e.re.MatchString(s)
+		default:
+			return !e.re.MatchString(s)
+		}
+	case filterList:
+		for _, item := range value.([]string) {
+			if strings.EqualFold(item, e.str) {
+				return true
+			}
+		}
+		return false
+	case filterBool:
+		return (value.(bool) == e.boolean) == (e.op == "==")
+	case filterSize:
+		return compareOrdered(value.(int64), e.sizeKB, e.op)
+	case filterNumber:
+		return compareOrdered(value.(int64), e.number, e.op)
+	default:
+		return compareOrdered(value.(time.Time).Unix(), e.time.Unix(), e.op)
+	}
+}
+
+// compareOrdered applies a comparison operator to two numbers
+func compareOrdered(a, b int64, op string) bool {
+	switch op {
+	case "==":
+		return a == b
+	case "!=":
+		return a != b
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	default:
+		return a >= b
+	}
+}
+
+// filterTokenKind is the kind of a token of a --filter expression
+type filterTokenKind int
+
+const (
+	filterTokenEOF filterTokenKind = iota
+	// filterTokenWord is a field name, an operator spelled as a word like has, or an unquoted literal like 500MB, now-90d or true
+	filterTokenWord
+	filterTokenString
+	filterTokenOperator
+)
+
+type filterToken struct {
+	kind  filterTokenKind
+	text  string
+	index int
+}
+
+// filterSymbols are the operators made of symbols, two character operators are listed first so they are matched first
+var filterSymbols = []string{"&&", "||", "==", "!=", "=~", "!~", "<=", ">=", "<", ">", "!", "(", ")"}
+
+// isFilterWordRune reports whether r can be part of a word, dashes and colons are included so dates and now-90d are one word
+func isFilterWordRune(r byte) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.IndexByte("_.-:+", r) != -1
+}
+
+// tokenizeFilter splits a --filter expression into tokens
+func tokenizeFilter(expr string) ([]filterToken, error) {
+	var tokens []filterToken
+
+	for i := 0; i < len(expr); {
+		c := expr[i]
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '"' || c == '\'':
+			end := i + 1
+			for end < len(expr) && expr[end] != c {
+				if expr[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(expr) {
+				return nil, fmt.Errorf("unterminated string starting at position %d", i+1)
+			}
+			text := expr[i+1 : end]
+			if c == '"' {
+				unquoted, err := strconv.Unquote(expr[i : end+1])
+				if err != nil {
+					return nil, fmt.Errorf("invalid string at position %d: %v", i+1, err)
+				}
+				text = unquoted
+			}
+			tokens = append(tokens, filterToken{kind: filterTokenString, text: text, index: i})
+			i = end + 1
+		case isFilterWordRune(c):
+			end := i
+			for end < len(expr) && isFilterWordRune(expr[end]) {
+				end++
+			}
+			tokens = append(tokens, filterToken{kind: filterTokenWord, text: expr[i:end], index: i})
+			i = end
+		default:
+			matched := false
+			for _, symbol := range filterSymbols {
+				if strings.HasPrefix(expr[i:], symbol) {
+					tokens = append(tokens, filterToken{kind: filterTokenOperator, text: symbol, index: i})
+					i += len(symbol)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("unexpected character %q at position %d", c, i+1)
+			}
+		}
+	}
+
+	return append(tokens, filterToken{kind: filterTokenEOF, index: len(expr)}), nil
+}
+
+// filterParser is a recursive descent parser for --filter expressions. From lowest to highest
+// precedence an expression is made of ||, &&, ! and either a comparison, a bool field or a
+// parenthesized expression.
+type filterParser[T any] struct {
+	tokens []filterToken
+	pos    int
+	now    time.Time
+	fields filterFieldSet[T]
+}
+
+// parseRepoFilter parses a --filter expression of clone, relative times like now-90d are counted back from now
+func parseRepoFilter(expr string, now time.Time) (repoFilterExpr, error) {
+	return parseFilter(expr, now, repoFilterFields)
+}
+
+// parseFilter parses a --filter expression that can use the given fields
+func parseFilter[T any](expr string, now time.Time, fields filterFieldSet[T]) (filterExpr[T], error) {
+	tokens, err := tokenizeFilter(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &filterParser[T]{tokens: tokens, now: now, fields: fields}
+	if p.peek().kind == filterTokenEOF {
+		return nil, fmt.Errorf("the expression is empty")
+	}
+
+	e, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != filterTokenEOF {
+		return nil, p.unexpected(tok)
+	}
+
+	return e, nil
+}
+
+func (p *filterParser[T]) peek() filterToken {
+	return p.tokens[p.pos]
+}
+
+func (p *filterParser[T]) next() filterToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != filterTokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *filterParser[T]) isOperator(tok filterToken, op string) bool {
+	return tok.kind == filterTokenOperator && tok.text == op
+}
+
+func (p *filterParser[T]) unexpected(tok filterToken) error {
+	if tok.kind == filterTokenEOF {
+		return fmt.Errorf("unexpected end of expression")
+	}
+	return fmt.Errorf("unexpected %q at position %d", tok.text, tok.index+1)
+}
+
+func (p *filterParser[T]) parseOr() (filterExpr[T], error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOperator(p.peek(), "||") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = filterOr[T]{left, right}
+	}
+	return left, nil
+}
+
+func (p *filterParser[T]) parseAnd() (filterExpr[T], error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOperator(p.peek(), "&&") {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = filterAnd[T]{left, right}
+	}
+	return left, nil
+}
+
+func (p *filterParser[T]) parseUnary() (filterExpr[T], error) {
+	if p.isOperator(p.peek(), "!") {
+		p.next()
+		e, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return filterNot[T]{e}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *filterParser[T]) parsePrimary() (filterExpr[T], error) {
+	tok := p.next()
+
+	if p.isOperator(tok, "(") {
+		e, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); !p.isOperator(closing, ")") {
+			return nil, p.unexpected(closing)
+		}
+		return e, nil
+	}
+
+	if tok.kind != filterTokenWord {
+		return nil, p.unexpected(tok)
+	}
+
+	field, ok := p.fields.fields[tok.text]
+	if !ok {
+		return nil, fmt.Errorf("unknown field %q at position %d, must be one of %s", tok.text, tok.index+1, p.fields.names)
+	}
+
+	opTok := p.peek()
+	op := opTok.text
+	isComparison := opTok.kind == filterTokenOperator && op != "&&" && op != "||" && op != ")" && op != "!" ||
+		opTok.kind == filterTokenWord && op == "has"
+	if !isComparison {
+		if field.kind != filterBool {
+			return nil, fmt.Errorf("field %q at position %d must be compared with a value", tok.text, tok.index+1)
+		}
+		return filterComparison[T]{field: field, op: "==", boolean: true}, nil
+	}
+	p.next()
+
+	if !slices.Contains(filterOperators[field.kind], op) {
+		return nil, fmt.Errorf("operator %q at position %d can't be used with %s, use one of %s", op, opTok.index+1, tok.text, strings.Join(filterOperators[field.kind], ", "))
+	}
+
+	valueTok := p.next()
+	if valueTok.kind != filterTokenWord && valueTok.kind != filterTokenString {
+		return nil, p.unexpected(valueTok)
+	}
+
+	c := filterComparison[T]{field: field, op: op}
+	if err := p.parseLiteral(&c, valueTok); err != nil {
+		return nil, fmt.Errorf("invalid value for %s at position %d: %v", tok.text, valueTok.index+1, err)
+	}
+
+	return c, nil
+}
+
+// parseLiteral sets the literal of the comparison from a token, the kind of the field decides how it's parsed
+func (p *filterParser[T]) parseLiteral(c *filterComparison[T], tok filterToken) error {
+	switch c.field.kind {
+	case filterString, filterList:
+		c.str = tok.text
+		if c.op == "=~" || c.op == "!~" {
+			re, err := regexp.Compile(tok.text)
+			if err != nil {
+				return err
+			}
+			c.re = re
+		}
+	case filterBool:
+		b, err := strconv.ParseBool(tok.text)
+		if err != nil {
+			return fmt.Errorf("%q is not true or false", tok.text)
+		}
+		c.boolean = b
+	case filterSize:
+		kb, err := parseRepoSize(tok.text)
+		if err != nil {
+			return err
+		}
+		c.sizeKB = kb
+	case filterNumber:
+		n, err := strconv.ParseInt(tok.text, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a whole number", tok.text)
+		}
+		c.number = n
+	case filterTime:
+		t, err := p.parseTime(tok.text)
+		if err != nil {
+			return err
+		}
+		c.time = t
+	}
+	return nil
+}
+
+// parseTime parses now, now-<duration> or anything parseActivityTime accepts
+func (p *filterParser[T]) parseTime(value string) (time.Time, error) {
+	if value == "now" {
+		return p.now, nil
+	}
+	return parseActivityTime(strings.TrimPrefix(value, "now-"), p.now)
+}
+
+// FilterByExpression keeps repositories matching the GHORG_FILTER expression
+func (rf *RepositoryFilter) FilterByExpression(repos []scm.Repo) []scm.Repo {
+	expr, err := parseRepoFilter(os.Getenv("GHORG_FILTER"), time.Now())
+	if err != nil {
+		colorlog.PrintErrorAndExit(fmt.Sprintf("Invalid GHORG_FILTER: %v", err))
+	}
+
+	filteredRepos := []scm.Repo{}
+
+	for _, repo := range repos {
+		if expr.matches(repo) {
+			filteredRepos = append(filteredRepos, repo)
+		}
+	}
+
+	return filteredRepos
+}
@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gabrie30/ghorg/scm"
+)
+
+func TestParseRepoFilter_Matches(t *testing.T) {
+	now := time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC)
+
+	svc := scm.Repo{
+		Name:           "svc-billing",
+		Path:           "platform/svc-billing",
+		Language:       "Go",
+		Visibility:     "private",
+		Topics:         []string{"prod", "payments"},
+		SizeKB:         2 * 1024,
+		LastActivityAt: now.AddDate(0, 0, -10),
+	}
+	archived := scm.Repo{
+		Name:           "svc-legacy",
+		Language:       "Java",
+		Visibility:     "public",
+		Archived:       true,
+		Topics:         []string{"prod"},
+		SizeKB:         3 * 1024 * 1024,
+		LastActivityAt: now.AddDate(-2, 0, 0),
+	}
+	fork := scm.Repo{Name: "kubernetes", Fork: true, Visibility: "public"}
+	gist := scm.Repo{Name: "notes", IsGitHubGist: true}
+
+	repos := []scm.Repo{svc, archived, fork, gist}
+
+	testCases := []struct {
+		expr string
+		want []string
+	}{
+		{expr: `name =~ "^svc-" && !archived && topics has "prod" && pushed_at > now-90d`, want: []string{"svc-billing"}},
+		{expr: `archived`, want: []string{"svc-legacy"}},
+		{expr: `!archived`, want: []string{"svc-billing", "kubernetes", "notes"}},
+		{expr: `archived == false && fork != true`, want: []string{"svc-billing", "notes"}},
+		{expr: `fork || language == "java"`, want: []string{"svc-legacy", "kubernetes"}},
+		{expr: `name == 'KUBERNETES'`, want: []string{"kubernetes"}},
+		{expr: `name !~ '^svc-\w+$'`, want: []string{"kubernetes", "notes"}},
+		{expr: `path =~ "^platform/"`, want: []string{"svc-billing"}},
+		{expr: `topics has "PAYMENTS"`, want: []string{"svc-billing"}},
+		{expr: `!(topics has "prod")`, want: []string{"kubernetes", "notes"}},
+		{expr: `size > 1 && size < 1GB`, want: []string{"svc-billing"}},
+		{expr: `size >= 3GB`, want: []string{"svc-legacy"}},
+		{expr: `pushed_at < 2023-01-01`, want: []string{"svc-legacy", "kubernetes", "notes"}},
+		{expr: `pushed_at>=now-1y`, want: []string{"svc-billing"}},
+		{expr: `visibility == "public" && (fork || archived)`, want: []string{"svc-legacy", "kubernetes"}},
+		{expr: `visibility == "public" && fork || archived`, want: []string{"svc-legacy", "kubernetes"}},
+		{expr: `visibility == "private" || fork && archived`, want: []string{"svc-billing"}},
+	}
+
+	for _, tc := range testCases {
+		expr, err := parseRepoFilter(tc.expr, now)
+		if err != nil {
+			t.Errorf("parseRepoFilter(%q): unexpected error %v", tc.expr, err)
+			continue
+		}
+
+		got := []string{}
+		for _, repo := range repos {
+			if expr.matches(repo) {
+				got = append(got, repo.Name)
+			}
+		}
+
+		if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+			t.Errorf("parseRepoFilter(%q): expected %v, got %v", tc.expr, tc.want, got)
+		}
+	}
+}
+
+func TestParseRepoFilter_Errors(t *testing.T) {
+	testCases := []struct {
+		expr string
+		want string
+	}{
+		{expr: ``, want: "empty"},
+		{expr: `stars > 10`, want: `unknown field "stars"`},
+		{expr: `name`, want: "must be compared"},
+		{expr: `name > "a"`, want: `operator ">" at position 6 can't be used with name`},
+		{expr: `topics == "prod"`, want: `operator "==" at position 8 can't be used with topics, use one of has`},
+		{expr: `name =~ "("`, want: "invalid value for name"},
+		{expr: `size > huge`, want: "invalid value for size"},
+		{expr: `pushed_at > yesterday`, want: "invalid value for pushed_at"},
+		{expr: `archived == maybe`, want: "invalid value for archived"},
+		{expr: `name == "svc`, want: "unterminated string"},
+		{expr: `(archived`, want: "unexpected end of expression"},
+		{expr: `archived fork`, want: `unexpected "fork" at position 10`},
+		{expr: `archived & fork`, want: "unexpected character"},
+	}
+
+	for _, tc := range testCases {
+		_, err := parseRepoFilter(tc.expr, time.Now())
+		if err == nil {
+			t.Errorf("parseRepoFilter(%q): expected an error", tc.expr)
+			continue
+		}
+		if !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("parseRepoFilter(%q): expected error containing %q, got %q", tc.expr, tc.want, err.Error())
+		}
+	}
+}
+
+func TestRepositoryFilter_ApplyAllFilters_Expression(t *testing.T) {
+	defer UnsetEnv("GHORG_")()
+	os.Setenv("GHORG_FILTER", `!fork && language == "go"`)
+	os.Setenv("GHORG_MATCH_PREFIX", "svc-")
+
+	repos := []scm.Repo{
+		{Name: "svc-api", Language: "Go"},
+		{Name: "svc-fork", Language: "Go", Fork: true},
+		{Name: "svc-web", Language: "TypeScript"},
+		{Name: "tools", Language: "Go"},
+	}
+
+	got := NewRepositoryFilter().ApplyAllFilters(repos)
+
+	if len(got) != 1 || got[0].Name != "svc-api" {
+		t.Errorf("expected only svc-api, got %v", got)
+	}
+}
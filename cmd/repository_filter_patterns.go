@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/gabrie30/ghorg/scm"
+)
+
+const (
+	// filterFileSyntaxGitignore reads ghorgignore and ghorgonly like a .gitignore, matching repo paths
+	filterFileSyntaxGitignore = "gitignore"
+	// filterFileSyntaxSubstring is the original syntax, every line is matched as a substring of the clone url
+	filterFileSyntaxSubstring = "substring"
+	// filterFileSyntaxHeader on the first line of a ghorgignore or ghorgonly switches it to the gitignore syntax
+	filterFileSyntaxHeader = "# ghorg: gitignore"
+)
+
+// filterFileSyntax returns the syntax of a ghorgignore or ghorgonly file. GHORG_FILTER_FILE_SYNTAX wins when
+// it's set, otherwise files starting with filterFileSyntaxHeader use the gitignore syntax and every other file
+// keeps the substring syntax it was written for.
+func filterFileSyntax(lines []string) string {
+	if syntax := os.Getenv("GHORG_FILTER_FILE_SYNTAX"); syntax != "" {
+		return syntax
+	}
+	if len(lines) > 0 && strings.EqualFold(strings.TrimSpace(lines[0]), filterFileSyntaxHeader) {
+		return filterFileSyntaxGitignore
+	}
+	return filterFileSyntaxSubstring
+}
+
+// repoPatternRule is a single line of a ghorgignore or ghorgonly file
+type repoPatternRule struct {
+	pattern string
+	line    int
+	negate  bool
+	re      *regexp.Regexp
+}
+
+// String returns the rule as it was written in the file
+func (r repoPatternRule) String() string {
+	return fmt.Sprintf("%q (line %d)", r.pattern, r.line)
+}
+
+// repoPatternRules are the rules of a ghorgignore or ghorgonly file in the order they were written
+type repoPatternRules []repoPatternRule
+
+// parseRepoPatternRules parses the lines of a ghorgignore or ghorgonly file. With the gitignore syntax
+// blank lines and lines starting with # are skipped, a leading ! negates a rule and \# or \! escape
+// those characters. With the substring syntax every non empty line is a rule.
+func parseRepoPatternRules(lines []string, syntax string) (repoPatternRules, error) {
+	if syntax != filterFileSyntaxGitignore && syntax != filterFileSyntaxSubstring {
+		return nil, fmt.Errorf("invalid GHORG_FILTER_FILE_SYNTAX %q, must be gitignore or substring", syntax)
+	}
+
+	var rules repoPatternRules
+	for i, line := range lines {
+		if syntax == filterFileSyntaxSubstring {
+			if line != "" {
+				rules = append(rules, repoPatternRule{pattern: line, line: i + 1})
+			}
+			continue
+		}
+
+		pattern := strings.TrimRight(line, " \t\r")
+		if pattern == "" || strings.HasPrefix(pattern, "#") {
+			continue
+		}
+
+		rule := repoPatternRule{pattern: pattern, line: i + 1}
+		if strings.HasPrefix(pattern, "!") {
+			rule.negate = true
+			pattern = pattern[1:]
+		} else if strings.HasPrefix(pattern, `\#`) || strings.HasPrefix(pattern, `\!`) {
+			pattern = pattern[1:]
+		}
+
+		re, err := repoPatternRegexp(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q on line %d: %v", line, i+1, err)
+		}
+		rule.re = re
+
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// scpLikeURL matches ssh clone urls like git@github.com:org/repo.git
+var scpLikeURL = regexp.MustCompile(`^[\w.-]+@[\w.-]+:`)
+
+// repoPatternRegexp compiles a gitignore style pattern to a regex over repo paths like org/repo or
+// group/subgroup/repo. Patterns without a slash match any part of the path, patterns with one are
+// anchored to the start. A pattern matching a group also matches every repo below it. Clone urls
+// are accepted for compatibility with files written for the substring syntax and match that repo.
+func repoPatternRegexp(pattern string) (*regexp.Regexp, error) {
+	if strings.Contains(pattern, "://") || scpLikeURL.MatchString(pattern) {
+		pattern = "/" + repoPatternPath(pattern)
+	}
+
+	pattern = strings.TrimSuffix(pattern, "/")
+	anchored := strings.Contains(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+	if pattern == "" {
+		return nil, fmt.Errorf("the pattern is empty")
+	}
+
+	var b strings.Builder
+	b.WriteString("(?i)")
+	if anchored {
+		b.WriteString("^")
+	} else {
+		b.WriteString("(^|/)")
+	}
+
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end == -1 {
+				return nil, fmt.Errorf("unterminated character class")
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(pattern):
+			i++
+			b.WriteString(regexp.QuoteMeta(string(pattern[i])))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	b.WriteString("(/.*)?$")
+
+	return regexp.Compile(b.String())
+}
+
+// repoPatternPath returns the path patterns are matched against, the clone url without its
+// scheme, host, credentials and .git suffix, e.g. org/repo or group/subgroup/repo
+func repoPatternPath(cloneURL string) string {
+	path := cloneURL
+	if u, err := url.Parse(cloneURL); err == nil && u.Host != "" {
+		path = u.Path
+	} else if _, after, ok := strings.Cut(cloneURL, ":"); ok {
+		// scp-like ssh urls, git@github.com:org/repo.git
+		path = after
+	}
+
+	return strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+}
+
+// match returns the rule deciding whether the repo matches, the last matching rule wins like in a
+// .gitignore. It returns false when no rule matches or the deciding rule is negated.
+func (rules repoPatternRules) match(repo scm.Repo) (repoPatternRule, bool) {
+	var decided repoPatternRule
+	matched := false
+
+	path := repoPatternPath(repo.URL)
+	for _, rule := range rules {
+		if rule.re == nil {
+			if strings.Contains(repo.URL, rule.pattern) {
+				return rule, true
+			}
+			continue
+		}
+		if rule.re.MatchString(path) {
+			decided = rule
+			matched = !rule.negate
+		}
+	}
+
+	return decided, matched
+}
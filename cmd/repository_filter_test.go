@@ -4,6 +4,7 @@ import (
 	"os"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/gabrie30/ghorg/scm"
 )
@@ -49,6 +50,17 @@ func TestRepositoryFilter_FilterByRegexMatch(t *testing.T) {
 			repos:         []scm.Repo{{Name: "repo1"}, {Name: "repo2"}},
 			expectedRepos: []scm.Repo{},
 		},
+		{
+			name:  "gists are already matched by the scm client",
+			regex: "^notes$",
+			repos: []scm.Repo{
+				{Name: "notes-abc123", IsGitHubGist: true},
+				{Name: "notes-repo"},
+			},
+			expectedRepos: []scm.Repo{
+				{Name: "notes-abc123", IsGitHubGist: true},
+			},
+		},
 		{
 			name:          "empty regex returns all",
 			regex:         "",
@@ -103,6 +115,17 @@ func TestRepositoryFilter_FilterByExcludeRegexMatch(t *testing.T) {
 				{Name: "core-lib"},
 			},
 		},
+		{
+			name:  "gists are already matched by the scm client",
+			regex: "^notes",
+			repos: []scm.Repo{
+				{Name: "notes-abc123", IsGitHubGist: true},
+				{Name: "notes-repo"},
+			},
+			expectedRepos: []scm.Repo{
+				{Name: "notes-abc123", IsGitHubGist: true},
+			},
+		},
 		{
 			name:          "no exclusions",
 			regex:         "^nonexistent",
@@ -124,6 +147,201 @@ func TestRepositoryFilter_FilterByExcludeRegexMatch(t *testing.T) {
 	}
 }
 
+func TestRepositoryFilter_FilterByLastActivity(t *testing.T) {
+	filter := NewRepositoryFilter()
+
+	old := scm.Repo{Name: "old", LastActivityAt: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	recent := scm.Repo{Name: "recent", LastActivityAt: time.Now().Add(-time.Hour)}
+	unknown := scm.Repo{Name: "unknown"}
+
+	testCases := []struct {
+		name          string
+		since         string
+		before        string
+		expectedRepos []scm.Repo
+	}{
+		{
+			name:          "pushed since a date",
+			since:         "2021-01-01",
+			expectedRepos: []scm.Repo{recent, unknown},
+		},
+		{
+			name:          "pushed since a duration",
+			since:         "12mo",
+			expectedRepos: []scm.Repo{recent, unknown},
+		},
+		{
+			name:          "pushed before a duration",
+			before:        "1y",
+			expectedRepos: []scm.Repo{old, unknown},
+		},
+		{
+			name:          "pushed between two dates",
+			since:         "2019-06-01",
+			before:        "2020-06-01T00:00:00Z",
+			expectedRepos: []scm.Repo{old, unknown},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("GHORG_PUSHED_SINCE", tc.since)
+			t.Setenv("GHORG_PUSHED_BEFORE", tc.before)
+
+			result := filter.FilterByLastActivity([]scm.Repo{old, recent, unknown})
+			if !reflect.DeepEqual(result, tc.expectedRepos) {
+				t.Errorf("Expected %v, got %v", tc.expectedRepos, result)
+			}
+		})
+	}
+}
+
+func TestParseActivityTime(t *testing.T) {
+	now := time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{value: "2024-01-31", want: time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)},
+		{value: "2024-01-31T10:00:00Z", want: time.Date(2024, 1, 31, 10, 0, 0, 0, time.UTC)},
+		{value: "36h", want: now.Add(-36 * time.Hour)},
+		{value: "90d", want: now.AddDate(0, 0, -90)},
+		{value: "2w", want: now.AddDate(0, 0, -14)},
+		{value: "12mo", want: now.AddDate(0, -12, 0)},
+		{value: "1Y", want: now.AddDate(-1, 0, 0)},
+		{value: "12m", wantErr: true},
+		{value: "last year", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		got, err := parseActivityTime(tc.value, now)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("parseActivityTime(%q): expected an error, got %v", tc.value, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseActivityTime(%q): unexpected error %v", tc.value, err)
+			continue
+		}
+		if !got.Equal(tc.want) {
+			t.Errorf("parseActivityTime(%q): expected %v, got %v", tc.value, tc.want, got)
+		}
+	}
+}
+
+func TestRepositoryFilter_FilterBySize(t *testing.T) {
+	filter := NewRepositoryFilter()
+
+	small := scm.Repo{Name: "small", SizeKB: 512}
+	large := scm.Repo{Name: "large", SizeKB: 3 * 1024 * 1024}
+	unknown := scm.Repo{Name: "unknown"}
+
+	testCases := []struct {
+		name          string
+		min           string
+		max           string
+		expectedRepos []scm.Repo
+	}{
+		{
+			name:          "max size in gigabytes",
+			max:           "2GB",
+			expectedRepos: []scm.Repo{small, unknown},
+		},
+		{
+			name:          "min size without a unit is megabytes",
+			min:           "1",
+			expectedRepos: []scm.Repo{large, unknown},
+		},
+		{
+			name:          "min and max",
+			min:           "100KB",
+			max:           "1.5mb",
+			expectedRepos: []scm.Repo{small, unknown},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("GHORG_MIN_REPO_SIZE", tc.min)
+			t.Setenv("GHORG_MAX_REPO_SIZE", tc.max)
+
+			result := filter.FilterBySize([]scm.Repo{small, large, unknown})
+			if !reflect.DeepEqual(result, tc.expectedRepos) {
+				t.Errorf("Expected %v, got %v", tc.expectedRepos, result)
+			}
+		})
+	}
+}
+
+func TestParseRepoSize(t *testing.T) {
+	testCases := []struct {
+		value   string
+		want    int64
+		wantErr bool
+	}{
+		{value: "500", want: 500 * 1024},
+		{value: "500MB", want: 500 * 1024},
+		{value: "2 GB", want: 2 * 1024 * 1024},
+		{value: "1.5gb", want: 1536 * 1024},
+		{value: "100KB", want: 100},
+		{value: "1TB", wantErr: true},
+		{value: "big", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		got, err := parseRepoSize(tc.value)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("parseRepoSize(%q): expected an error, got %d", tc.value, got)
+			}
+			continue
+		}
+		if err != nil || got != tc.want {
+			t.Errorf("parseRepoSize(%q): expected %d, got %d (err: %v)", tc.value, tc.want, got, err)
+		}
+	}
+}
+
+func TestRepositoryFilter_FilterByVisibility(t *testing.T) {
+	filter := NewRepositoryFilter()
+
+	repos := []scm.Repo{
+		{Name: "public", Visibility: "public"},
+		{Name: "private", Visibility: "private"},
+		{Name: "internal", Visibility: "internal"},
+		{Name: "unknown"},
+		{Name: "secret-gist", Visibility: "secret", IsGitHubGist: true},
+	}
+
+	testCases := []struct {
+		name          string
+		visibility    string
+		expectedNames []string
+	}{
+		{name: "public only", visibility: "public", expectedNames: []string{"public"}},
+		{name: "private and internal", visibility: "Private, internal", expectedNames: []string{"private", "internal", "secret-gist"}},
+		{name: "no filter", visibility: "", expectedNames: []string{"public", "private", "internal", "unknown", "secret-gist"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("GHORG_VISIBILITY", tc.visibility)
+
+			var names []string
+			for _, repo := range filter.FilterByVisibility(repos) {
+				names = append(names, repo.Name)
+			}
+			if !reflect.DeepEqual(names, tc.expectedNames) {
+				t.Errorf("Expected %v, got %v", tc.expectedNames, names)
+			}
+		})
+	}
+}
+
 func TestRepositoryFilter_FilterByMatchPrefix(t *testing.T) {
 	filter := NewRepositoryFilter()
 
@@ -258,6 +476,23 @@ func TestRepositoryFilter_FilterByGhorgignore(t *testing.T) {
 				{Name: "repo1", URL: "https://github.com/org/repo1"},
 			},
 		},
+		{
+			name:          "gitignore syntax with globs, negation, comments and urls",
+			ignoreContent: "# ghorg: gitignore\n# archived stuff\n\nold-*\n!old-but-gold\nhttps://github.com/org/mirror.git\n**/vendor/**\n",
+			repos: []scm.Repo{
+				{Name: "repo1", URL: "https://github.com/org/repo1"},
+				{Name: "old-project", URL: "https://github.com/org/old-project"},
+				{Name: "old-but-gold", URL: "https://github.com/org/old-but-gold"},
+				{Name: "mirror", URL: "https://github.com/org/mirror"},
+				{Name: "mirror-tools", URL: "https://github.com/org/mirror-tools"},
+				{Name: "lib", URL: "https://gitlab.com/group/vendor/lib.git"},
+			},
+			expectedRepos: []scm.Repo{
+				{Name: "repo1", URL: "https://github.com/org/repo1"},
+				{Name: "old-but-gold", URL: "https://github.com/org/old-but-gold"},
+				{Name: "mirror-tools", URL: "https://github.com/org/mirror-tools"},
+			},
+		},
 		{
 			name:          "filters multiple patterns",
 			ignoreContent: "test-repo\nold-project",
@@ -327,6 +562,32 @@ func TestRepositoryFilter_FilterByGhorgonly(t *testing.T) {
 				{Name: "user-service", URL: "https://github.com/org/user-service"},
 			},
 		},
+		{
+			name:        "gitignore syntax header matches names instead of substrings",
+			onlyContent: "# ghorg: gitignore\napi",
+			repos: []scm.Repo{
+				{Name: "api", URL: "https://github.com/org/api.git"},
+				{Name: "api-gateway", URL: "https://github.com/org/api-gateway.git"},
+				{Name: "payments-api", URL: "https://github.com/org/payments-api.git"},
+			},
+			expectedRepos: []scm.Repo{
+				{Name: "api", URL: "https://github.com/org/api.git"},
+			},
+		},
+		{
+			name:        "gitignore syntax with globs, anchored groups and negation",
+			onlyContent: "# ghorg: gitignore\n# platform team\nplatform/services/*\n!platform/services/legacy-*\n*-api\n",
+			repos: []scm.Repo{
+				{Name: "billing", URL: "https://gitlab.com/platform/services/billing.git"},
+				{Name: "legacy-billing", URL: "https://gitlab.com/platform/services/legacy-billing.git"},
+				{Name: "billing", URL: "https://gitlab.com/other/services/billing.git"},
+				{Name: "payments-api", URL: "git@gitlab.com:other/payments-api.git"},
+			},
+			expectedRepos: []scm.Repo{
+				{Name: "billing", URL: "https://gitlab.com/platform/services/billing.git"},
+				{Name: "payments-api", URL: "git@gitlab.com:other/payments-api.git"},
+			},
+		},
 		{
 			name:        "no matches returns empty",
 			onlyContent: "nonexistent",
@@ -451,6 +712,38 @@ func TestRepositoryFilter_ApplyAllFilters(t *testing.T) {
 	}
 }
 
+func TestRepositoryFilter_ApplyAllFilters_Skipped(t *testing.T) {
+	defer UnsetEnv("GHORG_")()
+
+	repos := []scm.Repo{
+		{Name: "api", URL: "https://github.com/org/api.git"},
+		{Name: "lib-utils", URL: "https://github.com/org/lib-utils.git"},
+		{Name: "ignored", URL: "https://github.com/org/ignored.git"},
+	}
+
+	_ = os.Setenv("GHORG_EXCLUDE_MATCH_PREFIX", "lib-")
+
+	tmpfile, err := createTempFileWithContent("ignored")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer func() { _ = os.Remove(tmpfile.Name()) }()
+	_ = os.Setenv("GHORG_IGNORE_PATH", tmpfile.Name())
+	_ = os.Setenv("GHORG_ONLY_PATH", "/tmp/nonexistent-ghorgonly-file-for-test")
+
+	filter := NewRepositoryFilter()
+	filter.ApplyAllFilters(repos)
+
+	expected := []skippedRepo{
+		{Repo: repos[1], Filter: "exclude-match-prefix"},
+		{Repo: repos[2], Filter: "ghorgignore", Reason: `excluded by ghorgignore rule "ignored" (line 1)`},
+	}
+
+	if !reflect.DeepEqual(filter.Skipped(), expected) {
+		t.Errorf("Expected %v, got %v", expected, filter.Skipped())
+	}
+}
+
 // Benchmark tests for performance validation
 func BenchmarkRepositoryFilter_FilterByRegexMatch(b *testing.B) {
 	filter := NewRepositoryFilter()
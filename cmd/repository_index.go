@@ -0,0 +1,415 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gabrie30/ghorg/scm"
+)
+
+// repoIndexFileName is the manifest of the repos cloned into an output dir, it records where each
+// local directory came from so commands don't have to infer it from directory names
+const repoIndexFileName = "_ghorg_index.json"
+
+// repoIndexVersion is bumped when the format of the index changes in a way older versions can't read
+const repoIndexVersion = 1
+
+// repoIndexSaveInterval limits how often the index is written while repos are processed, it's
+// always written once more when the clone finishes
+const repoIndexSaveInterval = 2 * time.Second
+
+// Kinds of clones recorded in the index, a wiki shares its ID with its repo
+const (
+	repoIndexKindRepo    = "repo"
+	repoIndexKindWiki    = "wiki"
+	repoIndexKindSnippet = "snippet"
+	repoIndexKindGist    = "gist"
+)
+
+// repoIndexEntry is a single local clone
+type repoIndexEntry struct {
+	// Path is the directory of the clone relative to the output dir, always with forward slashes
+	Path string `json:"path"`
+	// ID is the stable id the scm assigned to the repo, it's empty on providers that don't report one
+	ID   string `json:"id,omitempty"`
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	// FullName is the owner/name of the repo on providers that address repos that way
+	FullName string `json:"full_name,omitempty"`
+	// SCMPath is where the repo is located within the scm, e.g. group/subgroup/repo
+	SCMPath  string `json:"scm_path,omitempty"`
+	URL      string `json:"url"`
+	Provider string `json:"provider"`
+	// Target is the org or user the repo was cloned from
+	Target    string `json:"target,omitempty"`
+	CloneType string `json:"clone_type,omitempty"`
+	// Branch is the branch ghorg checks out, the default branch unless --branch was set
+	Branch    string    `json:"branch,omitempty"`
+	VCS       string    `json:"vcs,omitempty"`
+	Topics    []string  `json:"topics,omitempty"`
+	ClonedAt  time.Time `json:"cloned_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// identity returns what identifies the entry across renames, it's empty when the scm reported no ID
+func (e repoIndexEntry) identity() string {
+	if e.ID == "" {
+		return ""
+	}
+	return e.Kind + ":" + e.VCS + ":" + e.ID
+}
+
+// repoIndexFile is the json document written to repoIndexFileName
+type repoIndexFile struct {
+	Version int              `json:"version"`
+	Repos   []repoIndexEntry `json:"repos"`
+}
+
+// repoIndex is the index of one output dir
+type repoIndex struct {
+	outputDir string
+	mutex     sync.Mutex
+	entries   map[string]repoIndexEntry
+	dirty     bool
+	lastSaved time.Time
+}
+
+// repoIndexPath returns where the index of outputDir is kept
+func repoIndexPath(outputDir string) string {
+	return filepath.Join(outputDir, repoIndexFileName)
+}
+
+// loadRepoIndex reads the index of outputDir, returning an empty index when there is none yet
+func loadRepoIndex(outputDir string) (*repoIndex, error) {
+	index := &repoIndex{outputDir: outputDir, entries: make(map[string]repoIndexEntry), lastSaved: time.Now()}
+
+	data, err := os.ReadFile(repoIndexPath(outputDir))
+	if os.IsNotExist(err) {
+		return index, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var file repoIndexFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("could not parse %s: %v", repoIndexPath(outputDir), err)
+	}
+	if file.Version > repoIndexVersion {
+		return nil, fmt.Errorf("%s was written by a newer version of ghorg (index version %d)", repoIndexPath(outputDir), file.Version)
+	}
+
+	for _, entry := range file.Repos {
+		index.entries[entry.Path] = entry
+	}
+
+	return index, nil
+}
+
+// repoIndexKind returns the kind of clone repo is
+func repoIndexKind(repo scm.Repo) string {
+	switch {
+	case repo.IsWiki:
+		return repoIndexKindWiki
+	case repo.IsGitHubGist:
+		return repoIndexKindGist
+	case repo.IsGitLabSnippet || repo.IsSnippet:
+		return repoIndexKindSnippet
+	default:
+		return repoIndexKindRepo
+	}
+}
+
+// newRepoIndexEntry builds the entry of a repo cloned to relPath, the target and clone type it was cloned with are
+// recorded by record
+func newRepoIndexEntry(repo scm.Repo, relPath string) repoIndexEntry {
+	vcs := repo.VCS
+	if vcs == "" {
+		vcs = scm.VCSGit
+	}
+
+	id := repo.ID
+	if repo.IsGitLabSnippet {
+		id = repo.GitLabSnippetInfo.ID
+	}
+
+	return repoIndexEntry{
+		Path:     filepath.ToSlash(relPath),
+		ID:       id,
+		Kind:     repoIndexKind(repo),
+		Name:     repo.Name,
+		FullName: repo.FullName,
+		SCMPath:  strings.TrimPrefix(filepath.ToSlash(repo.Path), "/"),
+		URL:      redactURL(repo.URL),
+		Provider: strings.ToLower(os.Getenv("GHORG_SCM_TYPE")),
+		Branch:   repo.CloneBranch,
+		VCS:      vcs,
+		Topics:   repo.Topics,
+	}
+}
+
+// relPath returns the directory of a clone relative to the output dir with forward slashes
+func (index *repoIndex) relPath(hostPath string) (string, error) {
+	rel, err := filepath.Rel(index.outputDir, hostPath)
+	if err != nil {
+		return "", err
+	}
+	if rel == "." || strings.HasPrefix(rel, "..") {
+		return "", fmt.Errorf("%s is not inside %s", hostPath, index.outputDir)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// record adds or updates the entry of a repo that was just cloned or updated at repo.HostPath, target is the org or
+// user it was cloned from with cloneType
+func (index *repoIndex) record(repo scm.Repo, target string, cloneType string) error {
+	rel, err := index.relPath(repo.HostPath)
+	if err != nil {
+		return err
+	}
+
+	entry := newRepoIndexEntry(repo, rel)
+	entry.Target, entry.CloneType = target, cloneType
+	now := time.Now().UTC()
+
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+
+	entry.ClonedAt, entry.UpdatedAt = now, now
+	// keep the original clone time unless a different repo now lives in the directory
+	if existing, ok := index.entries[entry.Path]; ok && existing.Kind == entry.Kind && (existing.identity() == entry.identity() || existing.ID == "" || entry.ID == "") {
+		entry.ClonedAt = existing.ClonedAt
+	}
+	index.entries[entry.Path] = entry
+	index.dirty = true
+
+	if time.Since(index.lastSaved) >= repoIndexSaveInterval {
+		return index.saveLocked()
+	}
+	return nil
+}
+
+// remove drops the entry of a local directory, relPath is relative to the output dir
+func (index *repoIndex) remove(relPath string) {
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+
+	relPath = filepath.ToSlash(relPath)
+	if _, ok := index.entries[relPath]; ok {
+		delete(index.entries, relPath)
+		index.dirty = true
+	}
+}
+
+// merge adds entries to the index, replacing the entries of the same directories
+func (index *repoIndex) merge(entries []repoIndexEntry) {
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+
+	for _, entry := range entries {
+		index.entries[entry.Path] = entry
+		index.dirty = true
+	}
+}
+
+// lookup returns the entry of a local directory, relPath is relative to the output dir
+func (index *repoIndex) lookup(relPath string) (repoIndexEntry, bool) {
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+
+	entry, ok := index.entries[filepath.ToSlash(relPath)]
+	return entry, ok
+}
+
+// list returns every entry sorted by path
+func (index *repoIndex) list() []repoIndexEntry {
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+
+	return index.sortedLocked()
+}
+
+func (index *repoIndex) sortedLocked() []repoIndexEntry {
+	entries := make([]repoIndexEntry, 0, len(index.entries))
+	for _, entry := range index.entries {
+		entries = append(entries, entry)
+	}
+	slices.SortFunc(entries, func(a, b repoIndexEntry) int { return strings.Compare(a.Path, b.Path) })
+	return entries
+}
+
+// save writes the index if it changed since it was last written
+func (index *repoIndex) save() error {
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+
+	return index.saveLocked()
+}
+
+func (index *repoIndex) saveLocked() error {
+	if !index.dirty {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(repoIndexFile{Version: repoIndexVersion, Repos: index.sortedLocked()}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// write to a temp file first so an interrupted clone never leaves a truncated index behind
+	tmp := repoIndexPath(index.outputDir) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, repoIndexPath(index.outputDir)); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+
+	index.dirty = false
+	index.lastSaved = time.Now()
+	return nil
+}
+
+// inRemote reports whether the repo a local directory was cloned from is still among repos. known
+// is false when the directory isn't in the index, callers then fall back to matching by name.
+// Entries with an ID are matched by it so a repo renamed on the scm still counts as found.
+func (index *repoIndex) inRemote(relPath string, repos []scm.Repo) (found bool, known bool) {
+	if index == nil {
+		return false, false
+	}
+
+	entry, ok := index.lookup(relPath)
+	if !ok {
+		return false, false
+	}
+
+	for _, repo := range repos {
+		candidate := newRepoIndexEntry(repo, "")
+		if entry.identity() != "" && candidate.identity() != "" {
+			if entry.identity() == candidate.identity() {
+				return true, true
+			}
+			continue
+		}
+		if entry.Kind == candidate.Kind && entry.URL == candidate.URL {
+			return true, true
+		}
+	}
+
+	return false, true
+}
+
+// repoStillInRemote reports whether a local directory found while pruning belongs to one of repos,
+// using the index when it knows the directory and the directory name otherwise
+func repoStillInRemote(index *repoIndex, relPath string, repos []scm.Repo) bool {
+	if found, known := index.inRemote(relPath, repos); known {
+		return found
+	}
+	return sliceContainsNamedRepo(repos, relPath)
+}
+
+// repoRelocation is a local clone of a repo that was renamed or moved on the scm since it was cloned
+type repoRelocation struct {
+	// Repo is the position of the repo in the list relocations were looked up for
+	Repo int
+	// From is the directory the repo is cloned in, To is the directory it would be cloned to now
+	From string
+	To   string
+}
+
+// findRelocations looks up the repos that aren't cloned at their host path yet but whose ID is
+// recorded in the index under another directory. hostPaths are the directories repos would be
+// cloned to. Directories another repo would be cloned to are never moved away.
+func (index *repoIndex) findRelocations(repos []scm.Repo, hostPaths []string) []repoRelocation {
+	if index == nil {
+		return nil
+	}
+
+	claimed := make(map[string]bool, len(hostPaths))
+	for _, hostPath := range hostPaths {
+		if rel, err := index.relPath(hostPath); err == nil {
+			claimed[rel] = true
+		}
+	}
+
+	// an identity recorded under more than one directory is ambiguous so it's never followed
+	byIdentity := make(map[string]*repoIndexEntry)
+	for _, entry := range index.list() {
+		id := entry.identity()
+		if id == "" {
+			continue
+		}
+		if _, seen := byIdentity[id]; seen {
+			byIdentity[id] = nil
+			continue
+		}
+		byIdentity[id] = &entry
+	}
+
+	var relocations []repoRelocation
+	for i, repo := range repos {
+		entry := byIdentity[newRepoIndexEntry(repo, "").identity()]
+		if entry == nil || claimed[entry.Path] {
+			continue
+		}
+
+		if _, err := os.Stat(hostPaths[i]); err == nil {
+			continue
+		}
+		from := filepath.Join(index.outputDir, filepath.FromSlash(entry.Path))
+		if _, err := os.Stat(from); err != nil {
+			continue
+		}
+
+		relocations = append(relocations, repoRelocation{Repo: i, From: from, To: hostPaths[i]})
+		// the same repo can't be listed twice but make sure a directory is only ever moved once
+		claimed[entry.Path] = true
+	}
+
+	return relocations
+}
+
+// move renames a local clone on disk and in the index, removing directories it leaves empty
+func (index *repoIndex) move(from, to string) error {
+	fromRel, err := index.relPath(from)
+	if err != nil {
+		return err
+	}
+	toRel, err := index.relPath(to)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(to), 0o755); err != nil {
+		return err
+	}
+	if err := os.Rename(from, to); err != nil {
+		return err
+	}
+
+	// group directories of repos moved between groups are left behind empty, os.Remove only removes empty ones
+	for dir := filepath.Dir(from); dir != index.outputDir && strings.HasPrefix(dir, index.outputDir); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			break
+		}
+	}
+
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+
+	if entry, ok := index.entries[fromRel]; ok {
+		delete(index.entries, fromRel)
+		entry.Path = toRel
+		index.entries[toRel] = entry
+		index.dirty = true
+	}
+
+	return nil
+}
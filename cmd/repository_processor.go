@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -10,6 +11,7 @@ import (
 	"time"
 
 	"github.com/gabrie30/ghorg/colorlog"
+	"github.com/gabrie30/ghorg/configs"
 	"github.com/gabrie30/ghorg/git"
 	"github.com/gabrie30/ghorg/scm"
 )
@@ -30,10 +32,30 @@ func applyCloneDelay(repoURL string) {
 // RepositoryProcessor handles the processing of individual repositories
 type RepositoryProcessor struct {
 	git            git.Gitter
+	outputDir      string
 	stats          *CloneStats
 	mutex          *sync.RWMutex
 	untouchedRepos []string
 	protectedRepos []string
+	// reviewLister lists the pull or merge requests recorded with --fetch-review-refs, nil when not enabled
+	reviewLister scm.ReviewRequestLister
+	// metadataExporter exports the scm metadata of repos with --backup-metadata, nil when not enabled
+	metadataExporter scm.MetadataExporter
+	// index records every repo processed into outputDir, nil when the existing index couldn't be read
+	index *repoIndex
+	// target and cloneType are the org or user the repos are cloned from and how, they are recorded in the index.
+	// They're captured when the processor is created since the globals they come from change between targets.
+	target    string
+	cloneType string
+	// worktreeBranches are the GHORG_WORKTREE_BRANCHES patterns of the branches checked out into worktrees
+	worktreeBranches []string
+	// sparsePaths are the sparse paths of each repo, nil when sparse checkouts are not used
+	sparsePaths *repoSparsePaths
+	// referenceStore is the directory of the bare repos new clones borrow objects from, empty when
+	// --reference-store is not used
+	referenceStore string
+	// forkSources finds the upstream of forks the scm didn't report one for, nil when the scm always does
+	forkSources scm.ForkSourceResolver
 }
 
 // CloneStats tracks statistics during clone operations
@@ -44,18 +66,58 @@ type CloneStats struct {
 	NewCommits           int
 	UntouchedPrunes      int
 	ProtectedCount       int
+	SkippedWikiCount     int
 	TotalDurationSeconds int
 	CloneInfos           []string
 	CloneErrors          []string
 }
 
-// NewRepositoryProcessor creates a new repository processor
+// NewRepositoryProcessor creates a new repository processor that clones into the current outputDirAbsolutePath
 func NewRepositoryProcessor(git git.Gitter) *RepositoryProcessor {
-	return &RepositoryProcessor{
-		git:   git,
-		stats: &CloneStats{},
-		mutex: &sync.RWMutex{},
+	rp := &RepositoryProcessor{
+		git:       git,
+		outputDir: outputDirAbsolutePath,
+		target:    targetCloneSource,
+		cloneType: os.Getenv("GHORG_CLONE_TYPE"),
+		stats:     &CloneStats{},
+		mutex:     &sync.RWMutex{},
 	}
+
+	index, err := loadRepoIndex(rp.outputDir)
+	if err != nil {
+		colorlog.PrintError(fmt.Sprintf("Could not read the repo index, it won't be updated: %v", err))
+	}
+	rp.index = index
+
+	// the patterns are validated before cloning starts
+	rp.worktreeBranches, _ = parseWorktreeBranches(os.Getenv("GHORG_WORKTREE_BRANCHES"))
+	rp.sparsePaths, _ = loadRepoSparsePaths()
+	if os.Getenv("GHORG_REFERENCE_STORE") == "true" {
+		rp.referenceStore = configs.GhorgReferenceStoreLocation()
+	}
+
+	if os.Getenv("GHORG_FETCH_REVIEW_REFS") == "true" || os.Getenv("GHORG_BACKUP_METADATA") == "true" || rp.referenceStore != "" {
+		client, err := scm.GetClient(strings.ToLower(os.Getenv("GHORG_SCM_TYPE")))
+		if err == nil {
+			if os.Getenv("GHORG_FETCH_REVIEW_REFS") == "true" {
+				rp.reviewLister, _ = client.(scm.ReviewRequestLister)
+			}
+			if os.Getenv("GHORG_BACKUP_METADATA") == "true" {
+				rp.metadataExporter, _ = client.(scm.MetadataExporter)
+			}
+			if rp.referenceStore != "" {
+				rp.forkSources, _ = client.(scm.ForkSourceResolver)
+			}
+		}
+	}
+
+	return rp
+}
+
+// shouldFetchAll reports whether existing repos are fetched with git fetch --all. Review
+// refs are only updated by a fetch so --fetch-review-refs implies it.
+func shouldFetchAll() bool {
+	return os.Getenv("GHORG_FETCH_ALL") == "true" || os.Getenv("GHORG_FETCH_REVIEW_REFS") == "true"
 }
 
 // ProcessRepository handles the cloning or updating of a single repository
@@ -65,6 +127,7 @@ func (rp *RepositoryProcessor) ProcessRepository(repo *scm.Repo, repoNameWithCol
 
 	// Set the final host path
 	repo.HostPath = rp.buildHostPath(*repo, finalRepoSlug)
+	repo.SparsePaths = rp.sparsePaths.forRepo(*repo)
 
 	// Handle prune untouched logic
 	if rp.shouldPruneUntouched(repo) {
@@ -96,6 +159,20 @@ func (rp *RepositoryProcessor) ProcessRepository(repo *scm.Repo, repoNameWithCol
 		}
 	}
 
+	if len(rp.worktreeBranches) > 0 && os.Getenv("GHORG_BACKUP") != "true" {
+		rp.syncWorktrees(*repo)
+	}
+
+	if os.Getenv("GHORG_FETCH_REVIEW_REFS") == "true" {
+		rp.recordReviewRequests(*repo)
+	}
+
+	if os.Getenv("GHORG_BACKUP_METADATA") == "true" {
+		rp.backupMetadata(*repo)
+	}
+
+	rp.recordInIndex(*repo)
+
 	// Print unified success message (matching original behavior)
 	if repoWillBePulled && repo.Commits.CountDiff > 0 {
 		colorlog.PrintSuccess(fmt.Sprintf("Success %s %s, branch: %s, new commits: %d", action, repo.URL, repo.CloneBranch, repo.Commits.CountDiff))
@@ -104,9 +181,183 @@ func (rp *RepositoryProcessor) ProcessRepository(repo *scm.Repo, repoNameWithCol
 	}
 }
 
+// reviewRequestsFileName is the sidecar file the pull or merge requests of a repo are recorded in
+const reviewRequestsFileName = "ghorg-review-requests.json"
+
+// gitDirPath returns the git directory of repo, sidecar files are kept there so
+// they never show up as local changes or get mistaken for repos when pruning
+func gitDirPath(repo scm.Repo) string {
+	if repo.VCS == scm.VCSHg {
+		// hg backups are clones without a working copy so they still have a .hg directory
+		return filepath.Join(repo.HostPath, ".hg")
+	}
+	if os.Getenv("GHORG_BACKUP") == "true" {
+		return repo.HostPath
+	}
+	return filepath.Join(repo.HostPath, ".git")
+}
+
+// reviewRequestsPath returns where the review requests of repo are recorded
+func reviewRequestsPath(repo scm.Repo) string {
+	return filepath.Join(gitDirPath(repo), reviewRequestsFileName)
+}
+
+// recordReviewRequests writes the pull or merge requests of repo to its sidecar file
+func (rp *RepositoryProcessor) recordReviewRequests(repo scm.Repo) {
+	if rp.reviewLister == nil || repo.IsWiki || repo.IsGitLabSnippet || repo.IsGitHubGist || repo.IsSnippet {
+		return
+	}
+
+	includeClosed := os.Getenv("GHORG_REVIEW_REFS_INCLUDE_CLOSED") == "true"
+	reviewRequests, err := rp.reviewLister.GetReviewRequests(repo, includeClosed)
+	if err != nil {
+		rp.addInfo(fmt.Sprintf("Could not list review requests for: %s Error: %v", repo.URL, err))
+		return
+	}
+
+	// the origin refspecs fetch the refs of every request, so without closed ones each open request is fetched on its own
+	if fetcher, ok := rp.git.(git.ReviewRefFetcher); ok && !includeClosed {
+		numbers := make([]int64, 0, len(reviewRequests))
+		for _, reviewRequest := range reviewRequests {
+			numbers = append(numbers, reviewRequest.Number)
+		}
+
+		// Temporarily restore credentials to fetch the review refs of private repos
+		if err := rp.git.SetOriginWithCredentials(repo); err != nil {
+			rp.addError(fmt.Sprintf("Problem trying to set remote with credentials: %s Error: %v", repo.URL, err))
+			return
+		}
+
+		fetchErr := fetcher.FetchReviewRequestRefs(repo, numbers)
+
+		// Always strip credentials again for security, even if fetch failed
+		if err := rp.git.SetOrigin(repo); err != nil {
+			rp.addError(fmt.Sprintf("Problem trying to reset remote after fetching review refs: %s Error: %v", repo.URL, err))
+			return
+		}
+
+		if fetchErr != nil {
+			rp.addInfo(fmt.Sprintf("Could not fetch review refs for: %s Error: %v", repo.URL, fetchErr))
+		}
+	}
+
+	data, err := json.MarshalIndent(reviewRequests, "", "  ")
+	if err != nil {
+		rp.addInfo(fmt.Sprintf("Could not encode review requests for: %s Error: %v", repo.URL, err))
+		return
+	}
+
+	if err := os.WriteFile(reviewRequestsPath(repo), data, 0o644); err != nil {
+		rp.addInfo(fmt.Sprintf("Could not record review requests for: %s Error: %v", repo.URL, err))
+	}
+}
+
+// metadataDirSuffix is added to the directory of a clone to get the directory its scm metadata is exported into,
+// it's kept outside the clone so it's neither git history nor part of the working copy
+const metadataDirSuffix = ".metadata"
+
+// metadataStateFileName records when the metadata of a repo was last exported
+const metadataStateFileName = "ghorg-metadata-state.json"
+
+// metadataSinceOverlap is subtracted from the last export time to absorb clock skew
+// between this machine and the scm, exporting an item twice just overwrites it
+const metadataSinceOverlap = 10 * time.Minute
+
+type metadataState struct {
+	LastExportedAt time.Time `json:"last_exported_at"`
+}
+
+// metadataDirPath returns the directory the scm metadata of the clone at hostPath is exported into
+func metadataDirPath(hostPath string) string {
+	return hostPath + metadataDirSuffix
+}
+
+// isMetadataDir reports whether path is the metadata export of a clone
+func isMetadataDir(path string) bool {
+	return strings.HasSuffix(path, metadataDirSuffix) && isGitRepository(strings.TrimSuffix(path, metadataDirSuffix))
+}
+
+// backupMetadata exports the scm metadata of repo, only fetching issues and pull or
+// merge requests that were updated since the last successful export
+func (rp *RepositoryProcessor) backupMetadata(repo scm.Repo) {
+	if rp.metadataExporter == nil || repo.IsWiki || repo.IsGitLabSnippet || repo.IsGitHubGist || repo.IsSnippet {
+		return
+	}
+
+	dir := metadataDirPath(repo.HostPath)
+	statePath := filepath.Join(dir, metadataStateFileName)
+
+	var since time.Time
+	if data, err := os.ReadFile(statePath); err == nil {
+		var state metadataState
+		if err := json.Unmarshal(data, &state); err == nil && !state.LastExportedAt.IsZero() {
+			since = state.LastExportedAt.Add(-metadataSinceOverlap)
+		}
+	}
+
+	startedAt := time.Now().UTC()
+	if err := rp.metadataExporter.ExportMetadata(repo, dir, since); err != nil {
+		rp.addError(fmt.Sprintf("Problem backing up metadata for: %s Error: %v", repo.URL, err))
+		return
+	}
+
+	data, err := json.MarshalIndent(metadataState{LastExportedAt: startedAt}, "", "  ")
+	if err == nil {
+		err = os.WriteFile(statePath, data, 0o644)
+	}
+	if err != nil {
+		rp.addInfo(fmt.Sprintf("Could not record metadata export time for: %s, the next export will be a full export Error: %v", repo.URL, err))
+	}
+}
+
+// plannedHostPaths returns the directory each of repos will be cloned to, resolving name collisions
+// on a copy so the collisions of the actual clone are unaffected
+func (rp *RepositoryProcessor) plannedHostPaths(repos []scm.Repo, repoPathAsSlug bool) []string {
+	repoNameWithCollisions, hasCollisions := hasRepoNameCollisions(repos)
+
+	hostPaths := make([]string, len(repos))
+	for i, repo := range repos {
+		repoSlug := initialRepoSlug(repo)
+		if repo.Path != "" && repoPathAsSlug {
+			repoSlug = repo.Path
+		}
+		hostPaths[i] = rp.buildHostPath(repo, rp.handleNameCollisions(repo, repoNameWithCollisions, hasCollisions, repoSlug, i))
+	}
+
+	return hostPaths
+}
+
+// RelocateRenamedRepos moves the local clones of repos that were renamed or moved on the scm to the
+// directory they would be cloned to now, so they are pulled, which updates their remote, instead of
+// being cloned again while --prune deletes the old directory along with its local branches
+func (rp *RepositoryProcessor) RelocateRenamedRepos(repos []scm.Repo, repoPathAsSlug bool) {
+	for _, relocation := range rp.index.findRelocations(repos, rp.plannedHostPaths(repos, repoPathAsSlug)) {
+		repo := repos[relocation.Repo]
+		if err := rp.index.move(relocation.From, relocation.To); err != nil {
+			rp.addInfo(fmt.Sprintf("Could not move %s to %s after it was renamed or moved on the scm, it will be cloned again: %s Error: %v", relocation.From, relocation.To, repo.URL, err))
+			continue
+		}
+		colorlog.PrintInfo(fmt.Sprintf("Moved %s to %s, it was renamed or moved on the scm", relocation.From, relocation.To))
+
+		// branch worktrees move along with the clone, they are reconnected to it when they are synced
+		if _, err := os.Stat(worktreesDirPath(relocation.From)); err == nil {
+			if err := os.Rename(worktreesDirPath(relocation.From), worktreesDirPath(relocation.To)); err != nil {
+				rp.addInfo(fmt.Sprintf("Could not move the worktrees of %s to %s: %s Error: %v", relocation.From, relocation.To, repo.URL, err))
+			}
+		}
+		if _, err := os.Stat(metadataDirPath(relocation.From)); err == nil {
+			if err := os.Rename(metadataDirPath(relocation.From), metadataDirPath(relocation.To)); err != nil {
+				rp.addInfo(fmt.Sprintf("Could not move the metadata export of %s to %s: %s Error: %v", relocation.From, relocation.To, repo.URL, err))
+			}
+		}
+	}
+	rp.SaveIndex()
+}
+
 // handleNameCollisions manages repository name collisions
 func (rp *RepositoryProcessor) handleNameCollisions(repo scm.Repo, repoNameWithCollisions map[string]bool, hasCollisions bool, repoSlug string, index int) string {
-	if !hasCollisions {
+	// snippet folder names are already unique within SnippetsDirName
+	if !hasCollisions || repo.IsSnippet {
 		return rp.addSuffixesIfNeeded(repo, repoSlug)
 	}
 
@@ -142,8 +393,13 @@ func (rp *RepositoryProcessor) handleNameCollisions(repo scm.Repo, repoNameWithC
 	return rp.addSuffixesIfNeeded(repo, repoSlug)
 }
 
-// addSuffixesIfNeeded adds appropriate suffixes for wikis and snippets
+// addSuffixesIfNeeded adds appropriate suffixes for wikis, snippets and mercurial repos
 func (rp *RepositoryProcessor) addSuffixesIfNeeded(repo scm.Repo, repoSlug string) string {
+	// sourcehut lets a git and a mercurial repo share a name
+	if repo.VCS == scm.VCSHg && !strings.HasSuffix(repoSlug, ".hg") {
+		repoSlug = repoSlug + ".hg"
+	}
+
 	if repo.IsWiki && !strings.HasSuffix(repoSlug, ".wiki") {
 		repoSlug = repoSlug + ".wiki"
 	}
@@ -158,14 +414,18 @@ func (rp *RepositoryProcessor) addSuffixesIfNeeded(repo scm.Repo, repoSlug strin
 // buildHostPath constructs the final host path for the repository
 func (rp *RepositoryProcessor) buildHostPath(repo scm.Repo, repoSlug string) string {
 	if repo.IsGitLabRootLevelSnippet {
-		return filepath.Join(outputDirAbsolutePath, "_ghorg_root_level_snippets", repo.GitLabSnippetInfo.Title+"-"+repo.GitLabSnippetInfo.ID)
+		return filepath.Join(rp.outputDir, "_ghorg_root_level_snippets", repo.GitLabSnippetInfo.Title+"-"+repo.GitLabSnippetInfo.ID)
 	}
 
 	if repo.IsGitLabSnippet {
-		return filepath.Join(outputDirAbsolutePath, repoSlug, repo.GitLabSnippetInfo.Title+"-"+repo.GitLabSnippetInfo.ID)
+		return filepath.Join(rp.outputDir, repoSlug, repo.GitLabSnippetInfo.Title+"-"+repo.GitLabSnippetInfo.ID)
+	}
+
+	if repo.IsSnippet {
+		return filepath.Join(rp.outputDir, filepath.FromSlash(repo.Path))
 	}
 
-	return filepath.Join(outputDirAbsolutePath, repoSlug)
+	return filepath.Join(rp.outputDir, repoSlug)
 }
 
 // shouldPruneUntouched determines if a repository should be pruned as untouched
@@ -340,6 +600,21 @@ func (rp *RepositoryProcessor) handleExistingRepository(repo *scm.Repo, action *
 func (rp *RepositoryProcessor) handleNewRepository(repo *scm.Repo, action *string) bool {
 	*action = "cloning"
 
+	// Providers only report whether wikis are enabled, so check there is something to clone first
+	if repo.IsWiki {
+		hasContent, err := rp.git.HasRemoteHeads(*repo)
+		if err != nil {
+			rp.addError(fmt.Sprintf("Could not check the wiki for content: %s Error: %v", repo.URL, err))
+			return false
+		}
+		if !hasContent {
+			rp.addSkippedWiki(*repo)
+			return false
+		}
+	}
+
+	rp.useReferenceStore(repo)
+
 	err := rp.git.Clone(*repo)
 
 	// Handle wiki clone attempts that might fail
@@ -449,7 +724,7 @@ func (rp *RepositoryProcessor) handleBackupMode(repo *scm.Repo) bool {
 // handleNoCleanMode processes repositories in no-clean mode
 func (rp *RepositoryProcessor) handleNoCleanMode(repo *scm.Repo) bool {
 	// Fetch all if enabled
-	if os.Getenv("GHORG_FETCH_ALL") == "true" {
+	if shouldFetchAll() {
 		// Temporarily restore credentials for fetch-all to work with private repos
 		err := rp.git.SetOriginWithCredentials(*repo)
 		if err != nil {
@@ -484,7 +759,7 @@ func (rp *RepositoryProcessor) handleNoCleanMode(repo *scm.Repo) bool {
 // handleStandardPull processes repositories in standard pull mode
 func (rp *RepositoryProcessor) handleStandardPull(repo *scm.Repo) bool {
 	// Fetch all if enabled
-	if os.Getenv("GHORG_FETCH_ALL") == "true" {
+	if shouldFetchAll() {
 		// Temporarily restore credentials for fetch-all to work with private repos
 		err := rp.git.SetOriginWithCredentials(*repo)
 		if err != nil {
@@ -532,6 +807,15 @@ func (rp *RepositoryProcessor) handleStandardPull(repo *scm.Repo) bool {
 		}
 	}
 
+	// Apply the sparse paths before cleaning so changes to them take effect, clean, reset and pull keep to them
+	if rp.sparsePaths != nil {
+		err = rp.git.SparseCheckout(*repo)
+		if err != nil {
+			rp.addError(fmt.Sprintf("Problem setting sparse paths: %s Error: %v", repo.URL, err))
+			return false
+		}
+	}
+
 	// Get pre-pull commit count
 	count, err := rp.git.RepoCommitCount(*repo)
 	if err != nil {
@@ -576,6 +860,28 @@ func (rp *RepositoryProcessor) handleStandardPull(repo *scm.Repo) bool {
 	return true
 }
 
+// recordInIndex adds a repo that was cloned or updated to the index of the output dir
+func (rp *RepositoryProcessor) recordInIndex(repo scm.Repo) {
+	if rp.index == nil {
+		return
+	}
+	// the index is bookkeeping, failing to update it doesn't fail the clone
+	if err := rp.index.record(repo, rp.target, rp.cloneType); err != nil {
+		colorlog.PrintSubtleInfo(fmt.Sprintf("Could not record %s in %s: %v", repo.URL, repoIndexFileName, err))
+	}
+}
+
+// SaveIndex writes the index of the output dir, it's called once all repos have been processed
+// and after pruning since records are only written periodically while cloning
+func (rp *RepositoryProcessor) SaveIndex() {
+	if rp.index == nil {
+		return
+	}
+	if err := rp.index.save(); err != nil {
+		colorlog.PrintError(fmt.Sprintf("Could not write %s: %v", repoIndexPath(rp.outputDir), err))
+	}
+}
+
 // addError adds an error to the stats in a thread-safe manner
 func (rp *RepositoryProcessor) addError(msg string) {
 	rp.mutex.Lock()
@@ -598,6 +904,14 @@ func (rp *RepositoryProcessor) addProtected(repoPath string) {
 	rp.mutex.Unlock()
 }
 
+// addSkippedWiki reports a wiki that is empty or does not exist, these are expected so they are not infos or errors
+func (rp *RepositoryProcessor) addSkippedWiki(repo scm.Repo) {
+	colorlog.PrintSubtleInfo(fmt.Sprintf("Skipping %s: wiki is empty or does not exist", repo.URL))
+	rp.mutex.Lock()
+	rp.stats.SkippedWikiCount++
+	rp.mutex.Unlock()
+}
+
 // GetStats returns a copy of the current statistics
 func (rp *RepositoryProcessor) GetStats() CloneStats {
 	rp.mutex.RLock()
@@ -610,6 +924,7 @@ func (rp *RepositoryProcessor) GetStats() CloneStats {
 		NewCommits:           rp.stats.NewCommits,
 		UntouchedPrunes:      rp.stats.UntouchedPrunes,
 		ProtectedCount:       rp.stats.ProtectedCount,
+		SkippedWikiCount:     rp.stats.SkippedWikiCount,
 		TotalDurationSeconds: rp.stats.TotalDurationSeconds,
 		CloneInfos:           append([]string(nil), rp.stats.CloneInfos...),
 		CloneErrors:          append([]string(nil), rp.stats.CloneErrors...),
@@ -1,10 +1,16 @@
 package cmd
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gabrie30/ghorg/scm"
 )
@@ -15,12 +21,14 @@ type ExtendedMockGitClient struct {
 	shouldFailClone             bool
 	shouldFailCheckout          bool
 	shouldFailSetOrigin         bool
+	shouldFailRemoteHeads       bool
 	shouldReturnEmptyRepo       bool
 	shouldReturnDirtyStatus     bool
 	shouldReturnUnpushedCommits bool
 	preCommitCount              int
 	postCommitCount             int
 	currentBranch               string
+	remoteURL                   string
 	checkoutBranchCalled        bool
 	checkoutBranchArg           string
 }
@@ -41,6 +49,13 @@ func (g *ExtendedMockGitClient) Clone(repo scm.Repo) error {
 	return g.MockGitClient.Clone(repo)
 }
 
+func (g *ExtendedMockGitClient) HasRemoteHeads(repo scm.Repo) (bool, error) {
+	if g.shouldFailRemoteHeads {
+		return false, errors.New("mock ls-remote error")
+	}
+	return g.MockGitClient.HasRemoteHeads(repo)
+}
+
 func (g *ExtendedMockGitClient) Checkout(repo scm.Repo) error {
 	if g.shouldFailCheckout {
 		return errors.New("mock checkout error")
@@ -84,6 +99,13 @@ func (g *ExtendedMockGitClient) GetCurrentBranch(repo scm.Repo) (string, error)
 	return g.currentBranch, nil
 }
 
+func (g *ExtendedMockGitClient) GetRemoteURL(repo scm.Repo) (string, error) {
+	if g.remoteURL != "" {
+		return g.remoteURL, nil
+	}
+	return repo.URL, nil
+}
+
 func (g *ExtendedMockGitClient) CheckoutBranch(repo scm.Repo, branch string) error {
 	g.checkoutBranchCalled = true
 	g.checkoutBranchArg = branch
@@ -276,6 +298,70 @@ func TestRepositoryProcessor_ProcessRepository_WikiHandling(t *testing.T) {
 	}
 }
 
+func TestRepositoryProcessor_ProcessRepository_SkipsEmptyWiki(t *testing.T) {
+	defer UnsetEnv("GHORG_")()
+
+	dir := t.TempDir()
+	outputDirAbsolutePath = dir
+
+	mockGit := NewExtendedMockGit()
+	processor := NewRepositoryProcessor(mockGit)
+
+	// MockGitClient reports testRepoEmpty as having no remote heads
+	repo := scm.Repo{
+		Name:        "testRepoEmpty",
+		URL:         "https://github.com/org/testRepoEmpty.wiki.git",
+		CloneBranch: "master",
+		IsWiki:      true,
+	}
+
+	processor.ProcessRepository(&repo, make(map[string]bool), false, "testRepoEmpty.wiki", 0)
+
+	stats := processor.GetStats()
+	if stats.SkippedWikiCount != 1 {
+		t.Errorf("Expected 1 skipped wiki, got %d", stats.SkippedWikiCount)
+	}
+	if stats.CloneCount != 0 {
+		t.Errorf("Expected empty wiki not to be cloned, got %d clones", stats.CloneCount)
+	}
+	if len(stats.CloneInfos) != 0 || len(stats.CloneErrors) != 0 {
+		t.Errorf("Expected skipped wiki not to be reported as info or error, got infos %v errors %v", stats.CloneInfos, stats.CloneErrors)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "testRepoEmpty.wiki")); !os.IsNotExist(err) {
+		t.Errorf("Expected no directory for skipped wiki, stat error: %v", err)
+	}
+}
+
+func TestRepositoryProcessor_ProcessRepository_WikiRemoteHeadsError(t *testing.T) {
+	defer UnsetEnv("GHORG_")()
+	outputDirAbsolutePath = t.TempDir()
+
+	mockGit := NewExtendedMockGit()
+	mockGit.shouldFailRemoteHeads = true
+	processor := NewRepositoryProcessor(mockGit)
+
+	repo := scm.Repo{
+		Name:        "test-repo",
+		URL:         "https://github.com/org/test-repo.wiki.git",
+		CloneBranch: "master",
+		IsWiki:      true,
+	}
+
+	processor.ProcessRepository(&repo, make(map[string]bool), false, "test-repo.wiki", 0)
+
+	// auth or network failures must not be mistaken for an empty wiki
+	stats := processor.GetStats()
+	if stats.SkippedWikiCount != 0 {
+		t.Errorf("Expected no skipped wikis, got %d", stats.SkippedWikiCount)
+	}
+	if len(stats.CloneErrors) != 1 || !strings.Contains(stats.CloneErrors[0], "mock ls-remote error") {
+		t.Errorf("Expected the remote heads error to be reported, got %v", stats.CloneErrors)
+	}
+	if stats.CloneCount != 0 {
+		t.Errorf("Expected the wiki not to be cloned, got %d clones", stats.CloneCount)
+	}
+}
+
 func TestRepositoryProcessor_ProcessRepository_BackupMode(t *testing.T) {
 	defer UnsetEnv("GHORG_")()
 	_ = os.Setenv("GHORG_BACKUP", "true")
@@ -601,6 +687,65 @@ func TestRepositoryProcessor_ProcessRepository_GitLabSnippets(t *testing.T) {
 	}
 }
 
+func TestRepositoryProcessor_ProcessRepository_Snippets(t *testing.T) {
+	defer UnsetEnv("GHORG_")()
+
+	dir, err := os.MkdirTemp("", "ghorg_test_snippets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	outputDirAbsolutePath = dir
+
+	mockGit := NewExtendedMockGit()
+	processor := NewRepositoryProcessor(mockGit)
+
+	repo := scm.Repo{
+		ID:          "a1",
+		Name:        "notes-a1",
+		Path:        "_ghorg_snippets/notes-a1",
+		URL:         "https://bitbucket.org/snippets/workspace/a1/snippet.git",
+		CloneBranch: "master",
+		IsSnippet:   true,
+	}
+
+	// snippet folder names are unique already, collisions in the org must not rename them
+	repoNameWithCollisions := map[string]bool{"notes-a1": true}
+	processor.ProcessRepository(&repo, repoNameWithCollisions, true, "notes-a1", 0)
+
+	expectedPath := filepath.Join(outputDirAbsolutePath, scm.SnippetsDirName, "notes-a1")
+	if repo.HostPath != expectedPath {
+		t.Errorf("Expected host path %s, got %s", expectedPath, repo.HostPath)
+	}
+
+	if stats := processor.GetStats(); stats.CloneCount != 1 {
+		t.Errorf("Expected clone count to be 1, got %d", stats.CloneCount)
+	}
+}
+
+func TestRepositoryProcessor_ProcessRepository_MercurialNameShared(t *testing.T) {
+	defer UnsetEnv("GHORG_")()
+
+	outputDirAbsolutePath = t.TempDir()
+
+	mockGit := NewExtendedMockGit()
+	processor := NewRepositoryProcessor(mockGit)
+
+	gitRepo := scm.Repo{Name: "legacy", Path: "user/legacy", URL: "https://git.sr.ht/~user/legacy", CloneBranch: "master", VCS: scm.VCSGit}
+	hgRepo := scm.Repo{Name: "legacy", Path: "user/legacy.hg", URL: "https://hg.sr.ht/~user/legacy", CloneBranch: "default", VCS: scm.VCSHg}
+
+	processor.ProcessRepository(&gitRepo, make(map[string]bool), false, "legacy", 0)
+	processor.ProcessRepository(&hgRepo, make(map[string]bool), false, "legacy", 1)
+
+	if want := filepath.Join(outputDirAbsolutePath, "legacy"); gitRepo.HostPath != want {
+		t.Errorf("Expected git repo host path %s, got %s", want, gitRepo.HostPath)
+	}
+	if want := filepath.Join(outputDirAbsolutePath, "legacy.hg"); hgRepo.HostPath != want {
+		t.Errorf("Expected hg repo host path %s, got %s", want, hgRepo.HostPath)
+	}
+}
+
 func TestRepositoryProcessor_GetStats(t *testing.T) {
 	mockGit := NewExtendedMockGit()
 	processor := NewRepositoryProcessor(mockGit)
@@ -1282,3 +1427,161 @@ func TestRepositoryProcessor_ProtectLocal_NoRestoreWhenDisabled(t *testing.T) {
 		t.Error("Expected CheckoutBranch NOT to be called when protect-local is disabled")
 	}
 }
+
+// mockReviewRequestLister returns canned review requests and records whether closed ones were asked for
+type mockReviewRequestLister struct {
+	includeClosed bool
+}
+
+func (m *mockReviewRequestLister) GetReviewRequests(repo scm.Repo, includeClosed bool) ([]scm.ReviewRequest, error) {
+	m.includeClosed = includeClosed
+	return []scm.ReviewRequest{{Number: 7, Title: "Add feature", Author: "someone", State: "open"}}, nil
+}
+
+func TestRepositoryProcessor_ProcessRepository_RecordsReviewRequests(t *testing.T) {
+	defer UnsetEnv("GHORG_")()
+	dir := t.TempDir()
+
+	_ = os.Setenv("GHORG_FETCH_REVIEW_REFS", "true")
+	_ = os.Setenv("GHORG_REVIEW_REFS_INCLUDE_CLOSED", "true")
+	outputDirAbsolutePath = dir
+
+	lister := &mockReviewRequestLister{}
+	processor := NewRepositoryProcessor(HostPathMockGit{})
+	processor.reviewLister = lister
+
+	repo := scm.Repo{
+		Name:        "test-repo",
+		URL:         "https://github.com/org/test-repo",
+		CloneBranch: "main",
+	}
+	processor.ProcessRepository(&repo, map[string]bool{}, false, "test-repo", 0)
+
+	data, err := os.ReadFile(filepath.Join(dir, "test-repo", ".git", reviewRequestsFileName))
+	if err != nil {
+		t.Fatalf("Expected review requests to be recorded: %v", err)
+	}
+
+	var got []scm.ReviewRequest
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Number != 7 || got[0].Author != "someone" {
+		t.Errorf("Unexpected review requests recorded: %+v", got)
+	}
+
+	if !lister.includeClosed {
+		t.Errorf("Expected closed review requests to be requested")
+	}
+}
+
+// reviewRefMockGit records the review requests whose refs were fetched one by one
+// and whether origin carried credentials while they were
+type reviewRefMockGit struct {
+	HostPathMockGit
+	fetched         *[]int64
+	withCredentials *bool
+	fetchedWithout  *bool
+}
+
+func (g reviewRefMockGit) SetOriginWithCredentials(repo scm.Repo) error {
+	*g.withCredentials = true
+	return nil
+}
+
+func (g reviewRefMockGit) SetOrigin(repo scm.Repo) error {
+	*g.withCredentials = false
+	return nil
+}
+
+func (g reviewRefMockGit) FetchReviewRequestRefs(repo scm.Repo, numbers []int64) error {
+	if !*g.withCredentials {
+		*g.fetchedWithout = true
+	}
+	*g.fetched = append(*g.fetched, numbers...)
+	return nil
+}
+
+func TestRepositoryProcessor_ProcessRepository_FetchesOpenReviewRefs(t *testing.T) {
+	for _, includeClosed := range []bool{false, true} {
+		t.Run(fmt.Sprintf("include closed %v", includeClosed), func(t *testing.T) {
+			defer UnsetEnv("GHORG_")()
+			outputDirAbsolutePath = t.TempDir()
+
+			_ = os.Setenv("GHORG_FETCH_REVIEW_REFS", "true")
+			_ = os.Setenv("GHORG_REVIEW_REFS_INCLUDE_CLOSED", strconv.FormatBool(includeClosed))
+
+			fetched := []int64{}
+			withCredentials, fetchedWithout := false, false
+			processor := NewRepositoryProcessor(reviewRefMockGit{fetched: &fetched, withCredentials: &withCredentials, fetchedWithout: &fetchedWithout})
+			processor.reviewLister = &mockReviewRequestLister{}
+
+			repo := scm.Repo{Name: "test-repo", URL: "https://github.com/org/test-repo", CloneBranch: "main"}
+			processor.ProcessRepository(&repo, map[string]bool{}, false, "test-repo", 0)
+
+			// the refspecs of every request are only used when closed requests are included
+			want := []int64{7}
+			if includeClosed {
+				want = []int64{}
+			}
+			if !reflect.DeepEqual(fetched, want) {
+				t.Errorf("Expected the refs of requests %v to be fetched, got %v", want, fetched)
+			}
+			if fetchedWithout {
+				t.Error("Expected origin to carry credentials while the review refs were fetched")
+			}
+			if withCredentials {
+				t.Error("Expected the credentials to be stripped from origin after fetching the review refs")
+			}
+		})
+	}
+}
+
+// mockMetadataExporter records the since each export was asked for
+type mockMetadataExporter struct {
+	since []time.Time
+}
+
+func (m *mockMetadataExporter) ExportMetadata(repo scm.Repo, dir string, since time.Time) error {
+	m.since = append(m.since, since)
+	return os.MkdirAll(dir, 0o755)
+}
+
+func TestRepositoryProcessor_BackupMetadata_Incremental(t *testing.T) {
+	defer UnsetEnv("GHORG_")()
+	dir := t.TempDir()
+
+	_ = os.Setenv("GHORG_BACKUP_METADATA", "true")
+	outputDirAbsolutePath = dir
+
+	exporter := &mockMetadataExporter{}
+	processor := NewRepositoryProcessor(HostPathMockGit{})
+	processor.metadataExporter = exporter
+
+	repo := scm.Repo{Name: "test-repo", URL: "https://github.com/org/test-repo", CloneBranch: "main"}
+	repo.HostPath = filepath.Join(dir, "test-repo")
+	_ = os.MkdirAll(filepath.Join(repo.HostPath, ".git"), 0o755)
+
+	before := time.Now().UTC()
+	processor.backupMetadata(repo)
+	processor.backupMetadata(repo)
+
+	if len(exporter.since) != 2 {
+		t.Fatalf("Expected 2 exports, got %d", len(exporter.since))
+	}
+	if !exporter.since[0].IsZero() {
+		t.Errorf("Expected the first export to be a full export, got since %v", exporter.since[0])
+	}
+	if want := before.Add(-metadataSinceOverlap); exporter.since[1].Before(want) {
+		t.Errorf("Expected the second export to only ask for updates since %v, got %v", want, exporter.since[1])
+	}
+
+	// the export is kept next to the clone so it's not mistaken for git history
+	if _, err := os.Stat(filepath.Join(repo.HostPath+".metadata", metadataStateFileName)); err != nil {
+		t.Errorf("Expected the metadata export state to be recorded: %v", err)
+	}
+
+	if stats := processor.GetStats(); len(stats.CloneErrors) != 0 {
+		t.Errorf("Expected no errors, got %v", stats.CloneErrors)
+	}
+}
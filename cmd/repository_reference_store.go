@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/gabrie30/ghorg/git"
+	"github.com/gabrie30/ghorg/scm"
+)
+
+// referenceStoreLocks serializes the fetches into each reference repo, forks of the same upstream share one
+// and can be cloned at the same time
+var referenceStoreLocks sync.Map
+
+// referenceRepoPath returns the bare repo in store that repo borrows objects from, <store>/<host>/<path>.git.
+// Forks use the one of their upstream when the scm reports it, so a fork network shares a single reference.
+func referenceRepoPath(store string, repo scm.Repo) string {
+	path := repoPatternPath(repo.URL)
+	if upstream := filepath.Clean(strings.Trim(repo.UpstreamPath, "/")); repo.UpstreamPath != "" && !strings.HasPrefix(upstream, "..") {
+		path = upstream
+	}
+	return filepath.Join(store, repoHost(repo.URL), filepath.FromSlash(path)+".git")
+}
+
+// repoHost returns the hostname of a clone url, scp-like ssh urls included
+func repoHost(cloneURL string) string {
+	if u, err := url.Parse(cloneURL); err == nil && u.Host != "" {
+		return u.Hostname()
+	}
+	// git@github.com:org/repo.git
+	host, _, _ := strings.Cut(cloneURL, ":")
+	if _, after, ok := strings.Cut(host, "@"); ok {
+		host = after
+	}
+	return host
+}
+
+// useReferenceStore fetches repo into its reference repo before it's cloned and sets repo.ReferencePath so the
+// clone only downloads the objects the reference doesn't have. Repos are cloned without the store when it
+// can't be updated, wikis, snippets and gists never use it.
+func (rp *RepositoryProcessor) useReferenceStore(repo *scm.Repo) {
+	if rp.referenceStore == "" || repo.IsWiki || repo.IsGitLabSnippet || repo.IsGitHubGist || repo.IsSnippet || repo.VCS == scm.VCSHg {
+		return
+	}
+	store, ok := rp.git.(git.ReferenceStore)
+	if !ok {
+		return
+	}
+
+	// only new clones of forks look up their upstream, listing repos doesn't on every scm
+	if repo.Fork && repo.UpstreamPath == "" && rp.forkSources != nil {
+		if upstream, err := rp.forkSources.GetForkSource(*repo); err == nil {
+			repo.UpstreamPath = upstream
+		}
+	}
+
+	referencePath := referenceRepoPath(rp.referenceStore, *repo)
+	lock, _ := referenceStoreLocks.LoadOrStore(referencePath, &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	defer lock.(*sync.Mutex).Unlock()
+
+	if err := store.UpdateReference(*repo, referencePath, repoPatternPath(repo.URL)); err != nil {
+		rp.addInfo(fmt.Sprintf("Could not update the reference store, cloning without it: %s Error: %v", repo.URL, err))
+		return
+	}
+	repo.ReferencePath = referencePath
+}
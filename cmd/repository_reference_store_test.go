@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gabrie30/ghorg/scm"
+)
+
+// referenceMockGitClient records the fetches into the reference store and the reference each repo was cloned with
+type referenceMockGitClient struct {
+	MockGitClient
+	fetched    *[]string
+	referenced *[]string
+	// failing fails every fetch into the store
+	failing bool
+}
+
+func (g referenceMockGitClient) UpdateReference(repo scm.Repo, referencePath string, namespace string) error {
+	if g.failing {
+		return errors.New("fatal: could not read from remote repository")
+	}
+	*g.fetched = append(*g.fetched, namespace+" -> "+referencePath)
+	return nil
+}
+
+func (g referenceMockGitClient) Clone(repo scm.Repo) error {
+	*g.referenced = append(*g.referenced, repo.ReferencePath)
+	return os.MkdirAll(repo.HostPath, 0755)
+}
+
+func TestReferenceRepoPath(t *testing.T) {
+	store := filepath.Join("cache", "reference-store")
+
+	testCases := []struct {
+		repo scm.Repo
+		want string
+	}{
+		{scm.Repo{URL: "https://github.com/org/api.git"}, filepath.Join(store, "github.com", "org", "api.git")},
+		{scm.Repo{URL: "https://github.com/someone/api.git", UpstreamPath: "org/api"}, filepath.Join(store, "github.com", "org", "api.git")},
+		{scm.Repo{URL: "git@gitlab.example.com:group/subgroup/api.git", UpstreamPath: "/group/api/"}, filepath.Join(store, "gitlab.example.com", "group", "api.git")},
+		{scm.Repo{URL: "ssh://git@gitea.example.com:2222/org/api.git"}, filepath.Join(store, "gitea.example.com", "org", "api.git")},
+		{scm.Repo{URL: "https://github.com/someone/api.git", UpstreamPath: "../../etc"}, filepath.Join(store, "github.com", "someone", "api.git")},
+	}
+	for _, tc := range testCases {
+		if got := referenceRepoPath(store, tc.repo); got != tc.want {
+			t.Errorf("%s: expected %s, got %s", tc.repo.URL, tc.want, got)
+		}
+	}
+}
+
+func TestRepositoryProcessor_ReferenceStore(t *testing.T) {
+	defer UnsetEnv("GHORG_")()
+	dir := t.TempDir()
+	store := filepath.Join(t.TempDir(), "reference-store")
+	outputDirAbsolutePath = dir
+	_ = os.Setenv("GHORG_REFERENCE_STORE", "true")
+	_ = os.Setenv("GHORG_REFERENCE_STORE_PATH", store)
+
+	var fetched, referenced []string
+	rp := NewRepositoryProcessor(referenceMockGitClient{fetched: &fetched, referenced: &referenced})
+
+	repos := []scm.Repo{
+		{Name: "api", URL: "https://github.com/org/api", CloneBranch: "main"},
+		{Name: "api-fork", URL: "https://github.com/org/api-fork", UpstreamPath: "upstream/api", CloneBranch: "main"},
+		{Name: "api.wiki", URL: "https://github.com/org/api.wiki", IsWiki: true, CloneBranch: "main"},
+	}
+	for i := range repos {
+		rp.ProcessRepository(&repos[i], map[string]bool{}, false, repos[i].Name, i)
+	}
+
+	upstreamReference := filepath.Join(store, "github.com", "upstream", "api.git")
+	wantFetched := []string{
+		"org/api -> " + filepath.Join(store, "github.com", "org", "api.git"),
+		"org/api-fork -> " + upstreamReference,
+	}
+	if strings.Join(fetched, "\n") != strings.Join(wantFetched, "\n") {
+		t.Errorf("expected fetches %v, got %v", wantFetched, fetched)
+	}
+	if len(referenced) != 3 || referenced[1] != upstreamReference || referenced[2] != "" {
+		t.Errorf("expected the fork to be cloned with the reference of its upstream and the wiki without one, got %v", referenced)
+	}
+}
+
+func TestRepositoryProcessor_ReferenceStoreFailure(t *testing.T) {
+	defer UnsetEnv("GHORG_")()
+	outputDirAbsolutePath = t.TempDir()
+	_ = os.Setenv("GHORG_REFERENCE_STORE", "true")
+	_ = os.Setenv("GHORG_REFERENCE_STORE_PATH", t.TempDir())
+
+	var fetched, referenced []string
+	rp := NewRepositoryProcessor(referenceMockGitClient{fetched: &fetched, referenced: &referenced, failing: true})
+
+	repo := scm.Repo{Name: "api", URL: "https://github.com/org/api", CloneBranch: "main"}
+	rp.ProcessRepository(&repo, map[string]bool{}, false, "api", 0)
+
+	if len(referenced) != 1 || referenced[0] != "" {
+		t.Errorf("expected the repo to be cloned without a reference, got %v", referenced)
+	}
+	stats := rp.GetStats()
+	if stats.CloneCount != 1 || len(stats.CloneErrors) != 0 {
+		t.Errorf("expected the clone to succeed, got %+v", stats)
+	}
+	if len(stats.CloneInfos) != 1 || !strings.Contains(stats.CloneInfos[0], "reference store") {
+		t.Errorf("expected an info about the reference store, got %v", stats.CloneInfos)
+	}
+}
+
+// mockForkSources returns the upstream of forks from a map and records which forks were looked up
+type mockForkSources struct {
+	upstreams map[string]string
+	resolved  *[]string
+}
+
+func (m mockForkSources) GetForkSource(fork scm.Repo) (string, error) {
+	*m.resolved = append(*m.resolved, fork.FullName)
+	return m.upstreams[fork.FullName], nil
+}
+
+func TestRepositoryProcessor_ReferenceStoreResolvesForksOfNewClones(t *testing.T) {
+	defer UnsetEnv("GHORG_")()
+	dir := t.TempDir()
+	store := filepath.Join(t.TempDir(), "reference-store")
+	outputDirAbsolutePath = dir
+	_ = os.Setenv("GHORG_REFERENCE_STORE", "true")
+	_ = os.Setenv("GHORG_REFERENCE_STORE_PATH", store)
+
+	var fetched, referenced, resolved []string
+	rp := NewRepositoryProcessor(referenceMockGitClient{fetched: &fetched, referenced: &referenced})
+	rp.forkSources = mockForkSources{upstreams: map[string]string{"org/api-fork": "upstream/api", "org/cloned-fork": "upstream/web"}, resolved: &resolved}
+
+	if err := os.MkdirAll(filepath.Join(dir, "cloned-fork"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	repos := []scm.Repo{
+		{Name: "api-fork", FullName: "org/api-fork", URL: "https://github.com/org/api-fork", Fork: true, CloneBranch: "main"},
+		{Name: "cloned-fork", FullName: "org/cloned-fork", URL: "https://github.com/org/cloned-fork", Fork: true, CloneBranch: "main"},
+		{Name: "api", FullName: "org/api", URL: "https://github.com/org/api", CloneBranch: "main"},
+	}
+	for i := range repos {
+		rp.ProcessRepository(&repos[i], map[string]bool{}, false, repos[i].Name, i)
+	}
+
+	if len(resolved) != 1 || resolved[0] != "org/api-fork" {
+		t.Errorf("expected only the fork being cloned to be looked up, got %v", resolved)
+	}
+	if len(referenced) == 0 || referenced[0] != filepath.Join(store, "github.com", "upstream", "api.git") {
+		t.Errorf("expected the fork to be cloned with the reference of its upstream, got %v", referenced)
+	}
+}
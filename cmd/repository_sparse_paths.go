@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gabrie30/ghorg/scm"
+	"gopkg.in/yaml.v2"
+)
+
+// repoSparsePaths are the sparse paths repos are checked out with, GHORG_SPARSE_PATHS applies to every repo
+// and GHORG_SPARSE_PATHS_FILE maps repos to their own
+type repoSparsePaths struct {
+	defaults []string
+	// byRepo is keyed by repo path like org/repo or group/subgroup/repo, or by repo name. An empty list checks
+	// out every file of that repo.
+	byRepo map[string][]string
+}
+
+// loadRepoSparsePaths reads GHORG_SPARSE_PATHS and GHORG_SPARSE_PATHS_FILE, it returns nil when neither is set
+func loadRepoSparsePaths() (*repoSparsePaths, error) {
+	value, file := os.Getenv("GHORG_SPARSE_PATHS"), os.Getenv("GHORG_SPARSE_PATHS_FILE")
+	if value == "" && file == "" {
+		return nil, nil
+	}
+
+	defaults, err := parseSparsePaths(strings.Split(value, ","))
+	if err != nil {
+		return nil, fmt.Errorf("GHORG_SPARSE_PATHS: %v", err)
+	}
+	sparse := &repoSparsePaths{defaults: defaults, byRepo: map[string][]string{}}
+
+	if file == "" {
+		return sparse, nil
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("could not read GHORG_SPARSE_PATHS_FILE: %v", err)
+	}
+
+	mapping := map[string][]string{}
+	if err := yaml.UnmarshalStrict(data, &mapping); err != nil {
+		return nil, fmt.Errorf("could not parse GHORG_SPARSE_PATHS_FILE %s, it must map repos to lists of paths: %v", file, err)
+	}
+
+	for repo, paths := range mapping {
+		key := strings.TrimSuffix(strings.Trim(repo, "/"), ".git")
+		if key == "" {
+			return nil, fmt.Errorf("GHORG_SPARSE_PATHS_FILE %s: empty repo name", file)
+		}
+		if sparse.byRepo[key], err = parseSparsePaths(paths); err != nil {
+			return nil, fmt.Errorf("GHORG_SPARSE_PATHS_FILE %s, repo %s: %v", file, repo, err)
+		}
+	}
+
+	return sparse, nil
+}
+
+// parseSparsePaths cleans up cone mode paths. Cone mode takes directories relative to the root of the repo,
+// patterns and paths leaving the repo are rejected.
+func parseSparsePaths(paths []string) ([]string, error) {
+	cleaned := []string{}
+	for _, p := range paths {
+		p = strings.Trim(strings.TrimSpace(p), "/")
+		if p == "" {
+			continue
+		}
+		if strings.ContainsAny(p, `*?[]!\`) {
+			return nil, fmt.Errorf("%q is a pattern, sparse paths are directories", p)
+		}
+		if p = filepath.ToSlash(filepath.Clean(p)); p == "." || p == ".." || strings.HasPrefix(p, "../") {
+			return nil, fmt.Errorf("%q is not a directory inside the repo", p)
+		}
+		cleaned = append(cleaned, p)
+	}
+	return cleaned, nil
+}
+
+// forRepo returns the sparse paths of repo, a mapping for its path wins over one for its name, which wins over
+// GHORG_SPARSE_PATHS. Wikis, snippets and gists are always checked out in full.
+func (sparse *repoSparsePaths) forRepo(repo scm.Repo) []string {
+	if sparse == nil || repo.IsWiki || repo.IsGitLabSnippet || repo.IsGitHubGist || repo.IsSnippet {
+		return nil
+	}
+
+	for _, key := range []string{repoPatternPath(repo.URL), repo.Name} {
+		if paths, ok := sparse.byRepo[key]; ok {
+			return paths
+		}
+	}
+	return sparse.defaults
+}
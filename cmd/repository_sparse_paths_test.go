@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gabrie30/ghorg/scm"
+)
+
+// sparseMockGitClient records the sparse paths each repo was checked out with
+type sparseMockGitClient struct {
+	MockGitClient
+	applied *[][]string
+}
+
+func (g sparseMockGitClient) SparseCheckout(repo scm.Repo) error {
+	*g.applied = append(*g.applied, repo.SparsePaths)
+	return nil
+}
+
+func TestParseSparsePaths(t *testing.T) {
+	got, err := parseSparsePaths([]string{" services/api/ ", "", "/libs", "docs/./guides"})
+	if err != nil || !reflect.DeepEqual(got, []string{"services/api", "libs", "docs/guides"}) {
+		t.Errorf("expected cleaned paths, got %v %v", got, err)
+	}
+
+	for _, invalid := range []string{"services/*", "..", "../other", "!docs"} {
+		if _, err := parseSparsePaths([]string{invalid}); err == nil {
+			t.Errorf("expected %q to be rejected", invalid)
+		}
+	}
+}
+
+func TestLoadRepoSparsePaths(t *testing.T) {
+	defer UnsetEnv("GHORG_")()
+
+	sparse, err := loadRepoSparsePaths()
+	if sparse != nil || err != nil {
+		t.Fatalf("expected nothing when sparse paths are not set, got %v %v", sparse, err)
+	}
+
+	file := filepath.Join(t.TempDir(), "sparse-paths.yaml")
+	mapping := "monorepo:\n  - services/api\n  - libs\ngroup/subgroup/docs.git: [site]\nsmall: []\n"
+	if err := os.WriteFile(file, []byte(mapping), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_ = os.Setenv("GHORG_SPARSE_PATHS", "tools")
+	_ = os.Setenv("GHORG_SPARSE_PATHS_FILE", file)
+
+	sparse, err = loadRepoSparsePaths()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	testCases := []struct {
+		repo scm.Repo
+		want []string
+	}{
+		{scm.Repo{Name: "monorepo", URL: "https://github.com/org/monorepo"}, []string{"services/api", "libs"}},
+		{scm.Repo{Name: "docs", URL: "https://gitlab.com/group/subgroup/docs"}, []string{"site"}},
+		{scm.Repo{Name: "docs", URL: "https://gitlab.com/other/docs"}, []string{"tools"}},
+		{scm.Repo{Name: "small", URL: "https://github.com/org/small"}, []string{}},
+		{scm.Repo{Name: "monorepo", URL: "https://github.com/org/monorepo.wiki", IsWiki: true}, nil},
+	}
+	for _, tc := range testCases {
+		if got := sparse.forRepo(tc.repo); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: expected %v, got %v", tc.repo.URL, tc.want, got)
+		}
+	}
+
+	if err := os.WriteFile(file, []byte("monorepo: services/api\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadRepoSparsePaths(); err == nil || !strings.Contains(err.Error(), "lists of paths") {
+		t.Errorf("expected an error for a mapping that is not a list, got %v", err)
+	}
+}
+
+func TestRepositoryProcessor_SparsePaths(t *testing.T) {
+	defer UnsetEnv("GHORG_")()
+	dir := t.TempDir()
+	outputDirAbsolutePath = dir
+	_ = os.Setenv("GHORG_SPARSE_PATHS", "services/api,libs")
+
+	var applied [][]string
+	rp := NewRepositoryProcessor(sparseMockGitClient{applied: &applied})
+
+	// existing repos have their sparse paths applied before they are cleaned and pulled
+	if err := os.MkdirAll(filepath.Join(dir, "monorepo"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	repo := scm.Repo{Name: "monorepo", URL: "https://github.com/org/monorepo", CloneBranch: "main"}
+	rp.ProcessRepository(&repo, map[string]bool{}, false, "monorepo", 0)
+
+	if !reflect.DeepEqual(repo.SparsePaths, []string{"services/api", "libs"}) {
+		t.Errorf("expected the repo to get the sparse paths, got %v", repo.SparsePaths)
+	}
+	if !reflect.DeepEqual(applied, [][]string{{"services/api", "libs"}}) {
+		t.Errorf("expected the sparse paths to be applied on pull, got %v", applied)
+	}
+	if stats := rp.GetStats(); len(stats.CloneErrors) != 0 || stats.PulledCount != 1 {
+		t.Errorf("expected the repo to be pulled without errors, got %+v", stats)
+	}
+}
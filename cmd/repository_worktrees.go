@@ -0,0 +1,228 @@
+package cmd
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/gabrie30/ghorg/colorlog"
+	"github.com/gabrie30/ghorg/git"
+	"github.com/gabrie30/ghorg/scm"
+)
+
+// worktreesDirSuffix is added to the directory of a clone to get the directory its branch worktrees are kept
+// in, e.g. the release/1.2 worktree of my-org/api is my-org/api.worktrees/release/1.2
+const worktreesDirSuffix = ".worktrees"
+
+// worktreesDirPath returns the directory the branch worktrees of the clone at hostPath are kept in
+func worktreesDirPath(hostPath string) string {
+	return hostPath + worktreesDirSuffix
+}
+
+// parseWorktreeBranches splits GHORG_WORKTREE_BRANCHES into branch patterns, * matches within a single
+// path segment so release/* matches release/1.2 but not release/1.2/hotfix
+func parseWorktreeBranches(value string) ([]string, error) {
+	patterns := []string{}
+	for _, pattern := range strings.Split(value, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" || slices.Contains(patterns, pattern) {
+			continue
+		}
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid branch pattern %q: %v", pattern, err)
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
+
+// matchingWorktreeBranches returns the branches matching any of the patterns, sorted
+func matchingWorktreeBranches(branches []string, patterns []string) []string {
+	matching := []string{}
+	for _, branch := range branches {
+		for _, pattern := range patterns {
+			if ok, _ := filepath.Match(pattern, branch); ok {
+				matching = append(matching, branch)
+				break
+			}
+		}
+	}
+	slices.Sort(matching)
+	return matching
+}
+
+// findWorktreeDirs returns the worktrees in dir, which have a .git file rather than a .git directory
+func findWorktreeDirs(dir string) []string {
+	worktrees := []string{}
+	_ = filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.IsDir() {
+			return nil
+		}
+		if stat, err := os.Stat(filepath.Join(path, ".git")); err == nil && !stat.IsDir() {
+			worktrees = append(worktrees, path)
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	return worktrees
+}
+
+// removeEmptyWorktreeDirs removes the directories under dir left empty by removed worktrees, and dir itself
+// once the last worktree is gone
+func removeEmptyWorktreeDirs(dir string, removed string) {
+	for parent := filepath.Dir(removed); strings.HasPrefix(parent, dir); parent = filepath.Dir(parent) {
+		// os.Remove only removes empty directories
+		if os.Remove(parent) != nil || parent == dir {
+			return
+		}
+	}
+}
+
+// syncWorktrees keeps a worktree per branch of repo matching GHORG_WORKTREE_BRANCHES next to the clone, sharing
+// its object store. New branches get a worktree, existing ones are updated like the clone and the worktrees of
+// deleted branches are removed. The branch checked out in the clone can't be checked out twice so it's skipped.
+func (rp *RepositoryProcessor) syncWorktrees(repo scm.Repo) {
+	worktrees, ok := rp.git.(git.WorktreeManager)
+	if !ok || len(rp.worktreeBranches) == 0 || repo.VCS == scm.VCSHg {
+		return
+	}
+
+	dir := worktreesDirPath(repo.HostPath)
+
+	// worktrees moved along with a clone of a renamed repo need to be reconnected, then the ones deleted by
+	// hand are forgotten so their branches can be checked out again
+	if paths := findWorktreeDirs(dir); len(paths) > 0 {
+		if err := worktrees.RepairWorktrees(repo, paths); err != nil {
+			rp.addInfo(fmt.Sprintf("Could not repair the worktrees of: %s Error: %v", repo.URL, err))
+		}
+	}
+	if err := worktrees.PruneWorktrees(repo); err != nil {
+		rp.addInfo(fmt.Sprintf("Could not prune the worktrees of: %s Error: %v", repo.URL, err))
+	}
+
+	// Temporarily restore credentials to fetch every branch of private repos
+	err := rp.git.SetOriginWithCredentials(repo)
+	if err != nil {
+		rp.addError(fmt.Sprintf("Problem trying to set remote with credentials: %s Error: %v", repo.URL, err))
+		return
+	}
+
+	fetchErr := worktrees.FetchBranches(repo)
+
+	// Always strip credentials again for security, even if fetch failed
+	err = rp.git.SetOrigin(repo)
+	if err != nil {
+		rp.addError(fmt.Sprintf("Problem trying to reset remote after fetching branches: %s Error: %v", repo.URL, err))
+		return
+	}
+
+	if fetchErr != nil {
+		rp.addError(fmt.Sprintf("Could not fetch branches for worktrees: %s Error: %v", repo.URL, fetchErr))
+		return
+	}
+
+	remoteBranches, err := worktrees.RemoteBranches(repo)
+	if err != nil {
+		rp.addError(fmt.Sprintf("Could not list branches for worktrees: %s Error: %v", repo.URL, err))
+		return
+	}
+
+	existing, err := worktrees.Worktrees(repo)
+	if err != nil {
+		rp.addError(fmt.Sprintf("Could not list worktrees: %s Error: %v", repo.URL, err))
+		return
+	}
+
+	checkedOut, err := rp.git.GetCurrentBranch(repo)
+	if err != nil || checkedOut == "" {
+		checkedOut = repo.CloneBranch
+	}
+
+	wanted := []string{}
+	for _, branch := range matchingWorktreeBranches(remoteBranches, rp.worktreeBranches) {
+		if branch != checkedOut {
+			wanted = append(wanted, branch)
+		}
+	}
+
+	// git lists worktrees by their real path, which differs from dir when the clone path has a symlink in it
+	realDir := dir
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		realDir = resolved
+	}
+
+	// worktrees outside of dir or with another branch checked out were not made by ghorg and are left alone
+	managed := map[string]string{}
+	for _, worktree := range existing {
+		rel, err := filepath.Rel(realDir, worktree.Path)
+		if err != nil || filepath.ToSlash(rel) != worktree.Branch {
+			continue
+		}
+		managed[worktree.Branch] = filepath.Join(dir, rel)
+	}
+
+	for _, branch := range wanted {
+		worktreePath, ok := managed[branch]
+		if !ok {
+			worktreePath = filepath.Join(dir, filepath.FromSlash(branch))
+			if err := worktrees.AddWorktree(repo, worktreePath, branch); err != nil {
+				rp.addInfo(fmt.Sprintf("Could not add a worktree for branch %s of: %s Error: %v", branch, repo.URL, err))
+				continue
+			}
+			colorlog.PrintSubtleInfo(fmt.Sprintf("Added worktree %s, branch: %s", worktreePath, branch))
+		}
+
+		rp.updateWorktree(repo, worktreePath, branch)
+	}
+
+	// without clean the worktrees are only removed when git considers them clean, so local changes are kept
+	force := os.Getenv("GHORG_NO_CLEAN") != "true" && os.Getenv("GHORG_PROTECT_LOCAL") != "true"
+	for branch, worktreePath := range managed {
+		if slices.Contains(wanted, branch) {
+			continue
+		}
+		if err := worktrees.RemoveWorktree(repo, worktreePath, force); err != nil {
+			rp.addInfo(fmt.Sprintf("Could not remove the worktree of branch %s, it was deleted or no longer matches GHORG_WORKTREE_BRANCHES: %s Error: %v", branch, worktreePath, err))
+			continue
+		}
+		removeEmptyWorktreeDirs(dir, worktreePath)
+		colorlog.PrintSubtleInfo(fmt.Sprintf("Removed worktree %s, branch %s was deleted or no longer matches", worktreePath, branch))
+	}
+}
+
+// updateWorktree resets a worktree to its remote branch the same way the clone is updated, worktrees with
+// local changes are skipped with --protect-local and --no-clean leaves them as they are
+func (rp *RepositoryProcessor) updateWorktree(repo scm.Repo, worktreePath string, branch string) {
+	if os.Getenv("GHORG_NO_CLEAN") == "true" {
+		return
+	}
+
+	worktree := repo
+	worktree.HostPath = worktreePath
+	worktree.CloneBranch = branch
+
+	if os.Getenv("GHORG_PROTECT_LOCAL") == "true" {
+		hasChanges, reason, err := rp.hasLocalChanges(&worktree)
+		if err != nil {
+			rp.addInfo(fmt.Sprintf("Could not check for local changes on worktree %s: %v", worktreePath, err))
+			return
+		}
+		if hasChanges {
+			rp.addProtected(worktreePath)
+			colorlog.PrintInfo(fmt.Sprintf("Skipping worktree %s: %s (--protect-local)", worktreePath, reason))
+			return
+		}
+	}
+
+	if err := rp.git.Clean(worktree); err != nil {
+		rp.addError(fmt.Sprintf("Problem running git clean on worktree: %s Error: %v", worktreePath, err))
+		return
+	}
+
+	if err := rp.git.Reset(worktree); err != nil {
+		rp.addError(fmt.Sprintf("Problem resetting worktree: %s to branch: %s Error: %v", worktreePath, branch, err))
+	}
+}
@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gabrie30/ghorg/git"
+	"github.com/gabrie30/ghorg/scm"
+)
+
+// worktreeMockGitClient keeps the worktrees of a single repo in memory and records which were reset
+type worktreeMockGitClient struct {
+	MockGitClient
+	remoteBranches []string
+	worktrees      *[]git.Worktree
+	// dirty worktrees can only be removed with force
+	dirty map[string]bool
+	reset *[]string
+}
+
+func (g worktreeMockGitClient) FetchBranches(repo scm.Repo) error { return nil }
+func (g worktreeMockGitClient) RemoteBranches(repo scm.Repo) ([]string, error) {
+	return g.remoteBranches, nil
+}
+func (g worktreeMockGitClient) Worktrees(repo scm.Repo) ([]git.Worktree, error) {
+	return append([]git.Worktree(nil), *g.worktrees...), nil
+}
+func (g worktreeMockGitClient) RepairWorktrees(repo scm.Repo, paths []string) error { return nil }
+func (g worktreeMockGitClient) PruneWorktrees(repo scm.Repo) error                  { return nil }
+
+func (g worktreeMockGitClient) AddWorktree(repo scm.Repo, path string, branch string) error {
+	if branch == "release/broken" {
+		return errors.New("fatal: invalid reference")
+	}
+	*g.worktrees = append(*g.worktrees, git.Worktree{Path: path, Branch: branch})
+	return os.MkdirAll(path, 0755)
+}
+
+func (g worktreeMockGitClient) RemoveWorktree(repo scm.Repo, path string, force bool) error {
+	if g.dirty[path] && !force {
+		return errors.New("fatal: contains modified or untracked files, use --force to delete it")
+	}
+	*g.worktrees = slices.DeleteFunc(*g.worktrees, func(w git.Worktree) bool { return w.Path == path })
+	return os.RemoveAll(path)
+}
+
+func (g worktreeMockGitClient) Reset(repo scm.Repo) error {
+	*g.reset = append(*g.reset, repo.CloneBranch)
+	return nil
+}
+
+func TestParseWorktreeBranches(t *testing.T) {
+	got, err := parseWorktreeBranches(" main, release/*,,main ")
+	if err != nil || !reflect.DeepEqual(got, []string{"main", "release/*"}) {
+		t.Errorf("expected [main release/*], got %v %v", got, err)
+	}
+
+	if _, err := parseWorktreeBranches("release/[1-"); err == nil {
+		t.Errorf("expected an invalid pattern to be rejected")
+	}
+}
+
+func TestMatchingWorktreeBranches(t *testing.T) {
+	branches := []string{"release/2.0", "main", "feature/login", "release/1.9", "release/1.9/hotfix", "releases"}
+	got := matchingWorktreeBranches(branches, []string{"main", "release/*"})
+	want := []string{"main", "release/1.9", "release/2.0"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestSyncWorktrees(t *testing.T) {
+	defer UnsetEnv("GHORG_")()
+
+	dir := t.TempDir()
+	outputDirAbsolutePath = dir
+	repo := scm.Repo{Name: "api", URL: "https://github.com/org/api", HostPath: filepath.Join(dir, "api"), CloneBranch: "main"}
+	worktreesDir := worktreesDirPath(repo.HostPath)
+
+	staleDir := filepath.Join(worktreesDir, "release", "1.8")
+	dirtyDir := filepath.Join(worktreesDir, "release", "1.7")
+	for _, path := range []string{staleDir, dirtyDir} {
+		if err := os.MkdirAll(path, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	worktrees := []git.Worktree{
+		{Path: filepath.Join(worktreesDir, "release", "1.9"), Branch: "release/1.9"},
+		{Path: staleDir, Branch: "release/1.8"},
+		{Path: dirtyDir, Branch: "release/1.7"},
+		// made by hand outside of the layout so it's left alone
+		{Path: filepath.Join(dir, "scratch"), Branch: "release/1.6"},
+	}
+	var reset []string
+	gitter := worktreeMockGitClient{
+		remoteBranches: []string{"main", "release/1.9", "release/2.0", "release/broken", "feature/login"},
+		worktrees:      &worktrees,
+		dirty:          map[string]bool{dirtyDir: true},
+		reset:          &reset,
+	}
+
+	_ = os.Setenv("GHORG_WORKTREE_BRANCHES", "main,release/*")
+	_ = os.Setenv("GHORG_PROTECT_LOCAL", "true")
+	rp := NewRepositoryProcessor(gitter)
+	rp.syncWorktrees(repo)
+
+	got := map[string]string{}
+	for _, worktree := range worktrees {
+		got[worktree.Branch] = worktree.Path
+	}
+	want := map[string]string{
+		"release/1.9": filepath.Join(worktreesDir, "release", "1.9"),
+		"release/2.0": filepath.Join(worktreesDir, "release", "2.0"),
+		"release/1.7": dirtyDir,
+		"release/1.6": filepath.Join(dir, "scratch"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected worktrees %v, got %v", want, got)
+	}
+
+	if !reflect.DeepEqual(reset, []string{"release/1.9", "release/2.0"}) {
+		t.Errorf("expected the matching worktrees to be reset to their branch, got %v", reset)
+	}
+	if _, err := os.Stat(filepath.Join(worktreesDir, "release", "1.8")); !os.IsNotExist(err) {
+		t.Errorf("expected the worktree of the deleted branch to be removed")
+	}
+
+	stats := rp.GetStats()
+	if len(stats.CloneErrors) != 0 {
+		t.Errorf("expected no errors, got %v", stats.CloneErrors)
+	}
+	if len(stats.CloneInfos) != 2 || !strings.Contains(stats.CloneInfos[0], "release/broken") || !strings.Contains(stats.CloneInfos[1], "release/1.7") {
+		t.Errorf("expected infos for the broken branch and the dirty worktree, got %v", stats.CloneInfos)
+	}
+}
+
+func TestSyncWorktrees_RemovesEmptyWorktreesDir(t *testing.T) {
+	defer UnsetEnv("GHORG_")()
+
+	dir := t.TempDir()
+	outputDirAbsolutePath = dir
+	repo := scm.Repo{Name: "api", HostPath: filepath.Join(dir, "api"), CloneBranch: "main"}
+	worktreePath := filepath.Join(worktreesDirPath(repo.HostPath), "release", "1.0")
+	if err := os.MkdirAll(worktreePath, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	worktrees := []git.Worktree{{Path: worktreePath, Branch: "release/1.0"}}
+	var reset []string
+	gitter := worktreeMockGitClient{remoteBranches: []string{"main"}, worktrees: &worktrees, reset: &reset}
+
+	_ = os.Setenv("GHORG_WORKTREE_BRANCHES", "release/*")
+	rp := NewRepositoryProcessor(gitter)
+	rp.syncWorktrees(repo)
+
+	if len(worktrees) != 0 {
+		t.Errorf("expected the worktree to be removed, got %v", worktrees)
+	}
+	if _, err := os.Stat(worktreesDirPath(repo.HostPath)); !os.IsNotExist(err) {
+		t.Errorf("expected the empty worktrees directory to be removed")
+	}
+}
@@ -34,6 +34,25 @@ var (
 	outputDir                    string
 	topics                       string
 	gitFilter                    string
+	worktreeBranches             string
+	sparsePaths                  string
+	sparsePathsFile              string
+	referenceStorePath           string
+	sourcehutHgBaseURL           string
+	sourcehutHg                  bool
+	gistVisibility               string
+	gistDescriptionRegex         string
+	gistFileExtensions           string
+	gistUpdatedSince             string
+	gistFolderName               string
+	pushedSince                  string
+	pushedBefore                 string
+	minRepoSize                  string
+	maxRepoSize                  string
+	visibility                   string
+	filterExpression             string
+	filterLanguage               string
+	filterLanguageThreshold      string
 	targetCloneSource            string
 	matchPrefix                  string
 	excludeMatchPrefix           string
@@ -44,8 +63,11 @@ var (
 	gitlabGroupMatchRegex        string
 	ghorgIgnorePath              string
 	ghorgOnlyPath                string
+	ghorgFileSyntax              string
+	outputFormat                 string
 	targetReposPath              string
 	ghorgReClonePath             string
+	ghorgReCloneDir              string
 	githubAppID                  string
 	githubAppPemPath             string
 	githubAppInstallationID      string
@@ -53,6 +75,8 @@ var (
 	githubFilterLanguage         string
 	githubRepoListConcurrency    string
 	cronTimerMinutes             string
+	cronMaintainMinutes          string
+	maintainTasks                string
 	recloneServerPort            string
 	cloneDelaySeconds            string
 	sshHostname                  string
@@ -76,6 +100,9 @@ var (
 	fetchAll                     bool
 	fetchGitLfs                  bool
 	fetchPrune                   bool
+	fetchReviewRefs              bool
+	reviewRefsIncludeClosed      bool
+	backupMetadata               bool
 	ghorgReCloneQuiet            bool
 	ghorgReCloneList             bool
 	ghorgReCloneEnvConfigOnly    bool
@@ -89,6 +116,8 @@ var (
 	ghorgPruneUntouched          bool
 	ghorgPruneUntouchedNoConfirm bool
 	protectLocal                 bool
+	referenceStore               bool
+	referenceStoreDissociate     bool
 	cloneErrors                  []string
 	cloneInfos                   []string
 )
@@ -165,6 +194,8 @@ func getOrSetDefaults(envVar string) {
 			_ = os.Setenv(envVar, configs.GhorgIgnoreLocation())
 		case "GHORG_ONLY_PATH":
 			_ = os.Setenv(envVar, configs.GhorgOnlyLocation())
+		case "GHORG_OUTPUT":
+			_ = os.Setenv(envVar, "text")
 		case "GHORG_RECLONE_PATH":
 			_ = os.Setenv(envVar, configs.GhorgReCloneLocation())
 		case "GHORG_CLONE_PROTOCOL":
@@ -187,6 +218,8 @@ func getOrSetDefaults(envVar string) {
 			_ = os.Setenv(envVar, "false")
 		case "GHORG_CRON_TIMER_MINUTES":
 			_ = os.Setenv(envVar, "60")
+		case "GHORG_MAINTAIN_TASKS":
+			_ = os.Setenv(envVar, "gc,fsck")
 		case "GHORG_RECLONE_SERVER_PORT":
 			_ = os.Setenv(envVar, ":8080")
 		case "GHORG_FETCH_ALL":
@@ -195,8 +228,20 @@ func getOrSetDefaults(envVar string) {
 			_ = os.Setenv(envVar, "false")
 		case "GHORG_FETCH_PRUNE":
 			_ = os.Setenv(envVar, "false")
+		case "GHORG_FETCH_REVIEW_REFS":
+			_ = os.Setenv(envVar, "false")
+		case "GHORG_REVIEW_REFS_INCLUDE_CLOSED":
+			_ = os.Setenv(envVar, "false")
+		case "GHORG_BACKUP_METADATA":
+			_ = os.Setenv(envVar, "false")
 		case "GHORG_PROTECT_LOCAL":
 			_ = os.Setenv(envVar, "false")
+		case "GHORG_REFERENCE_STORE":
+			_ = os.Setenv(envVar, "false")
+		case "GHORG_REFERENCE_STORE_PATH":
+			_ = os.Setenv(envVar, configs.GhorgReferenceStoreLocation())
+		case "GHORG_REFERENCE_STORE_DISSOCIATE":
+			_ = os.Setenv(envVar, "false")
 		case "GHORG_DRY_RUN":
 			_ = os.Setenv(envVar, "false")
 		case "GHORG_PRUNE":
@@ -219,6 +264,8 @@ func getOrSetDefaults(envVar string) {
 			_ = os.Setenv(envVar, "false")
 		case "GHORG_INSECURE_SOURCEHUT_CLIENT":
 			_ = os.Setenv(envVar, "false")
+		case "GHORG_SOURCEHUT_HG":
+			_ = os.Setenv(envVar, "false")
 		case "GHORG_GITHUB_USER_OPTION":
 			_ = os.Setenv(envVar, "owner")
 		case "GHORG_BACKUP":
@@ -326,12 +373,16 @@ func InitConfig() {
 	getOrSetDefaults("GHORG_FETCH_ALL")
 	getOrSetDefaults("GHORG_FETCH_GIT_LFS")
 	getOrSetDefaults("GHORG_FETCH_PRUNE")
+	getOrSetDefaults("GHORG_FETCH_REVIEW_REFS")
+	getOrSetDefaults("GHORG_REVIEW_REFS_INCLUDE_CLOSED")
+	getOrSetDefaults("GHORG_BACKUP_METADATA")
 	getOrSetDefaults("GHORG_PROTECT_LOCAL")
 	getOrSetDefaults("GHORG_PRUNE")
 	getOrSetDefaults("GHORG_PRUNE_NO_CONFIRM")
 	getOrSetDefaults("GHORG_PRUNE_UNTOUCHED")
 	getOrSetDefaults("GHORG_PRUNE_UNTOUCHED_NO_CONFIRM")
 	getOrSetDefaults("GHORG_DRY_RUN")
+	getOrSetDefaults("GHORG_OUTPUT")
 	getOrSetDefaults("GHORG_GITHUB_USER_OPTION")
 	getOrSetDefaults("GHORG_CLONE_WIKI")
 	getOrSetDefaults("GHORG_CLONE_SNIPPETS")
@@ -351,17 +402,32 @@ func InitConfig() {
 	getOrSetDefaults("GHORG_STATS_ENABLED")
 	getOrSetDefaults("GHORG_CRON_TIMER_MINUTES")
 	getOrSetDefaults("GHORG_RECLONE_SERVER_PORT")
+	getOrSetDefaults("GHORG_MAINTAIN_TASKS")
 	// Optionally set
+	getOrSetDefaults("GHORG_CRON_MAINTAIN_MINUTES")
 	getOrSetDefaults("GHORG_TOKEN_CMD")
 	getOrSetDefaults("GHORG_TARGET_REPOS_PATH")
 	getOrSetDefaults("GHORG_CLONE_DEPTH")
 	getOrSetDefaults("GHORG_GITHUB_TOKEN")
 	getOrSetDefaults("GHORG_GITHUB_TOKEN_FROM_GITHUB_APP")
 	getOrSetDefaults("GHORG_GITHUB_USER_GISTS")
+	getOrSetDefaults("GHORG_GIST_VISIBILITY")
+	getOrSetDefaults("GHORG_GIST_DESCRIPTION_REGEX")
+	getOrSetDefaults("GHORG_GIST_FILE_EXTENSIONS")
+	getOrSetDefaults("GHORG_GIST_UPDATED_SINCE")
+	getOrSetDefaults("GHORG_GIST_FOLDER_NAME")
 	getOrSetDefaults("GHORG_GITHUB_FILTER_LANGUAGE")
+	getOrSetDefaults("GHORG_FILTER_LANGUAGE")
+	getOrSetDefaults("GHORG_FILTER_LANGUAGE_THRESHOLD")
 	getOrSetDefaults("GHORG_GITHUB_REPO_LIST_CONCURRENCY")
 	getOrSetDefaults("GHORG_COLOR")
 	getOrSetDefaults("GHORG_TOPICS")
+	getOrSetDefaults("GHORG_PUSHED_SINCE")
+	getOrSetDefaults("GHORG_PUSHED_BEFORE")
+	getOrSetDefaults("GHORG_MIN_REPO_SIZE")
+	getOrSetDefaults("GHORG_MAX_REPO_SIZE")
+	getOrSetDefaults("GHORG_VISIBILITY")
+	getOrSetDefaults("GHORG_FILTER")
 	getOrSetDefaults("GHORG_GITLAB_TOKEN")
 	getOrSetDefaults("GHORG_BITBUCKET_USERNAME")
 	getOrSetDefaults("GHORG_BITBUCKET_APP_PASSWORD")
@@ -381,11 +447,20 @@ func InitConfig() {
 	getOrSetDefaults("GHORG_IGNORE_PATH")
 	getOrSetDefaults("GHORG_ONLY_PATH")
 	getOrSetDefaults("GHORG_RECLONE_PATH")
+	getOrSetDefaults("GHORG_RECLONE_DIR")
 	getOrSetDefaults("GHORG_QUIET")
 	getOrSetDefaults("GHORG_GIT_FILTER")
+	getOrSetDefaults("GHORG_WORKTREE_BRANCHES")
+	getOrSetDefaults("GHORG_SPARSE_PATHS")
+	getOrSetDefaults("GHORG_SPARSE_PATHS_FILE")
+	getOrSetDefaults("GHORG_REFERENCE_STORE")
+	getOrSetDefaults("GHORG_REFERENCE_STORE_PATH")
+	getOrSetDefaults("GHORG_REFERENCE_STORE_DISSOCIATE")
 	getOrSetDefaults("GHORG_GITEA_TOKEN")
 	getOrSetDefaults("GHORG_CODEBERG_TOKEN")
 	getOrSetDefaults("GHORG_SOURCEHUT_TOKEN")
+	getOrSetDefaults("GHORG_SOURCEHUT_HG")
+	getOrSetDefaults("GHORG_SOURCEHUT_HG_BASE_URL")
 	getOrSetDefaults("GHORG_INSECURE_GITEA_CLIENT")
 	getOrSetDefaults("GHORG_SSH_HOSTNAME")
 	getOrSetDefaults("GHORG_GITHUB_APP_PEM_PATH")
@@ -419,7 +494,7 @@ func init() {
 	cloneCmd.Flags().StringVarP(&bitbucketUsername, "bitbucket-username", "", "", "GHORG_BITBUCKET_USERNAME - Bitbucket only: Username for legacy app password authentication. Required when using app passwords")
 	cloneCmd.Flags().StringVarP(&bitbucketAPIEmail, "bitbucket-api-email", "", "", "GHORG_BITBUCKET_API_EMAIL - Bitbucket only: Email address for modern API token authentication. Use this instead of username for API tokens")
 	cloneCmd.Flags().StringVarP(&scmType, "scm", "s", "", "GHORG_SCM_TYPE - Source code management platform to clone from: github, gitlab, gitea, codeberg, bitbucket, or sourcehut (default: github)")
-	cloneCmd.Flags().StringVarP(&cloneType, "clone-type", "c", "", "GHORG_CLONE_TYPE - Target type to clone: 'org' for organization/group or 'user' for individual user repositories (default: org)")
+	cloneCmd.Flags().StringVarP(&cloneType, "clone-type", "c", "", "GHORG_CLONE_TYPE - Target type to clone: 'org' for organization/group, 'user' for individual user repositories, or 'memberships' for every org/top level group the token is a member of, 'starred' or 'watched' for the repos a user has starred or is watching (default: org)")
 	cloneCmd.Flags().BoolVar(&skipArchived, "skip-archived", false, "GHORG_SKIP_ARCHIVED - Skip archived/read-only repositories during cloning. Supported on GitHub, GitLab, and Gitea")
 	cloneCmd.Flags().BoolVar(&noClean, "no-clean", false, "GHORG_NO_CLEAN - Only clone new repositories without running 'git clean' on existing ones. Use this to preserve local changes in already-cloned repos")
 	cloneCmd.Flags().BoolVar(&prune, "prune", false, "GHORG_PRUNE - Remove local repositories that no longer exist remotely. When used with --skip-archived, also removes archived repos locally. Prompts before deletion unless combined with --prune-no-confirm")
@@ -427,14 +502,20 @@ func init() {
 	cloneCmd.Flags().BoolVar(&fetchAll, "fetch-all", false, "GHORG_FETCH_ALL - Fetch all remote branches for each repository using 'git fetch --all'. Useful for getting complete branch information")
 	cloneCmd.Flags().BoolVar(&fetchGitLfs, "fetch-git-lfs", false, "GHORG_FETCH_GIT_LFS - Fetch git LFS (large file storage) content for each repository using 'git lfs fetch --all'. Useful for backing up repositories that use Git LFS.")
 	cloneCmd.Flags().BoolVar(&fetchPrune, "fetch-prune", false, "GHORG_FETCH_PRUNE - Remove stale remote-tracking branches during fetch (adds --prune to git fetch). Only applies when --fetch-all is used. Note: This is different from --prune which removes local clone directories")
+	cloneCmd.Flags().BoolVar(&fetchReviewRefs, "fetch-review-refs", false, "GHORG_FETCH_REVIEW_REFS - Fetch the refs of open pull requests (GitHub, Gitea, Codeberg) or merge requests (GitLab) into refs/remotes/origin/pr/* or refs/remotes/origin/mr/* and record each repo's open pull/merge requests in .git/ghorg-review-requests.json")
+	cloneCmd.Flags().BoolVar(&reviewRefsIncludeClosed, "review-refs-include-closed", false, "GHORG_REVIEW_REFS_INCLUDE_CLOSED - Also fetch and record closed and merged pull/merge requests when using --fetch-review-refs")
+	cloneCmd.Flags().BoolVar(&backupMetadata, "backup-metadata", false, "GHORG_BACKUP_METADATA - Export the issues, pull/merge requests with their comments, releases with their assets, labels and milestones of each repo as JSON into a <repo>.metadata directory next to the repo. Later runs only export what changed. GitHub, GitLab, Gitea and Codeberg only")
 	cloneCmd.Flags().BoolVar(&dryRun, "dry-run", false, "GHORG_DRY_RUN - Simulate the clone operation without actually cloning repositories. Shows what would be cloned for testing/verification")
+	cloneCmd.Flags().StringVarP(&outputFormat, "output", "", "", "GHORG_OUTPUT - Format of the --dry-run plan, a human readable list or a json document on stdout with all other output sent to stderr (text or json). Default: text")
 	cloneCmd.Flags().BoolVar(&insecureGitlabClient, "insecure-gitlab-client", false, "GHORG_INSECURE_GITLAB_CLIENT - Skip TLS certificate verification for self-hosted GitLab instances. Use only for internal/trusted instances")
 	cloneCmd.Flags().BoolVar(&insecureGiteaClient, "insecure-gitea-client", false, "GHORG_INSECURE_GITEA_CLIENT - Allow connections to Gitea instances using HTTP instead of HTTPS. Required for non-SSL Gitea servers")
 	cloneCmd.Flags().BoolVar(&insecureCodebergClient, "insecure-codeberg-client", false, "GHORG_INSECURE_CODEBERG_CLIENT - Allow connections to self-hosted Forgejo instances using HTTP instead of HTTPS when using --scm=codeberg. Required for non-SSL servers")
 	cloneCmd.Flags().BoolVar(&insecureBitbucketClient, "insecure-bitbucket-client", false, "GHORG_INSECURE_BITBUCKET_CLIENT - Allow connections to Bitbucket Server instances using HTTP. Required for non-SSL Bitbucket servers")
 	cloneCmd.Flags().BoolVar(&insecureSourcehutClient, "insecure-sourcehut-client", false, "GHORG_INSECURE_SOURCEHUT_CLIENT - Allow connections to Sourcehut instances using HTTP. Required for non-SSL Sourcehut servers")
-	cloneCmd.Flags().BoolVar(&cloneWiki, "clone-wiki", false, "GHORG_CLONE_WIKI - Additionally clone wiki pages associated with each repository if they exist")
-	cloneCmd.Flags().BoolVar(&cloneSnippets, "clone-snippets", false, "GHORG_CLONE_SNIPPETS - Additionally clone all code snippets/gists. GitLab only")
+	cloneCmd.Flags().BoolVar(&sourcehutHg, "sourcehut-hg", false, "GHORG_SOURCEHUT_HG - Also clone mercurial repos from hg.sr.ht into <name>.hg directories, requires hg to be installed and a token with hg.sr.ht access")
+	cloneCmd.Flags().StringVarP(&sourcehutHgBaseURL, "sourcehut-hg-base-url", "", "", "GHORG_SOURCEHUT_HG_BASE_URL - Base URL of the hg.sr.ht instance to list mercurial repos from, implies --sourcehut-hg. Defaults to the --base-url host with git. replaced by hg.")
+	cloneCmd.Flags().BoolVar(&cloneWiki, "clone-wiki", false, "GHORG_CLONE_WIKI - Additionally clone wiki pages associated with each repository, and GitLab group wikis, if they exist. Empty wikis are skipped")
+	cloneCmd.Flags().BoolVar(&cloneSnippets, "clone-snippets", false, "GHORG_CLONE_SNIPPETS - Additionally clone all code snippets. GitLab and Bitbucket Cloud only")
 	cloneCmd.Flags().BoolVar(&skipForks, "skip-forks", false, "GHORG_SKIP_FORKS - Skip repositories that are forks of other repositories. Supported on GitHub, GitLab, and Gitea")
 	cloneCmd.Flags().BoolVar(&noToken, "no-token", false, "GHORG_NO_TOKEN - Run without authentication token. Only works if your SCM server allows unauthenticated API access (typically for public repos only)")
 	cloneCmd.Flags().BoolVar(&noDirSize, "no-dir-size", false, "GHORG_NO_DIR_SIZE - Skip calculating total directory size at the end of cloning. Improves performance when cloning many/large repositories")
@@ -452,6 +533,14 @@ func init() {
 	cloneCmd.Flags().StringVarP(&cloneDelaySeconds, "clone-delay-seconds", "", "", "GHORG_CLONE_DELAY_SECONDS - Delay in seconds between each clone operation. Useful for rate limiting or reducing server load. Auto-sets concurrency to 1 when > 0 (default: 0)")
 	cloneCmd.Flags().StringVarP(&cloneDepth, "clone-depth", "", "", "GHORG_CLONE_DEPTH - Create shallow clones with limited history (e.g., --clone-depth=1 for latest commit only). Reduces clone time and disk usage")
 	cloneCmd.Flags().StringVarP(&topics, "topics", "", "", "GHORG_TOPICS - Comma-separated list of GitHub/Gitea topics to filter repositories (e.g., --topics=docker,kubernetes). Only clones repos with matching topics")
+	cloneCmd.Flags().StringVarP(&pushedSince, "pushed-since", "", "", "GHORG_PUSHED_SINCE - Only clone repos pushed to on or after a date (e.g., --pushed-since=2024-01-31) or within a duration (e.g., --pushed-since=12mo). Durations use h, d, w, mo or y")
+	cloneCmd.Flags().StringVarP(&filterLanguage, "filter-language", "", "", "GHORG_FILTER_LANGUAGE - Only clone repos whose primary language is one of these. Comma-separated values (e.g., --filter-language=go,python). GitHub, GitLab, Gitea and Bitbucket Cloud only")
+	cloneCmd.Flags().StringVarP(&filterLanguageThreshold, "filter-language-threshold", "", "", "GHORG_FILTER_LANGUAGE_THRESHOLD - GitLab only: Match repos where any --filter-language makes up at least this percentage of the code (e.g., --filter-language-threshold=20) instead of only the primary language")
+	cloneCmd.Flags().StringVarP(&minRepoSize, "min-repo-size", "", "", "GHORG_MIN_REPO_SIZE - Only clone repos at least this size as reported by the scm (e.g., --min-repo-size=10MB). Accepts KB, MB or GB, defaults to MB")
+	cloneCmd.Flags().StringVarP(&maxRepoSize, "max-repo-size", "", "", "GHORG_MAX_REPO_SIZE - Only clone repos at most this size as reported by the scm (e.g., --max-repo-size=2GB). Accepts KB, MB or GB, defaults to MB")
+	cloneCmd.Flags().StringVarP(&visibility, "visibility", "", "", "GHORG_VISIBILITY - Only clone repos with these visibilities. Comma-separated values of public, private or internal (e.g., --visibility=public)")
+	cloneCmd.Flags().StringVarP(&filterExpression, "filter", "", "", "GHORG_FILTER - Only clone repos matching an expression over repo metadata (e.g., --filter='name =~ \"^svc-\" && !archived && topics has \"prod\" && pushed_at > now-90d'). See README for fields and operators")
+	cloneCmd.Flags().StringVarP(&pushedBefore, "pushed-before", "", "", "GHORG_PUSHED_BEFORE - Only clone repos last pushed to before a date (e.g., --pushed-before=2024-01-31) or longer ago than a duration (e.g., --pushed-before=2y). Durations use h, d, w, mo or y")
 	cloneCmd.Flags().StringVarP(&outputDir, "output-dir", "", "", "GHORG_OUTPUT_DIR - Custom name for the directory where repositories will be cloned. (default: name of org/user being cloned)")
 	cloneCmd.Flags().StringVarP(&matchPrefix, "match-prefix", "", "", "GHORG_MATCH_PREFIX - Only clone repositories with names starting with specified prefix(es). Comma-separated list supported (e.g., --match-prefix=frontend,backend)")
 	cloneCmd.Flags().StringVarP(&excludeMatchPrefix, "exclude-match-prefix", "", "", "GHORG_EXCLUDE_MATCH_PREFIX - Exclude repositories with names starting with specified prefix(es). Comma-separated list supported")
@@ -462,20 +551,33 @@ func init() {
 	cloneCmd.Flags().BoolVar(&gitlabIncludeSharedProjects, "gitlab-include-shared-projects", true, "GHORG_GITLAB_INCLUDE_SHARED_PROJECTS - GitLab only: Include projects shared with the group, not just those owned by it. Set to false to skip shared projects (default: true)")
 	cloneCmd.Flags().StringVarP(&ghorgIgnorePath, "ghorgignore-path", "", "", "GHORG_IGNORE_PATH - Custom path to ghorgignore file (similar to .gitignore but for repos). Default: $HOME/.config/ghorg/ghorgignore")
 	cloneCmd.Flags().StringVarP(&ghorgOnlyPath, "ghorgonly-path", "", "", "GHORG_ONLY_PATH - Custom path to ghorgonly file (whitelist of repos to clone). Default: $HOME/.config/ghorg/ghorgonly")
+	cloneCmd.Flags().StringVarP(&ghorgFileSyntax, "filter-file-syntax", "", "", "GHORG_FILTER_FILE_SYNTAX - Syntax of the ghorgignore and ghorgonly files, substring matches each line against clone urls and gitignore matches gitignore style patterns against repo paths (substring or gitignore). Default: gitignore for files starting with \"# ghorg: gitignore\", substring otherwise")
 	cloneCmd.Flags().StringVarP(&exitCodeOnCloneInfos, "exit-code-on-clone-infos", "", "", "GHORG_EXIT_CODE_ON_CLONE_INFOS - Exit code when informational messages occur during cloning (non-critical issues). Useful for CI/CD pipelines (default: 0)")
 	cloneCmd.Flags().StringVarP(&exitCodeOnCloneIssues, "exit-code-on-clone-issues", "", "", "GHORG_EXIT_CODE_ON_CLONE_ISSUES - Exit code when issues/errors occur during cloning. Useful for CI/CD failure detection (default: 1)")
 	cloneCmd.Flags().StringVarP(&gitFilter, "git-filter", "", "", "GHORG_GIT_FILTER - Arguments to pass to git's --filter flag. Use --git-filter=blob:none to exclude binary objects and reduce clone size. Requires git 2.19+")
+	cloneCmd.Flags().StringVarP(&sparsePaths, "sparse-paths", "", "", "GHORG_SPARSE_PATHS - Comma-separated directories to check out with a cone mode sparse checkout, files at the root of each repo are always checked out (e.g., --sparse-paths=services/api,libs). Combine with --git-filter=blob:none so only their files are downloaded. Requires git 2.27+")
+	cloneCmd.Flags().StringVarP(&sparsePathsFile, "sparse-paths-file", "", "", "GHORG_SPARSE_PATHS_FILE - Path to a yaml file mapping repo names or paths (e.g., org/repo) to their own list of sparse paths, overriding --sparse-paths. Map a repo to [] to check out all of it")
+	cloneCmd.Flags().BoolVar(&referenceStore, "reference-store", false, "GHORG_REFERENCE_STORE - Keep a bare cache repo per upstream and clone new repos with git clone --reference to it, forks of the same upstream share one so only the objects they don't have in common are downloaded and stored. Prune and maintenance never remove objects from the cache")
+	cloneCmd.Flags().StringVarP(&referenceStorePath, "reference-store-path", "", "", "GHORG_REFERENCE_STORE_PATH - Directory of the cache repos used by --reference-store, clones depend on it so it must not be moved or deleted. Default: $HOME/.local/share/ghorg/reference-store")
+	cloneCmd.Flags().BoolVar(&referenceStoreDissociate, "reference-store-dissociate", false, "GHORG_REFERENCE_STORE_DISSOCIATE - Clone with --dissociate, the objects borrowed from the reference store are copied into each new clone so it doesn't depend on the store. Saves bandwidth but not disk")
+	cloneCmd.Flags().StringVarP(&worktreeBranches, "worktree-branches", "", "", "GHORG_WORKTREE_BRANCHES - Comma-separated branch patterns to check out side by side as git worktrees sharing each clone's object store (e.g., --worktree-branches=main,release/*). Worktrees are kept in <repo>.worktrees/<branch>, updated on every clone and removed when their branch is deleted")
 	cloneCmd.Flags().BoolVarP(&githubTokenFromGithubApp, "github-token-from-github-app", "", false, "GHORG_GITHUB_TOKEN_FROM_GITHUB_APP - GitHub only: Treat the provided token as a GitHub App token (when obtained outside ghorg). Use with pre-generated app tokens")
 	cloneCmd.Flags().BoolVarP(&githubUserGists, "github-user-gists", "", false, "GHORG_GITHUB_USER_GISTS - GitHub only: Clone all of a user's gists into clone-dir/ghorg-gists. Requires --clone-type=user and --scm=github")
+	cloneCmd.Flags().StringVarP(&gistVisibility, "gist-visibility", "", "", "GHORG_GIST_VISIBILITY - GitHub only: Only clone public or secret gists (all, public or secret). Use with --github-user-gists")
+	cloneCmd.Flags().StringVarP(&gistDescriptionRegex, "gist-description-regex", "", "", "GHORG_GIST_DESCRIPTION_REGEX - GitHub only: Only clone gists whose description matches the regex. Use with --github-user-gists")
+	cloneCmd.Flags().StringVarP(&gistFileExtensions, "gist-file-extensions", "", "", "GHORG_GIST_FILE_EXTENSIONS - GitHub only: Only clone gists containing a file with one of these extensions. Comma-separated values (e.g., --gist-file-extensions=sh,py). Use with --github-user-gists")
+	cloneCmd.Flags().StringVarP(&gistUpdatedSince, "gist-updated-since", "", "", "GHORG_GIST_UPDATED_SINCE - GitHub only: Only clone gists updated on or after this date (e.g., --gist-updated-since=2024-01-31) or RFC 3339 timestamp. Use with --github-user-gists")
+	cloneCmd.Flags().StringVarP(&gistFolderName, "gist-folder-name", "", "", "GHORG_GIST_FOLDER_NAME - GitHub only: Name gist folders after their first file (filename, default) or their description (description), gists without a description fall back to the filename. Use with --github-user-gists")
 	cloneCmd.Flags().StringVarP(&githubAppPemPath, "github-app-pem-path", "", "", "GHORG_GITHUB_APP_PEM_PATH - GitHub only: Path to GitHub App private key (.pem file) for app-based authentication. Requires --github-app-id and --github-app-installation-id")
 	cloneCmd.Flags().StringVarP(&githubAppInstallationID, "github-app-installation-id", "", "", "GHORG_GITHUB_APP_INSTALLATION_ID - GitHub only: Installation ID for GitHub App authentication. Find in org settings URL")
-	cloneCmd.Flags().StringVarP(&githubFilterLanguage, "github-filter-language", "", "", "GHORG_GITHUB_FILTER_LANGUAGE - GitHub only: Filter repositories by programming language. Comma-separated values (e.g., --github-filter-language=go,python)")
+	cloneCmd.Flags().StringVarP(&githubFilterLanguage, "github-filter-language", "", "", "GHORG_GITHUB_FILTER_LANGUAGE - Deprecated, use --filter-language which works on every scm")
 	cloneCmd.Flags().StringVarP(&githubRepoListConcurrency, "github-repo-list-concurrency", "", "", "GHORG_GITHUB_REPO_LIST_CONCURRENCY - GitHub only: Max concurrent REST requests when listing org/user repos (API pagination). If unset, all pages are fetched in parallel (fastest; may hit GitHub secondary rate limits on huge orgs). Set to a lower number (e.g. 8 or 1) to throttle listing. Separate from GHORG_CONCURRENCY (git clones only).")
 	cloneCmd.Flags().StringVarP(&githubUserOption, "github-user-option", "", "", "GHORG_GITHUB_USER_OPTION - GitHub only: When using --clone-type=user, specify which repos to include: 'all', 'owner' (created by user), or 'member' (contributed to). (default: owner)")
 	cloneCmd.Flags().StringVarP(&githubAppID, "github-app-id", "", "", "GHORG_GITHUB_APP_ID - GitHub only: GitHub App ID for app-based authentication. Required with --github-app-pem-path")
 	cloneCmd.Flags().StringVar(&sshHostname, "ssh-hostname", "", "GHORG_SSH_HOSTNAME - Custom hostname to use in SSH clone URLs. Useful for SSH aliases in ~/.ssh/config (e.g., --ssh-hostname=my-github-alias creates git@my-github-alias:org/repo.git URLs)")
 
 	reCloneCmd.Flags().StringVarP(&ghorgReClonePath, "reclone-path", "", "", "GHORG_RECLONE_PATH - If you want to set a path other than $HOME/.config/ghorg/reclone.yaml for your reclone configuration")
+	reCloneCmd.Flags().StringVarP(&ghorgReCloneDir, "reclone-dir", "", "", "GHORG_RECLONE_DIR - Directory of *.yaml fragments merged into your reclone configuration (default: reclone.d next to your reclone.yaml)")
 	reCloneCmd.Flags().StringVar(&sshHostname, "ssh-hostname", "", "GHORG_SSH_HOSTNAME - Custom hostname to use in SSH clone URLs. Useful for SSH aliases in ~/.ssh/config (e.g., --ssh-hostname=my-github-alias creates git@my-github-alias:org/repo.git URLs)")
 	reCloneCmd.Flags().BoolVar(&ghorgReCloneQuiet, "quiet", false, "GHORG_RECLONE_QUIET - Quiet logging output")
 	reCloneCmd.Flags().BoolVar(&ghorgReCloneList, "list", false, "Prints reclone commands and optional descriptions to stdout then will exit 0. Does not obsfucate tokens, and is only available as a commandline argument")
@@ -483,12 +585,31 @@ func init() {
 
 	lsCmd.Flags().BoolP("long", "l", false, "Display detailed information about each clone directory, including size and number of repositories. Note: This may take longer depending on the number and size of the cloned organizations.")
 	lsCmd.Flags().BoolP("total", "t", false, "Display total amounts of all repos cloned. Note: This may take longer depending on the number and size of the cloned organizations.")
+	lsCmd.Flags().String("sort", "", "Sort by path, name, provider, branch, dirty, repos, size, last_sync or last_commit, prefix with - to sort in descending order (e.g. --sort=-size)")
+	lsCmd.Flags().String("filter", "", "Only list rows matching an expression over path, name, provider, branch, dirty, repos, size, last_sync and last_commit (e.g. --filter 'dirty || last_commit < now-1y')")
+	lsCmd.Flags().String("output", "text", "Output format, one of text, json or csv. json and csv include every column and are written to stdout with all other output on stderr")
+
+	duCmd.Flags().Int("top", 20, "Number of the largest repos to list, 0 lists every repo")
+	duCmd.Flags().StringSlice("apply", nil, "Reductions to apply to the repos they are suggested for, any of gc, lfs, partial or shallow (e.g. --apply=gc,lfs)")
+	duCmd.Flags().String("output", "text", "Output format, one of text or json. json is written to stdout with all other output on stderr")
 
 	recloneCronCmd.Flags().StringVarP(&cronTimerMinutes, "minutes", "m", "", "GHORG_CRON_TIMER_MINUTES - Number of minutes to run the reclone command on a cron")
+	recloneCronCmd.Flags().StringVar(&cronMaintainMinutes, "maintain-minutes", "", "GHORG_CRON_MAINTAIN_MINUTES - Number of minutes to run the maintain command on a cron, it never runs at the same time as a reclone. Not run when unset")
+
+	maintainCmd.Flags().StringVar(&maintainTasks, "tasks", "", "GHORG_MAINTAIN_TASKS - Comma separated maintenance tasks to run on each repo, any of gc, repack, commit-graph, pack-refs, loose-objects, incremental-repack and fsck (default: gc,fsck)")
+	maintainCmd.Flags().StringVar(&concurrency, "concurrency", "", "GHORG_CONCURRENCY - Maximum number of repos maintained at once (default: 25)")
+	maintainCmd.Flags().BoolVar(&ghorgStatsEnabled, "stats-enabled", false, "GHORG_STATS_ENABLED - Record each maintenance run in _ghorg_stats.csv")
+
+	bundleCmd.Flags().String("output", "", "Directory to write the bundles and their manifest.json to (default: <dir>-bundle-<timestamp> in the current directory)")
+	bundleCmd.Flags().Bool("incremental", false, "Only bundle the objects added since the last ghorg bundle of the clone directory")
+	bundleCmd.Flags().StringVar(&concurrency, "concurrency", "", "GHORG_CONCURRENCY - Maximum number of repos bundled at once (default: 25)")
+
+	unbundleCmd.Flags().String("output-dir", "", "Name of the clone directory in GHORG_ABSOLUTE_PATH_TO_CLONE_TO to import into (default: the name of the clone directory that was bundled)")
+	unbundleCmd.Flags().StringVar(&concurrency, "concurrency", "", "GHORG_CONCURRENCY - Maximum number of repos imported at once (default: 25)")
 
 	recloneServerCmd.Flags().StringVarP(&recloneServerPort, "port", "p", "", "GHORG_RECLONE_SERVER_PORT - Specifiy the port the reclone server will run on.")
 
-	rootCmd.AddCommand(lsCmd, versionCmd, cloneCmd, reCloneCmd, examplesCmd, recloneServerCmd, recloneCronCmd)
+	rootCmd.AddCommand(lsCmd, duCmd, maintainCmd, bundleCmd, unbundleCmd, versionCmd, cloneCmd, reCloneCmd, examplesCmd, recloneServerCmd, recloneCronCmd)
 }
 
 func Execute() {
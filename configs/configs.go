@@ -51,13 +51,19 @@ var (
 	ErrIncorrectScmType = errors.New("GHORG_SCM_TYPE or --scm must be one of " + strings.Join(scm.SupportedClients(), ", "))
 
 	// ErrIncorrectCloneType indicates an unsupported clone type being used
-	ErrIncorrectCloneType = errors.New("GHORG_CLONE_TYPE or --clone-type must be one of org or user")
+	ErrIncorrectCloneType = errors.New("GHORG_CLONE_TYPE or --clone-type must be one of org, user, memberships, starred or watched")
 
 	// ErrIncorrectProtocolType indicates an unsupported protocol type being used
 	ErrIncorrectProtocolType = errors.New("GHORG_CLONE_PROTOCOL or --protocol must be one of https or ssh")
 
 	// ErrIncorrectGithubUserOptionValue indicates an incorrectly set GHORG_GITHUB_USER_OPTION value
 	ErrIncorrectGithubUserOptionValue = errors.New("GHORG_GITHUB_USER_OPTION or --github-user-option must be one of 'owner', 'member', or 'all' and is only available to be used when GHORG_CLONE_TYPE: user or --clone-type=user is set")
+
+	// ErrUnsupportedReviewRefsScmType indicates review refs were requested for an scm without pull or merge requests refs
+	ErrUnsupportedReviewRefsScmType = errors.New("GHORG_FETCH_REVIEW_REFS or --fetch-review-refs is only supported for github, gitlab, gitea and codeberg")
+
+	// ErrUnsupportedBackupMetadataScmType indicates a metadata backup was requested for an scm that cannot export it
+	ErrUnsupportedBackupMetadataScmType = errors.New("GHORG_BACKUP_METADATA or --backup-metadata is only supported for github, gitlab, gitea and codeberg")
 )
 
 // Load triggers the configs to load first, not sure if this is actually needed
@@ -136,6 +142,29 @@ func GhorgReCloneLocation() string {
 	return filepath.Join(GhorgConfDir(), "reclone.yaml")
 }
 
+// GhorgReCloneDirLocation returns the path of the directory holding reclone.yaml fragments,
+// defaults to a reclone.d directory next to the reclone.yaml
+func GhorgReCloneDirLocation() string {
+	recloneDirLocation := os.Getenv("GHORG_RECLONE_DIR")
+	if recloneDirLocation != "" {
+		return recloneDirLocation
+	}
+
+	return filepath.Join(filepath.Dir(GhorgReCloneLocation()), "reclone.d")
+}
+
+// GhorgReferenceStoreLocation returns the directory of the bare repos clones borrow objects from with
+// GHORG_REFERENCE_STORE, defaults to reference-store in the ghorg data directory. Clones depend on it, so unlike
+// a cache it can't be deleted.
+func GhorgReferenceStoreLocation() string {
+	storeLocation := os.Getenv("GHORG_REFERENCE_STORE_PATH")
+	if storeLocation != "" {
+		return storeLocation
+	}
+
+	return filepath.Join(GhorgDataDir(), "reference-store")
+}
+
 // GhorgIgnoreDetected returns true if a ghorgignore file exists.
 func GhorgIgnoreDetected() bool {
 	_, err := os.Stat(GhorgIgnoreLocation())
@@ -164,6 +193,15 @@ func GhorgConfDir() string {
 	return filepath.Join(HomeDir(), ".config", "ghorg")
 }
 
+// GhorgDataDir returns the ghorg data directory, $XDG_DATA_HOME/ghorg or $HOME/.local/share/ghorg
+func GhorgDataDir() string {
+	if xdg := os.Getenv("XDG_DATA_HOME"); xdg != "" {
+		return filepath.Join(xdg, "ghorg")
+	}
+
+	return filepath.Join(HomeDir(), ".local", "share", "ghorg")
+}
+
 // XConfigHomeSet checks for XDG_CONFIG_HOME env set
 func XConfigHomeSet() bool {
 	return os.Getenv("XDG_CONFIG_HOME") != ""
@@ -536,7 +574,9 @@ func VerifyConfigsSetCorrectly() error {
 		return ErrIncorrectScmType
 	}
 
-	if cloneType != "user" && cloneType != "org" {
+	switch cloneType {
+	case "user", "org", "memberships", "starred", "watched":
+	default:
 		return ErrIncorrectCloneType
 	}
 
@@ -550,5 +590,13 @@ func VerifyConfigsSetCorrectly() error {
 		return ErrIncorrectProtocolType
 	}
 
+	if os.Getenv("GHORG_FETCH_REVIEW_REFS") == "true" && !utils.IsStringInSlice(scmType, []string{"github", "gitlab", "gitea", "codeberg"}) {
+		return ErrUnsupportedReviewRefsScmType
+	}
+
+	if os.Getenv("GHORG_BACKUP_METADATA") == "true" && !utils.IsStringInSlice(scmType, []string{"github", "gitlab", "gitea", "codeberg"}) {
+		return ErrUnsupportedBackupMetadataScmType
+	}
+
 	return nil
 }
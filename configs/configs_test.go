@@ -2,6 +2,7 @@ package configs_test
 
 import (
 	"os"
+	"path/filepath"
 	"runtime"
 	"testing"
 
@@ -145,6 +146,19 @@ func TestVerifyConfigsSetCorrectly(t *testing.T) {
 
 	})
 
+	t.Run("When memberships clone type", func(tt *testing.T) {
+		_ = os.Setenv("GHORG_SCM_TYPE", "github")
+		_ = os.Setenv("GHORG_CLONE_PROTOCOL", "ssh")
+
+		_ = os.Setenv("GHORG_CLONE_TYPE", "memberships")
+
+		err := configs.VerifyConfigsSetCorrectly()
+		if err == configs.ErrIncorrectCloneType {
+			tt.Errorf("Expected memberships to be a supported clone type, got: %v", err)
+		}
+
+	})
+
 	t.Run("When unsupported protocol", func(tt *testing.T) {
 		_ = os.Setenv("GHORG_SCM_TYPE", "github")
 		_ = os.Setenv("GHORG_CLONE_TYPE", "org")
@@ -157,6 +171,21 @@ func TestVerifyConfigsSetCorrectly(t *testing.T) {
 		}
 
 	})
+
+	t.Run("When review refs with unsupported scm", func(tt *testing.T) {
+		_ = os.Setenv("GHORG_SCM_TYPE", "bitbucket")
+		_ = os.Setenv("GHORG_CLONE_TYPE", "org")
+		_ = os.Setenv("GHORG_CLONE_PROTOCOL", "ssh")
+
+		_ = os.Setenv("GHORG_FETCH_REVIEW_REFS", "true")
+		defer func() { _ = os.Unsetenv("GHORG_FETCH_REVIEW_REFS") }()
+
+		err := configs.VerifyConfigsSetCorrectly()
+		if err != configs.ErrUnsupportedReviewRefsScmType {
+			tt.Errorf("Expected ErrUnsupportedReviewRefsScmType, got: %v", err)
+		}
+
+	})
 }
 
 func TestTrailingSlashes(t *testing.T) {
@@ -189,3 +218,18 @@ func TestTrailingSlashes(t *testing.T) {
 		}
 	})
 }
+
+func TestGhorgReferenceStoreLocation(t *testing.T) {
+	t.Setenv("GHORG_REFERENCE_STORE_PATH", "")
+	data := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", data)
+
+	if got := configs.GhorgReferenceStoreLocation(); got != filepath.Join(data, "ghorg", "reference-store") {
+		t.Errorf("expected the store in the ghorg data directory, got %s", got)
+	}
+
+	t.Setenv("GHORG_REFERENCE_STORE_PATH", "/mnt/store")
+	if got := configs.GhorgReferenceStoreLocation(); got != "/mnt/store" {
+		t.Errorf("expected GHORG_REFERENCE_STORE_PATH to be used, got %s", got)
+	}
+}
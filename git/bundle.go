@@ -0,0 +1,191 @@
+package git
+
+import (
+	"fmt"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gabrie30/ghorg/scm"
+)
+
+// Bundler exports git repos into bundle files and imports them again, it's used by ghorg bundle and ghorg
+// unbundle to move clones into networks that can't reach the scm
+type Bundler interface {
+	Refs(scm.Repo) (map[string]string, error)
+	HeadBranch(scm.Repo) (string, error)
+	HasObject(repo scm.Repo, id string) bool
+	CountBundleObjects(repo scm.Repo, exclude []string) (int, error)
+	CreateBundle(repo scm.Repo, path string, exclude []string) error
+	InitBundleRepo(repo scm.Repo, remoteURL string) error
+	UnbundleObjects(repo scm.Repo, path string) error
+	UpdateRefs(repo scm.Repo, refs map[string]string, old map[string]string, deleted []string) error
+	CountCommitsNotIn(repo scm.Repo, ids []string, in []string) (int, error)
+	IsValidRefName(ref string) bool
+	SetHead(repo scm.Repo, branch string) error
+	ResetWorktree(scm.Repo) error
+	GetRemoteURL(scm.Repo) (string, error)
+	ShortStatus(scm.Repo) (string, error)
+	IsShallowClone(scm.Repo) bool
+}
+
+// Refs returns every ref of the repo mapped to the object it points to, HEAD is left out
+func (g GitClient) Refs(repo scm.Repo) (map[string]string, error) {
+	output, err := runGitIn(repo, "for-each-ref", "--format=%(objectname) %(refname)")
+	if err != nil {
+		return nil, err
+	}
+
+	refs := map[string]string{}
+	for _, line := range strings.Split(string(output), "\n") {
+		if id, ref, ok := strings.Cut(strings.TrimSpace(line), " "); ok {
+			refs[ref] = id
+		}
+	}
+	return refs, nil
+}
+
+// HeadBranch returns the branch checked out in the repo, an empty string when HEAD is detached
+func (g GitClient) HeadBranch(repo scm.Repo) (string, error) {
+	output, err := exec.Command("git", "-C", repo.HostPath, "symbolic-ref", "-q", "--short", "HEAD").Output()
+	if err != nil {
+		// symbolic-ref -q exits 1 without printing anything when HEAD is detached
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+			return "", nil
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// HasObject reports whether the object with the given id is in the repo
+func (g GitClient) HasObject(repo scm.Repo, id string) bool {
+	return exec.Command("git", "-C", repo.HostPath, "cat-file", "-e", id).Run() == nil
+}
+
+// CountBundleObjects counts the objects a bundle of every ref would hold when the objects reachable from exclude
+// are left out, a bundle can't be created when there are none
+func (g GitClient) CountBundleObjects(repo scm.Repo, exclude []string) (int, error) {
+	args := append([]string{"rev-list", "--count", "--objects", "--all"}, excludeArgs(exclude)...)
+	output, err := runGitIn(repo, args...)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(output)))
+}
+
+// CreateBundle writes every ref of the repo into a bundle file at path. Objects reachable from exclude are left
+// out, the repo the bundle is imported into must already have them. Refs that point at an excluded object are
+// not in the bundle either.
+func (g GitClient) CreateBundle(repo scm.Repo, path string, exclude []string) error {
+	args := append([]string{"bundle", "create", path, "--all"}, excludeArgs(exclude)...)
+	_, err := runGitIn(repo, args...)
+	return err
+}
+
+func excludeArgs(exclude []string) []string {
+	args := []string{}
+	for _, id := range exclude {
+		args = append(args, "^"+id)
+	}
+	return args
+}
+
+// InitBundleRepo creates an empty repo to import bundles into, with origin set to remoteURL unless it's empty
+func (g GitClient) InitBundleRepo(repo scm.Repo, remoteURL string) error {
+	output, err := exec.Command("git", "init", "--quiet", repo.HostPath).CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(output)); msg != "" {
+			return fmt.Errorf("%v: %s", err, msg)
+		}
+		return err
+	}
+
+	if remoteURL == "" {
+		return nil
+	}
+	_, err = runGitIn(repo, "remote", "add", "origin", remoteURL)
+	return err
+}
+
+// UnbundleObjects stores the objects of a bundle in the repo without updating any ref, git checks the repo
+// has every commit the bundle was created without first
+func (g GitClient) UnbundleObjects(repo scm.Repo, path string) error {
+	_, err := runGitIn(repo, "bundle", "unbundle", path)
+	return err
+}
+
+// UpdateRefs points refs at their objects and deletes the deleted refs in a single transaction, either every
+// ref is updated or none is. Refs in old are only changed while they still point to the id it has for them.
+func (g GitClient) UpdateRefs(repo scm.Repo, refs map[string]string, old map[string]string, deleted []string) error {
+	names := make([]string, 0, len(refs))
+	for ref := range refs {
+		names = append(names, ref)
+	}
+	sort.Strings(names)
+
+	var stdin strings.Builder
+	// update-ref --stdin takes the old value as an optional last field
+	for _, ref := range names {
+		fmt.Fprintln(&stdin, strings.TrimSpace(fmt.Sprintf("update %s %s %s", ref, refs[ref], old[ref])))
+	}
+	for _, ref := range deleted {
+		fmt.Fprintln(&stdin, strings.TrimSpace(fmt.Sprintf("delete %s %s", ref, old[ref])))
+	}
+
+	_, err := runGitWithStdin(repo, stdin.String(), "update-ref", "--stdin")
+	return err
+}
+
+// CountCommitsNotIn counts the commits reachable from ids that are not reachable from in
+func (g GitClient) CountCommitsNotIn(repo scm.Repo, ids []string, in []string) (int, error) {
+	// the ids are passed on stdin since repos can have more refs than fit on a command line
+	var stdin strings.Builder
+	for _, id := range ids {
+		fmt.Fprintln(&stdin, id)
+	}
+	for _, id := range in {
+		fmt.Fprintln(&stdin, "^"+id)
+	}
+
+	output, err := runGitWithStdin(repo, stdin.String(), "rev-list", "--count", "--stdin")
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(output)))
+}
+
+// IsValidRefName reports whether git accepts ref as the name of a ref
+func (g GitClient) IsValidRefName(ref string) bool {
+	return exec.Command("git", "check-ref-format", ref).Run() == nil
+}
+
+// runGitWithStdin runs a git command in the repo reading stdin, it's not printed with GHORG_DEBUG since
+// printDebugCmd runs the command a second time without it
+func runGitWithStdin(repo scm.Repo, stdin string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = repo.HostPath
+	cmd.Stdin = strings.NewReader(stdin)
+
+	output, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			return nil, fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, err
+	}
+	return output, nil
+}
+
+// SetHead checks out branch without touching the worktree, ResetWorktree brings the worktree in line with it
+func (g GitClient) SetHead(repo scm.Repo, branch string) error {
+	_, err := runGitIn(repo, "symbolic-ref", "HEAD", "refs/heads/"+branch)
+	return err
+}
+
+// ResetWorktree discards the index and worktree changes so they match HEAD
+func (g GitClient) ResetWorktree(repo scm.Repo) error {
+	_, err := runGitIn(repo, "reset", "--quiet", "--hard")
+	return err
+}
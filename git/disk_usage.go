@@ -0,0 +1,220 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gabrie30/ghorg/scm"
+)
+
+// SpaceReclaimer inspects how a git clone uses disk and reduces it, it's used by ghorg du
+type SpaceReclaimer interface {
+	UntrackedPaths(scm.Repo) ([]string, error)
+	CountObjects(scm.Repo) (ObjectCounts, error)
+	IsPartialClone(scm.Repo) (bool, error)
+	IsShallowClone(scm.Repo) bool
+	LocalOnlyCommitCount(scm.Repo) (int, error)
+	LocalOnlyRefs(scm.Repo) ([]string, error)
+	ShortStatus(scm.Repo) (string, error)
+	Gc(scm.Repo) error
+	LfsPrune(scm.Repo) error
+	ConvertToPartialClone(scm.Repo) error
+	ConvertToShallowClone(scm.Repo) error
+	GetRemoteURL(scm.Repo) (string, error)
+	SetOrigin(scm.Repo) error
+	SetOriginWithCredentials(scm.Repo) error
+}
+
+// ObjectCounts is the output of git count-objects -v, sizes are in bytes
+type ObjectCounts struct {
+	LooseObjects int
+	LooseSize    int64
+	Packs        int
+	PackSize     int64
+	Garbage      int
+}
+
+func runGitIn(repo scm.Repo, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = repo.HostPath
+
+	if os.Getenv("GHORG_DEBUG") != "" {
+		if err := printDebugCmd(cmd, repo); err != nil {
+			return nil, err
+		}
+	}
+
+	output, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			return nil, fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, err
+	}
+	return output, nil
+}
+
+// UntrackedPaths returns the untracked and ignored paths of the worktree relative to the repo, directories
+// that are entirely untracked are returned once with a trailing slash
+func (g GitClient) UntrackedPaths(repo scm.Repo) ([]string, error) {
+	output, err := runGitIn(repo, "status", "--porcelain", "-z", "--ignored=matching", "--untracked-files=normal")
+	if err != nil {
+		return nil, err
+	}
+
+	paths := []string{}
+	for _, entry := range bytes.Split(output, []byte{0}) {
+		line := string(entry)
+		if strings.HasPrefix(line, "?? ") || strings.HasPrefix(line, "!! ") {
+			paths = append(paths, line[3:])
+		}
+	}
+	return paths, nil
+}
+
+// CountObjects reports the loose objects and packs in the object store
+func (g GitClient) CountObjects(repo scm.Repo) (ObjectCounts, error) {
+	output, err := runGitIn(repo, "count-objects", "-v")
+	if err != nil {
+		return ObjectCounts{}, err
+	}
+	return parseCountObjects(string(output)), nil
+}
+
+// parseCountObjects parses git count-objects -v, which reports sizes in KiB
+func parseCountObjects(output string) ObjectCounts {
+	counts := ObjectCounts{}
+	for _, line := range strings.Split(output, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			continue
+		}
+		switch key {
+		case "count":
+			counts.LooseObjects = int(n)
+		case "size":
+			counts.LooseSize = n * 1024
+		case "packs":
+			counts.Packs = int(n)
+		case "size-pack":
+			counts.PackSize = n * 1024
+		case "garbage":
+			counts.Garbage = int(n)
+		}
+	}
+	return counts
+}
+
+// IsPartialClone reports whether origin is a promisor remote, meaning objects left out by a filter are fetched on demand
+func (g GitClient) IsPartialClone(repo scm.Repo) (bool, error) {
+	output, err := exec.Command("git", "-C", repo.HostPath, "config", "--get", "remote.origin.promisor").Output()
+	if err != nil {
+		// git config exits 1 when the key is not set
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+			return false, nil
+		}
+		return false, err
+	}
+	return strings.TrimSpace(string(output)) == "true", nil
+}
+
+// IsShallowClone reports whether the repo has a shallow history
+func (g GitClient) IsShallowClone(repo scm.Repo) bool {
+	_, err := os.Stat(filepath.Join(repo.HostPath, ".git", "shallow"))
+	return err == nil
+}
+
+// LocalOnlyCommitCount counts the commits on local branches that are not on any remote branch
+func (g GitClient) LocalOnlyCommitCount(repo scm.Repo) (int, error) {
+	output, err := runGitIn(repo, "rev-list", "--count", "--branches", "--not", "--remotes")
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(output)))
+}
+
+// LocalOnlyRefs returns the refs other than branches, e.g. refs/stash or tags, that point to commits that are
+// not on any remote branch. Objects only they reference are dropped by a shallow or partial conversion, as are
+// the older stashes kept in the reflog of refs/stash.
+func (g GitClient) LocalOnlyRefs(repo scm.Repo) ([]string, error) {
+	// a single rev-list finds the common case of no such commits without checking every tag
+	output, err := runGitIn(repo, "rev-list", "--count", "--exclude=refs/heads/*", "--exclude=refs/remotes/*", "--all", "--not", "--remotes")
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(string(output)) == "0" {
+		return nil, nil
+	}
+
+	output, err = runGitIn(repo, "for-each-ref", "--format=%(refname)")
+	if err != nil {
+		return nil, err
+	}
+	candidates := []string{"HEAD"}
+	for _, ref := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if ref != "" && !strings.HasPrefix(ref, "refs/heads/") && !strings.HasPrefix(ref, "refs/remotes/") {
+			candidates = append(candidates, ref)
+		}
+	}
+
+	refs := []string{}
+	for _, ref := range candidates {
+		output, err := runGitIn(repo, "rev-list", "--count", ref, "--not", "--remotes")
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(string(output)) != "0" {
+			refs = append(refs, ref)
+		}
+	}
+	return refs, nil
+}
+
+// Gc packs loose objects and removes unreachable ones
+func (g GitClient) Gc(repo scm.Repo) error {
+	return g.Maintain(repo, "gc")
+}
+
+// LfsPrune deletes local LFS files that are not referenced by the checkout or recent commits
+func (g GitClient) LfsPrune(repo scm.Repo) error {
+	_, err := runGitIn(repo, "lfs", "prune")
+	return err
+}
+
+// ConvertToPartialClone makes origin a promisor remote filtering out blobs and repacks without them, blobs
+// are downloaded again when they are needed. Blobs only stored locally would be lost, so callers must make
+// sure every commit has been pushed. Requires git 2.43+.
+func (g GitClient) ConvertToPartialClone(repo scm.Repo) error {
+	if _, err := runGitIn(repo, "config", "remote.origin.promisor", "true"); err != nil {
+		return err
+	}
+	if _, err := runGitIn(repo, "config", "remote.origin.partialclonefilter", "blob:none"); err != nil {
+		return err
+	}
+	// -l leaves objects borrowed from alternates, like a reference store, out of the pack instead of copying them in
+	_, err := runGitIn(repo, "repack", "-a", "-d", "-l", "--filter=blob:none")
+	return err
+}
+
+// ConvertToShallowClone drops the history of the remote branches beyond their latest commit, history that
+// local branches still point to is kept. Commits only stored locally would be lost, so callers must make sure
+// every commit has been pushed. Origin needs its credentials set for private repos.
+func (g GitClient) ConvertToShallowClone(repo scm.Repo) error {
+	if _, err := runGitIn(repo, "fetch", "--depth=1", "origin"); err != nil {
+		return err
+	}
+	if _, err := runGitIn(repo, "reflog", "expire", "--expire=now", "--all"); err != nil {
+		return err
+	}
+	_, err := runGitIn(repo, "gc", "--prune=now", "--quiet")
+	return err
+}
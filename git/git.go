@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -23,6 +25,7 @@ type Gitter interface {
 	Checkout(scm.Repo) error
 	CheckoutBranch(scm.Repo, string) error
 	GetCurrentBranch(scm.Repo) (string, error)
+	GetRemoteURL(scm.Repo) (string, error)
 	RevListCompare(scm.Repo, string, string) (string, error)
 	ShortStatus(scm.Repo) (string, error)
 	Branch(scm.Repo) (string, error)
@@ -32,6 +35,9 @@ type Gitter interface {
 	RepoCommitCount(scm.Repo) (int, error)
 	HasRemoteHeads(scm.Repo) (bool, error)
 	LfsFetchAll(scm.Repo) error
+	LastCommitDate(scm.Repo) (time.Time, error)
+	Maintain(scm.Repo, string) error
+	SparseCheckout(scm.Repo) error
 }
 
 type GitClient struct{}
@@ -57,9 +63,17 @@ func printDebugCmd(cmd *exec.Cmd, repo scm.Repo) error {
 	return err
 }
 
+// HasRemoteHeads reports whether the remote has any branches. Before a repo is cloned
+// its clone url is checked instead, which is how empty wikis are found.
 func (g GitClient) HasRemoteHeads(repo scm.Repo) (bool, error) {
 	cmd := exec.Command("git", "ls-remote", "--heads", "--quiet", "--exit-code")
-	cmd.Dir = repo.HostPath
+	if _, err := os.Stat(repo.HostPath); err == nil {
+		cmd.Dir = repo.HostPath
+	} else {
+		cmd.Args = append(cmd.Args, repo.CloneURL)
+		// a missing repo can answer with an authentication challenge, fail instead of prompting
+		cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	}
 
 	err := cmd.Run()
 	if err == nil {
@@ -109,8 +123,15 @@ func cloneGitArgs(repo scm.Repo) []string {
 		args[index] = fmt.Sprintf("--filter=%v", os.Getenv("GHORG_GIT_FILTER"))
 	}
 
+	if repo.ReferencePath != "" {
+		args = append(args, "--reference="+repo.ReferencePath)
+	}
+
 	if os.Getenv("GHORG_BACKUP") == "true" {
 		args = append(args, "--mirror")
+	} else if len(repo.SparsePaths) > 0 {
+		// only the files at the root are checked out until the sparse paths are set
+		args = append(args, "--sparse")
 	}
 	return args
 }
@@ -123,7 +144,16 @@ func (g GitClient) Clone(repo scm.Repo) error {
 
 	if os.Getenv("GHORG_DEBUG") != "" {
 		cmd := exec.Command("git", args...)
-		return printDebugCmd(cmd, repo)
+		if err := printDebugCmd(cmd, repo); err != nil {
+			return err
+		}
+		if err := g.dissociateReference(repo); err != nil {
+			return err
+		}
+		if err := g.SparseCheckout(repo); err != nil {
+			return err
+		}
+		return g.fetchReviewRefs(repo)
 	}
 
 	maxAttempts := 1 + len(cloneRetryDelays)
@@ -136,12 +166,246 @@ func (g GitClient) Clone(repo scm.Repo) error {
 		cmd := exec.Command("git", args...)
 		lastErr = cmd.Run()
 		if lastErr == nil {
-			return nil
+			if err := g.dissociateReference(repo); err != nil {
+				return fmt.Errorf("could not dissociate from the reference store: %w", err)
+			}
+			if err := g.SparseCheckout(repo); err != nil {
+				return fmt.Errorf("could not set sparse paths: %w", err)
+			}
+			return g.fetchReviewRefs(repo)
 		}
 	}
 	return lastErr
 }
 
+// reviewRefspecs returns the fetch refspecs that map the pull or merge request refs
+// of GHORG_SCM_TYPE into refs/remotes/origin so they can be checked out offline
+func reviewRefspecs() []string {
+	switch os.Getenv("GHORG_SCM_TYPE") {
+	case "github":
+		return []string{
+			"+refs/pull/*/head:refs/remotes/origin/pr/*/head",
+			"+refs/pull/*/merge:refs/remotes/origin/pr/*/merge",
+		}
+	case "gitlab":
+		return []string{
+			"+refs/merge-requests/*/head:refs/remotes/origin/mr/*/head",
+			"+refs/merge-requests/*/merge:refs/remotes/origin/mr/*/merge",
+		}
+	case "gitea", "codeberg":
+		return []string{"+refs/pull/*/head:refs/remotes/origin/pr/*/head"}
+	}
+	return nil
+}
+
+// shouldFetchReviewRefs reports whether review refs should be fetched for repo. Mirror
+// clones already fetch every ref, and wikis, snippets and gists have no review refs.
+func shouldFetchReviewRefs(repo scm.Repo) bool {
+	if os.Getenv("GHORG_FETCH_REVIEW_REFS") != "true" || os.Getenv("GHORG_BACKUP") == "true" {
+		return false
+	}
+	return !repo.IsWiki && !repo.IsGitLabSnippet && !repo.IsGitHubGist
+}
+
+// fetchesAllReviewRefs reports whether the refs of every review request are fetched through the origin
+// refspecs. Otherwise only the refs of open requests are fetched with FetchReviewRequestRefs once they're listed.
+func fetchesAllReviewRefs() bool {
+	return os.Getenv("GHORG_REVIEW_REFS_INCLUDE_CLOSED") == "true"
+}
+
+// configureReviewRefspecs adds any missing review refspecs to the origin remote so every later fetch updates them
+func (g GitClient) configureReviewRefspecs(repo scm.Repo) error {
+	cmd := exec.Command("git", "config", "--get-all", "remote.origin.fetch")
+	cmd.Dir = repo.HostPath
+	// exits 1 when no refspecs are configured, which is fine
+	out, _ := cmd.Output()
+	existing := strings.Split(strings.TrimSpace(string(out)), "\n")
+
+	for _, refspec := range reviewRefspecs() {
+		if slices.Contains(existing, refspec) {
+			continue
+		}
+		cmd := exec.Command("git", "config", "--add", "remote.origin.fetch", refspec)
+		cmd.Dir = repo.HostPath
+		if os.Getenv("GHORG_DEBUG") != "" {
+			if err := printDebugCmd(cmd, repo); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := cmd.Run(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fetchReviewRefs configures and fetches the review refs of a freshly cloned repo
+func (g GitClient) fetchReviewRefs(repo scm.Repo) error {
+	if !shouldFetchReviewRefs(repo) || !fetchesAllReviewRefs() {
+		return nil
+	}
+
+	if err := g.configureReviewRefspecs(repo); err != nil {
+		return fmt.Errorf("could not configure review refspecs: %w", err)
+	}
+
+	args := []string{"fetch", "origin"}
+	if os.Getenv("GHORG_CLONE_DEPTH") != "" {
+		args = append(args, fmt.Sprintf("--depth=%v", os.Getenv("GHORG_CLONE_DEPTH")))
+	}
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = repo.HostPath
+	if os.Getenv("GHORG_DEBUG") != "" {
+		return printDebugCmd(cmd, repo)
+	}
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("could not fetch review refs: %w", err)
+	}
+	return nil
+}
+
+// ReviewRefFetcher fetches the refs of individual pull or merge requests, it's used by --fetch-review-refs
+// when closed requests are left out so their refs aren't fetched either
+type ReviewRefFetcher interface {
+	FetchReviewRequestRefs(repo scm.Repo, numbers []int64) error
+}
+
+// FetchReviewRequestRefs fetches the review refs of the given requests of repo. Refs that don't exist on the
+// remote, like the merge ref of a conflicting pull request, are skipped. The refspecs that fetch every request
+// are removed from origin first so later fetches don't bring closed ones back, and the local refs of requests
+// that are no longer given are deleted. Origin needs its credentials set for private repos.
+func (g GitClient) FetchReviewRequestRefs(repo scm.Repo, numbers []int64) error {
+	if !shouldFetchReviewRefs(repo) {
+		return nil
+	}
+
+	if err := g.removeReviewRefspecs(repo); err != nil {
+		return fmt.Errorf("could not remove review refspecs: %w", err)
+	}
+
+	wanted := make(map[string]bool, len(numbers))
+	for _, number := range numbers {
+		wanted[strconv.FormatInt(number, 10)] = true
+	}
+
+	refspecs := reviewRefspecs()
+	if err := g.deleteStaleReviewRefs(repo, refspecs, wanted); err != nil {
+		return fmt.Errorf("could not delete the refs of closed review requests: %w", err)
+	}
+	if len(numbers) == 0 {
+		return nil
+	}
+
+	patterns := []string{}
+	for _, refspec := range refspecs {
+		patterns = append(patterns, reviewRefspecSource(refspec))
+	}
+	out, err := runGitIn(repo, append([]string{"ls-remote", "origin"}, patterns...)...)
+	if err != nil {
+		return fmt.Errorf("could not list review refs: %w", err)
+	}
+
+	args := []string{"fetch", "origin"}
+	if os.Getenv("GHORG_CLONE_DEPTH") != "" {
+		args = append(args, fmt.Sprintf("--depth=%v", os.Getenv("GHORG_CLONE_DEPTH")))
+	}
+	fetched := false
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		for _, refspec := range refspecs {
+			if number, ok := reviewRefNumber(reviewRefspecSource(refspec), fields[1]); ok && wanted[number] {
+				args = append(args, strings.ReplaceAll(refspec, "*", number))
+				fetched = true
+			}
+		}
+	}
+	if !fetched {
+		return nil
+	}
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = repo.HostPath
+	if os.Getenv("GHORG_DEBUG") != "" {
+		return printDebugCmd(cmd, repo)
+	}
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("could not fetch review refs: %w", err)
+	}
+	return nil
+}
+
+// deleteStaleReviewRefs deletes the local review refs of requests that aren't wanted anymore, like the
+// refs of a pull request fetched while it was open
+func (g GitClient) deleteStaleReviewRefs(repo scm.Repo, refspecs []string, wanted map[string]bool) error {
+	for _, refspec := range refspecs {
+		destination := reviewRefspecDestination(refspec)
+		prefix, _, _ := strings.Cut(destination, "*")
+		out, err := runGitIn(repo, "for-each-ref", "--format=%(refname)", prefix)
+		if err != nil {
+			return err
+		}
+		for _, ref := range strings.Fields(string(out)) {
+			number, ok := reviewRefNumber(destination, ref)
+			if !ok || wanted[number] {
+				continue
+			}
+			if _, err := runGitIn(repo, "update-ref", "-d", ref); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// removeReviewRefspecs removes the review refspecs configureReviewRefspecs added to the origin remote
+func (g GitClient) removeReviewRefspecs(repo scm.Repo) error {
+	cmd := exec.Command("git", "config", "--get-all", "remote.origin.fetch")
+	cmd.Dir = repo.HostPath
+	// exits 1 when no refspecs are configured, which is fine
+	out, _ := cmd.Output()
+	existing := strings.Split(strings.TrimSpace(string(out)), "\n")
+
+	for _, refspec := range reviewRefspecs() {
+		if !slices.Contains(existing, refspec) {
+			continue
+		}
+		if _, err := runGitIn(repo, "config", "--unset-all", "remote.origin.fetch", "^"+regexp.QuoteMeta(refspec)+"$"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reviewRefspecSource returns the remote side of a review refspec, like refs/pull/*/head
+func reviewRefspecSource(refspec string) string {
+	source, _, _ := strings.Cut(strings.TrimPrefix(refspec, "+"), ":")
+	return source
+}
+
+// reviewRefspecDestination returns the local side of a review refspec, like refs/remotes/origin/pr/*/head
+func reviewRefspecDestination(refspec string) string {
+	_, destination, _ := strings.Cut(refspec, ":")
+	return destination
+}
+
+// reviewRefNumber returns the request number of ref when it matches the review ref pattern source
+func reviewRefNumber(source string, ref string) (string, bool) {
+	prefix, suffix, _ := strings.Cut(source, "*")
+	if !strings.HasPrefix(ref, prefix) || !strings.HasSuffix(ref, suffix) || len(ref) <= len(prefix)+len(suffix) {
+		return "", false
+	}
+	number := ref[len(prefix) : len(ref)-len(suffix)]
+	if _, err := strconv.ParseInt(number, 10, 64); err != nil {
+		return "", false
+	}
+	return number, true
+}
+
 func (g GitClient) SetOriginWithCredentials(repo scm.Repo) error {
 	args := []string{"remote", "set-url", "origin", repo.CloneURL}
 	cmd := exec.Command("git", args...)
@@ -202,6 +466,27 @@ func (g GitClient) GetCurrentBranch(repo scm.Repo) (string, error) {
 	return strings.TrimSpace(string(output)), nil
 }
 
+// GetRemoteURL returns the url of the origin remote
+func (g GitClient) GetRemoteURL(repo scm.Repo) (string, error) {
+	cmd := exec.Command("git", "remote", "get-url", "origin")
+	cmd.Dir = repo.HostPath
+
+	if os.Getenv("GHORG_DEBUG") != "" {
+		if err := printDebugCmd(cmd, repo); err != nil {
+			return "", err
+		}
+		cmd = exec.Command("git", "remote", "get-url", "origin")
+		cmd.Dir = repo.HostPath
+	}
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
+
 func (g GitClient) Clean(repo scm.Repo) error {
 	cmd := exec.Command("git", "clean", "-f", "-d")
 	cmd.Dir = repo.HostPath
@@ -255,6 +540,12 @@ func (g GitClient) Reset(repo scm.Repo) error {
 }
 
 func (g GitClient) FetchAll(repo scm.Repo) error {
+	if shouldFetchReviewRefs(repo) && fetchesAllReviewRefs() {
+		if err := g.configureReviewRefspecs(repo); err != nil {
+			return fmt.Errorf("could not configure review refspecs: %w", err)
+		}
+	}
+
 	args := []string{"fetch", "--all"}
 
 	if os.Getenv("GHORG_CLONE_DEPTH") != "" {
@@ -378,3 +669,98 @@ func (g GitClient) LfsFetchAll(repo scm.Repo) error {
 
 	return cmd.Run()
 }
+
+// LastCommitDate returns the committer date of HEAD
+func (g GitClient) LastCommitDate(repo scm.Repo) (time.Time, error) {
+	cmd := exec.Command("git", "log", "-1", "--format=%cI")
+	cmd.Dir = repo.HostPath
+
+	if os.Getenv("GHORG_DEBUG") != "" {
+		if err := printDebugCmd(cmd, repo); err != nil {
+			return time.Time{}, err
+		}
+	}
+
+	output, err := cmd.Output()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Parse(time.RFC3339, strings.TrimSpace(string(output)))
+}
+
+// MaintenanceTasks are the tasks Maintain can run, fsck checks the integrity of the repo and the rest
+// keep the object store compact
+var MaintenanceTasks = []string{"gc", "repack", "commit-graph", "pack-refs", "loose-objects", "incremental-repack", "fsck"}
+
+// repack leaves out objects borrowed from a reference store with -l like gc does, without it they would be
+// copied into every clone sharing the store
+var maintenanceTaskArgs = map[string][]string{
+	"gc":                 {"gc", "--quiet"},
+	"repack":             {"repack", "-a", "-d", "-l", "--quiet"},
+	"commit-graph":       {"commit-graph", "write", "--reachable"},
+	"pack-refs":          {"pack-refs", "--all"},
+	"loose-objects":      {"maintenance", "run", "--task=loose-objects", "--quiet"},
+	"incremental-repack": {"maintenance", "run", "--task=incremental-repack", "--quiet"},
+	"fsck":               {"fsck", "--no-progress", "--no-dangling"},
+}
+
+// Maintain runs one of MaintenanceTasks, the error includes what git printed so integrity problems found by
+// fsck can be reported
+func (g GitClient) Maintain(repo scm.Repo, task string) error {
+	args, ok := maintenanceTaskArgs[task]
+	if !ok {
+		return fmt.Errorf("unknown maintenance task %q", task)
+	}
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = repo.HostPath
+
+	if os.Getenv("GHORG_DEBUG") != "" {
+		return printDebugCmd(cmd, repo)
+	}
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(output)); msg != "" {
+			return fmt.Errorf("%v: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
+
+// SparseCheckout limits the working copy to the cone mode directories in repo.SparsePaths, files at the root
+// of the repo are always checked out. Without sparse paths a sparse repo has every file checked out again. The
+// definition is stored in .git/info/sparse-checkout so pulls, resets and cleans keep to it.
+func (g GitClient) SparseCheckout(repo scm.Repo) error {
+	if os.Getenv("GHORG_BACKUP") == "true" {
+		return nil
+	}
+
+	args := append([]string{"sparse-checkout", "set", "--cone"}, repo.SparsePaths...)
+	if len(repo.SparsePaths) == 0 {
+		// git config exits 1 when the key is not set, which means the repo has every file checked out
+		output, _ := exec.Command("git", "-C", repo.HostPath, "config", "--bool", "core.sparseCheckout").Output()
+		if strings.TrimSpace(string(output)) != "true" {
+			return nil
+		}
+		args = []string{"sparse-checkout", "disable"}
+	}
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = repo.HostPath
+
+	if os.Getenv("GHORG_DEBUG") != "" {
+		return printDebugCmd(cmd, repo)
+	}
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(output)); msg != "" {
+			return fmt.Errorf("%v: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
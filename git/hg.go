@@ -0,0 +1,269 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gabrie30/ghorg/scm"
+)
+
+// hgEmptyRepoID is what hg identify prints for a repository without any changesets
+const hgEmptyRepoID = "000000000000"
+
+// HgClient implements Gitter for mercurial repositories
+type HgClient struct{}
+
+func NewHg() HgClient {
+	return HgClient{}
+}
+
+// hgCommand builds an hg command that runs inside the repo. HGPLAIN disables
+// user configuration that would change the output we parse.
+func hgCommand(repo scm.Repo, args ...string) *exec.Cmd {
+	cmd := exec.Command("hg", args...)
+	cmd.Dir = repo.HostPath
+	cmd.Env = append(os.Environ(), "HGPLAIN=1")
+	return cmd
+}
+
+func runHg(repo scm.Repo, args ...string) error {
+	cmd := hgCommand(repo, args...)
+	if os.Getenv("GHORG_DEBUG") != "" {
+		return printDebugCmd(cmd, repo)
+	}
+	return cmd.Run()
+}
+
+func outputHg(repo scm.Repo, args ...string) (string, error) {
+	cmd := hgCommand(repo, args...)
+	if os.Getenv("GHORG_DEBUG") != "" {
+		if err := printDebugCmd(cmd, repo); err != nil {
+			return "", err
+		}
+		cmd = hgCommand(repo, args...)
+	}
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
+
+func (h HgClient) Clone(repo scm.Repo) error {
+	args := []string{"clone", repo.CloneURL, repo.HostPath}
+
+	// backups only need the history, not a working copy
+	if os.Getenv("GHORG_BACKUP") == "true" {
+		args = append(args, "--noupdate")
+	}
+
+	cmd := exec.Command("hg", args...)
+	cmd.Env = append(os.Environ(), "HGPLAIN=1")
+	if os.Getenv("GHORG_DEBUG") != "" {
+		return printDebugCmd(cmd, repo)
+	}
+
+	maxAttempts := 1 + len(cloneRetryDelays)
+	var lastErr error
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		if attempt > 0 {
+			_ = os.RemoveAll(repo.HostPath)
+			time.Sleep(cloneRetryDelays[attempt-1])
+		}
+		cmd := exec.Command("hg", args...)
+		cmd.Env = append(os.Environ(), "HGPLAIN=1")
+		lastErr = cmd.Run()
+		if lastErr == nil {
+			return nil
+		}
+	}
+	return lastErr
+}
+
+func (h HgClient) SetOriginWithCredentials(repo scm.Repo) error {
+	return setHgDefaultPath(repo, repo.CloneURL)
+}
+
+func (h HgClient) SetOrigin(repo scm.Repo) error {
+	return setHgDefaultPath(repo, repo.URL)
+}
+
+// setHgDefaultPath points the default path of the repo, hg's equivalent of the origin remote, at url
+func setHgDefaultPath(repo scm.Repo, url string) error {
+	hgrc := filepath.Join(repo.HostPath, ".hg", "hgrc")
+	if os.Getenv("GHORG_DEBUG") != "" {
+		fmt.Printf("Setting default path of %s to %s\n", hgrc, url)
+	}
+
+	data, err := os.ReadFile(hgrc)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	var lines []string
+	if len(data) > 0 {
+		lines = strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	}
+
+	section := ""
+	pathsIndex := -1
+	replaced := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			section = trimmed
+			if section == "[paths]" {
+				pathsIndex = i
+			}
+			continue
+		}
+		if section != "[paths]" {
+			continue
+		}
+		if key, _, ok := strings.Cut(trimmed, "="); ok && strings.TrimSpace(key) == "default" {
+			lines[i] = "default = " + url
+			replaced = true
+		}
+	}
+
+	if !replaced {
+		if pathsIndex == -1 {
+			lines = append(lines, "[paths]", "default = "+url)
+		} else {
+			lines = append(lines[:pathsIndex+1], append([]string{"default = " + url}, lines[pathsIndex+1:]...)...)
+		}
+	}
+
+	return os.WriteFile(hgrc, []byte(strings.Join(lines, "\n")+"\n"), 0600)
+}
+
+func (h HgClient) Checkout(repo scm.Repo) error {
+	return runHg(repo, "update", "--rev", repo.CloneBranch)
+}
+
+func (h HgClient) CheckoutBranch(repo scm.Repo, branch string) error {
+	return runHg(repo, "update", "--rev", branch)
+}
+
+func (h HgClient) GetCurrentBranch(repo scm.Repo) (string, error) {
+	return outputHg(repo, "branch")
+}
+
+// Maintain verifies the integrity of the repo for fsck, mercurial keeps its store compact on its own so the
+// other tasks have nothing to do
+func (h HgClient) Maintain(repo scm.Repo, task string) error {
+	if task != "fsck" {
+		return nil
+	}
+
+	output, err := hgCommand(repo, "verify", "--quiet").CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(output)); msg != "" {
+			return fmt.Errorf("%v: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
+
+// SparseCheckout does nothing, sparse paths are only supported for git repos so mercurial repos are always
+// fully checked out
+func (h HgClient) SparseCheckout(repo scm.Repo) error {
+	return nil
+}
+
+// LastCommitDate returns the date of the working directory's parent changeset
+func (h HgClient) LastCommitDate(repo scm.Repo) (time.Time, error) {
+	output, err := outputHg(repo, "log", "--rev", ".", "--template", "{date|rfc3339date}")
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Parse(time.RFC3339, output)
+}
+
+// GetRemoteURL returns the default path of the repo
+func (h HgClient) GetRemoteURL(repo scm.Repo) (string, error) {
+	return outputHg(repo, "paths", "default")
+}
+
+func (h HgClient) Clean(repo scm.Repo) error {
+	// purge is bundled with mercurial but older releases ship it disabled
+	return runHg(repo, "--config", "extensions.purge=", "purge")
+}
+
+func (h HgClient) UpdateRemote(repo scm.Repo) error {
+	return runHg(repo, "pull")
+}
+
+// Pull pulls new changesets and moves the working copy to the tip of the clone branch
+func (h HgClient) Pull(repo scm.Repo) error {
+	if err := runHg(repo, "pull"); err != nil {
+		return err
+	}
+	return runHg(repo, "update", "--rev", repo.CloneBranch)
+}
+
+func (h HgClient) Reset(repo scm.Repo) error {
+	return runHg(repo, "update", "--clean", "--rev", repo.CloneBranch)
+}
+
+func (h HgClient) FetchAll(repo scm.Repo) error {
+	return runHg(repo, "pull")
+}
+
+func (h HgClient) Branch(repo scm.Repo) (string, error) {
+	return outputHg(repo, "branches", "--quiet")
+}
+
+// RevListCompare returns the changesets that have not been pushed anywhere. Mercurial tracks this
+// with phases rather than remote tracking branches, so the branch arguments are not used.
+func (h HgClient) RevListCompare(repo scm.Repo, localBranch string, remoteBranch string) (string, error) {
+	cmd := hgCommand(repo, "log", "--rev", "draft()", "--template", "{node|short}\n")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+func (h HgClient) FetchCloneBranch(repo scm.Repo) error {
+	return runHg(repo, "pull", "--branch", repo.CloneBranch)
+}
+
+func (h HgClient) ShortStatus(repo scm.Repo) (string, error) {
+	return outputHg(repo, "status")
+}
+
+func (h HgClient) RepoCommitCount(repo scm.Repo) (int, error) {
+	output, err := outputHg(repo, "log", "--rev", "ancestors("+strconv.Quote(repo.CloneBranch)+")", "--template", ".")
+	if err != nil {
+		return 0, err
+	}
+	return len(output), nil
+}
+
+// HasRemoteHeads reports whether the remote has any changesets, the clone url is checked before a repo is cloned
+func (h HgClient) HasRemoteHeads(repo scm.Repo) (bool, error) {
+	source := "default"
+	if _, err := os.Stat(repo.HostPath); err != nil {
+		source = repo.CloneURL
+		repo.HostPath = ""
+	}
+	output, err := outputHg(repo, "identify", "--id", source)
+	if err != nil {
+		return false, err
+	}
+	return output != "" && output != hgEmptyRepoID, nil
+}
+
+// LfsFetchAll is a no-op, the largefiles extension downloads files as they are checked out
+func (h HgClient) LfsFetchAll(repo scm.Repo) error {
+	return nil
+}
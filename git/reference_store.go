@@ -0,0 +1,103 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/gabrie30/ghorg/scm"
+)
+
+// ReferenceStore keeps the bare repos new clones borrow objects from through git alternates, it's used by
+// --reference-store so forks of the same upstream only download the objects they don't share
+type ReferenceStore interface {
+	UpdateReference(repo scm.Repo, referencePath string, namespace string) error
+}
+
+// referenceConfig keeps every object of a reference repo for as long as it exists. Clones only record the
+// objects they borrow in their own refs, so the reference must never prune one: fetches into it don't delete
+// refs, auto gc is off and a gc run by hand keeps unreachable objects.
+var referenceConfig = [][]string{
+	{"gc.auto", "0"},
+	{"gc.pruneExpire", "never"},
+	{"fetch.prune", "false"},
+}
+
+// UpdateReference fetches the branches of repo into the bare repo at referencePath, creating it first. The
+// branches are kept under refs/ghorg/<namespace>/heads so the forks sharing a reference don't overwrite each
+// other's. Nothing about the remote is stored in the reference, so credentials in the clone url are not kept.
+func (g GitClient) UpdateReference(repo scm.Repo, referencePath string, namespace string) error {
+	reference := scm.Repo{Name: repo.Name, HostPath: referencePath}
+
+	if _, err := os.Stat(referencePath); os.IsNotExist(err) {
+		if err := createReference(reference); err != nil {
+			_ = os.RemoveAll(referencePath)
+			return fmt.Errorf("could not create reference repo %s: %w", referencePath, err)
+		}
+	}
+
+	refspec := fmt.Sprintf("+refs/heads/*:refs/ghorg/%s/heads/*", namespace)
+	if _, err := runGitIn(reference, "fetch", "--quiet", "--no-tags", repo.CloneURL, refspec); err != nil {
+		// git can print the url it failed to fetch, which has the token in it for https clones
+		return errors.New(strings.ReplaceAll(err.Error(), repo.CloneURL, repo.URL))
+	}
+	return nil
+}
+
+// dissociateReference copies the objects a new clone borrows from its reference into the clone and drops the
+// alternates link with GHORG_REFERENCE_STORE_DISSOCIATE, so the clone doesn't depend on the store. It's what git
+// clone --dissociate does, run as separate commands since some git versions fail the checkout of a dissociated
+// clone when the reference is packed.
+func (g GitClient) dissociateReference(repo scm.Repo) error {
+	if repo.ReferencePath == "" || os.Getenv("GHORG_REFERENCE_STORE_DISSOCIATE") != "true" {
+		return nil
+	}
+
+	if _, err := runGitIn(repo, "repack", "-a", "-d", "--quiet"); err != nil {
+		return err
+	}
+
+	output, err := runGitIn(repo, "rev-parse", "--git-path", "objects/info/alternates")
+	if err != nil {
+		return err
+	}
+	alternates := strings.TrimSpace(string(output))
+	if !filepath.IsAbs(alternates) {
+		alternates = filepath.Join(repo.HostPath, alternates)
+	}
+	if err := os.Remove(alternates); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func createReference(reference scm.Repo) error {
+	if err := os.MkdirAll(filepath.Dir(reference.HostPath), 0755); err != nil {
+		return err
+	}
+
+	output, err := exec.Command("git", "init", "--bare", "--quiet", reference.HostPath).CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(output)); msg != "" {
+			return fmt.Errorf("%v: %s", err, msg)
+		}
+		return err
+	}
+
+	for _, setting := range referenceConfig {
+		if _, err := runGitIn(reference, "config", setting[0], setting[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (v VCSClient) UpdateReference(repo scm.Repo, referencePath string, namespace string) error {
+	if repo.VCS == scm.VCSHg {
+		return errors.New("the reference store is only supported for git repos")
+	}
+	return v.git.UpdateReference(repo, referencePath, namespace)
+}
@@ -0,0 +1,120 @@
+package git
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gabrie30/ghorg/scm"
+)
+
+// VCSClient implements Gitter by handing each repo to the client for its version control system
+type VCSClient struct {
+	git GitClient
+	hg  HgClient
+}
+
+func NewVCS() VCSClient {
+	return VCSClient{git: NewGit(), hg: NewHg()}
+}
+
+func (v VCSClient) client(repo scm.Repo) Gitter {
+	if repo.VCS == scm.VCSHg {
+		return v.hg
+	}
+	return v.git
+}
+
+func (v VCSClient) Clone(repo scm.Repo) error {
+	return v.client(repo).Clone(repo)
+}
+
+func (v VCSClient) Reset(repo scm.Repo) error {
+	return v.client(repo).Reset(repo)
+}
+
+func (v VCSClient) Pull(repo scm.Repo) error {
+	return v.client(repo).Pull(repo)
+}
+
+func (v VCSClient) SetOrigin(repo scm.Repo) error {
+	return v.client(repo).SetOrigin(repo)
+}
+
+func (v VCSClient) SetOriginWithCredentials(repo scm.Repo) error {
+	return v.client(repo).SetOriginWithCredentials(repo)
+}
+
+func (v VCSClient) Clean(repo scm.Repo) error {
+	return v.client(repo).Clean(repo)
+}
+
+func (v VCSClient) Checkout(repo scm.Repo) error {
+	return v.client(repo).Checkout(repo)
+}
+
+func (v VCSClient) CheckoutBranch(repo scm.Repo, branch string) error {
+	return v.client(repo).CheckoutBranch(repo, branch)
+}
+
+func (v VCSClient) GetCurrentBranch(repo scm.Repo) (string, error) {
+	return v.client(repo).GetCurrentBranch(repo)
+}
+
+func (v VCSClient) GetRemoteURL(repo scm.Repo) (string, error) {
+	return v.client(repo).GetRemoteURL(repo)
+}
+
+func (v VCSClient) RevListCompare(repo scm.Repo, localBranch string, remoteBranch string) (string, error) {
+	return v.client(repo).RevListCompare(repo, localBranch, remoteBranch)
+}
+
+func (v VCSClient) ShortStatus(repo scm.Repo) (string, error) {
+	return v.client(repo).ShortStatus(repo)
+}
+
+func (v VCSClient) Branch(repo scm.Repo) (string, error) {
+	return v.client(repo).Branch(repo)
+}
+
+func (v VCSClient) UpdateRemote(repo scm.Repo) error {
+	return v.client(repo).UpdateRemote(repo)
+}
+
+func (v VCSClient) FetchAll(repo scm.Repo) error {
+	return v.client(repo).FetchAll(repo)
+}
+
+func (v VCSClient) FetchCloneBranch(repo scm.Repo) error {
+	return v.client(repo).FetchCloneBranch(repo)
+}
+
+func (v VCSClient) RepoCommitCount(repo scm.Repo) (int, error) {
+	return v.client(repo).RepoCommitCount(repo)
+}
+
+func (v VCSClient) HasRemoteHeads(repo scm.Repo) (bool, error) {
+	return v.client(repo).HasRemoteHeads(repo)
+}
+
+func (v VCSClient) LfsFetchAll(repo scm.Repo) error {
+	return v.client(repo).LfsFetchAll(repo)
+}
+
+func (v VCSClient) Maintain(repo scm.Repo, task string) error {
+	return v.client(repo).Maintain(repo, task)
+}
+
+func (v VCSClient) SparseCheckout(repo scm.Repo) error {
+	return v.client(repo).SparseCheckout(repo)
+}
+
+func (v VCSClient) LastCommitDate(repo scm.Repo) (time.Time, error) {
+	return v.client(repo).LastCommitDate(repo)
+}
+
+func (v VCSClient) FetchReviewRequestRefs(repo scm.Repo, numbers []int64) error {
+	if repo.VCS == scm.VCSHg {
+		return errors.New("review refs are only supported for git repos")
+	}
+	return v.git.FetchReviewRequestRefs(repo, numbers)
+}
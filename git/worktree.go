@@ -0,0 +1,196 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/gabrie30/ghorg/scm"
+)
+
+// Worktree is a linked worktree of a repo as listed by git worktree list
+type Worktree struct {
+	Path string
+	// Branch is empty when the worktree has a detached HEAD
+	Branch string
+}
+
+// WorktreeManager checks out branches of a clone into linked worktrees that share its object store, it's used
+// by --worktree-branches
+type WorktreeManager interface {
+	FetchBranches(scm.Repo) error
+	RemoteBranches(scm.Repo) ([]string, error)
+	Worktrees(scm.Repo) ([]Worktree, error)
+	AddWorktree(repo scm.Repo, path string, branch string) error
+	RemoveWorktree(repo scm.Repo, path string, force bool) error
+	RepairWorktrees(repo scm.Repo, paths []string) error
+	PruneWorktrees(scm.Repo) error
+}
+
+// branchesRefspec maps every branch of origin to a remote tracking branch, it's the refspec of a regular clone
+const branchesRefspec = "+refs/heads/*:refs/remotes/origin/*"
+
+// FetchBranches updates the remote tracking branch of every branch on origin and drops the ones deleted from it.
+// Shallow and single branch clones only track their clone branch, so the refspec for every branch is added to
+// origin first, worktrees can only track remote branches covered by it.
+func (g GitClient) FetchBranches(repo scm.Repo) error {
+	output, _ := exec.Command("git", "-C", repo.HostPath, "config", "--get-all", "remote.origin.fetch").Output()
+	if !slices.Contains(strings.Split(strings.TrimSpace(string(output)), "\n"), branchesRefspec) {
+		if _, err := runGitIn(repo, "config", "--add", "remote.origin.fetch", branchesRefspec); err != nil {
+			return err
+		}
+	}
+
+	args := []string{"fetch", "--prune", "origin"}
+
+	if os.Getenv("GHORG_CLONE_DEPTH") != "" {
+		index := 1
+		args = append(args[:index+1], args[index:]...)
+		args[index] = fmt.Sprintf("--depth=%v", os.Getenv("GHORG_CLONE_DEPTH"))
+	}
+
+	_, err := runGitIn(repo, args...)
+	return err
+}
+
+// RemoteBranches returns the branches of origin as of the last fetch
+func (g GitClient) RemoteBranches(repo scm.Repo) ([]string, error) {
+	output, err := runGitIn(repo, "for-each-ref", "--format=%(refname:lstrip=3)", "refs/remotes/origin")
+	if err != nil {
+		return nil, err
+	}
+
+	branches := []string{}
+	for _, branch := range strings.Split(string(output), "\n") {
+		// origin/HEAD points at the default branch, it's not a branch of its own
+		if branch = strings.TrimSpace(branch); branch != "" && branch != "HEAD" {
+			branches = append(branches, branch)
+		}
+	}
+	return branches, nil
+}
+
+// Worktrees returns the linked worktrees of a repo, the main worktree is left out
+func (g GitClient) Worktrees(repo scm.Repo) ([]Worktree, error) {
+	output, err := runGitIn(repo, "worktree", "list", "--porcelain")
+	if err != nil {
+		return nil, err
+	}
+	return parseWorktreeList(string(output)), nil
+}
+
+// parseWorktreeList parses git worktree list --porcelain, which lists the main worktree first and separates
+// worktrees with a blank line
+func parseWorktreeList(output string) []Worktree {
+	worktrees := []Worktree{}
+	for i, block := range strings.Split(strings.TrimSpace(output), "\n\n") {
+		if i == 0 {
+			continue
+		}
+
+		worktree := Worktree{}
+		for _, line := range strings.Split(block, "\n") {
+			key, value, _ := strings.Cut(line, " ")
+			switch key {
+			case "worktree":
+				worktree.Path = value
+			case "branch":
+				worktree.Branch = strings.TrimPrefix(value, "refs/heads/")
+			}
+		}
+		if worktree.Path != "" {
+			worktrees = append(worktrees, worktree)
+		}
+	}
+	return worktrees
+}
+
+// AddWorktree checks out branch into a new worktree at path. A local branch that already exists is reused so
+// commits on it are never dropped, otherwise it's created tracking the remote branch.
+func (g GitClient) AddWorktree(repo scm.Repo, path string, branch string) error {
+	if _, err := runGitIn(repo, "show-ref", "--verify", "--quiet", "refs/heads/"+branch); err == nil {
+		_, err = runGitIn(repo, "worktree", "add", path, branch)
+		return err
+	}
+
+	_, err := runGitIn(repo, "worktree", "add", "--track", "-b", branch, path, "origin/"+branch)
+	return err
+}
+
+// RemoveWorktree deletes the worktree at path, without force git refuses to remove a worktree with changes. The
+// local branch is kept so commits that were never pushed can still be recovered.
+func (g GitClient) RemoveWorktree(repo scm.Repo, path string, force bool) error {
+	args := []string{"worktree", "remove", path}
+	if force {
+		args = append(args, "--force")
+	}
+	_, err := runGitIn(repo, args...)
+	return err
+}
+
+// RepairWorktrees reconnects worktrees with the repo after either of them was moved
+func (g GitClient) RepairWorktrees(repo scm.Repo, paths []string) error {
+	_, err := runGitIn(repo, append([]string{"worktree", "repair"}, paths...)...)
+	return err
+}
+
+// PruneWorktrees forgets worktrees whose directory was deleted
+func (g GitClient) PruneWorktrees(repo scm.Repo) error {
+	_, err := runGitIn(repo, "worktree", "prune")
+	return err
+}
+
+// errWorktreesUnsupported is returned for mercurial repos, which have no worktrees
+var errWorktreesUnsupported = errors.New("worktrees are only supported for git repos")
+
+func (v VCSClient) FetchBranches(repo scm.Repo) error {
+	if repo.VCS == scm.VCSHg {
+		return errWorktreesUnsupported
+	}
+	return v.git.FetchBranches(repo)
+}
+
+func (v VCSClient) RemoteBranches(repo scm.Repo) ([]string, error) {
+	if repo.VCS == scm.VCSHg {
+		return nil, errWorktreesUnsupported
+	}
+	return v.git.RemoteBranches(repo)
+}
+
+func (v VCSClient) Worktrees(repo scm.Repo) ([]Worktree, error) {
+	if repo.VCS == scm.VCSHg {
+		return nil, errWorktreesUnsupported
+	}
+	return v.git.Worktrees(repo)
+}
+
+func (v VCSClient) AddWorktree(repo scm.Repo, path string, branch string) error {
+	if repo.VCS == scm.VCSHg {
+		return errWorktreesUnsupported
+	}
+	return v.git.AddWorktree(repo, path, branch)
+}
+
+func (v VCSClient) RemoveWorktree(repo scm.Repo, path string, force bool) error {
+	if repo.VCS == scm.VCSHg {
+		return errWorktreesUnsupported
+	}
+	return v.git.RemoveWorktree(repo, path, force)
+}
+
+func (v VCSClient) RepairWorktrees(repo scm.Repo, paths []string) error {
+	if repo.VCS == scm.VCSHg {
+		return errWorktreesUnsupported
+	}
+	return v.git.RepairWorktrees(repo, paths)
+}
+
+func (v VCSClient) PruneWorktrees(repo scm.Repo) error {
+	if repo.VCS == scm.VCSHg {
+		return errWorktreesUnsupported
+	}
+	return v.git.PruneWorktrees(repo)
+}
@@ -7,6 +7,7 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/gabrie30/ghorg/colorlog"
@@ -15,6 +16,7 @@ import (
 
 // compile-time assertion that Bitbucket implements the Client interface
 var _ Client = Bitbucket{}
+var _ CloneURLAuthenticator = Bitbucket{}
 
 func init() {
 	registerClient(Bitbucket{})
@@ -45,6 +47,7 @@ func (c Bitbucket) GetOrgRepos(targetOrg string) ([]Repo, error) {
 	defer spinningSpinner.Stop()
 
 	if c.isServer {
+		warnLanguageFilterUnsupported("Bitbucket Server")
 		return c.getServerProjectRepos(targetOrg)
 	}
 
@@ -54,12 +57,18 @@ func (c Bitbucket) GetOrgRepos(targetOrg string) ([]Repo, error) {
 		return []Repo{}, err
 	}
 
-	return c.filter(resp.Items)
+	repos, err := c.filter(resp.Items)
+	if err != nil {
+		return []Repo{}, err
+	}
+
+	return c.appendSnippets(repos, targetOrg)
 }
 
 // GetUserRepos gets user repos from bitbucket
 func (c Bitbucket) GetUserRepos(targetUser string) ([]Repo, error) {
 	if c.isServer {
+		warnLanguageFilterUnsupported("Bitbucket Server")
 		return c.getServerUserRepos(targetUser)
 	}
 
@@ -69,7 +78,12 @@ func (c Bitbucket) GetUserRepos(targetUser string) ([]Repo, error) {
 		return []Repo{}, err
 	}
 
-	return c.filter(resp.Items)
+	repos, err := c.filter(resp.Items)
+	if err != nil {
+		return []Repo{}, err
+	}
+
+	return c.appendSnippets(repos, targetUser)
 }
 
 // NewClient create new bitbucket scm client
@@ -157,8 +171,10 @@ func (Bitbucket) NewClient() (Client, error) {
 
 // Bitbucket Server API structures
 type ServerRepository struct {
+	ID      int            `json:"id"`
 	Name    string         `json:"name"`
 	Slug    string         `json:"slug"`
+	Public  bool           `json:"public"`
 	Links   map[string]any `json:"links"`
 	Project struct {
 		Key string `json:"key"`
@@ -283,9 +299,14 @@ func (c Bitbucket) filterServerRepos(repos []ServerRepository) []Repo {
 				}
 
 				r := Repo{
-					Name: repo.Name,
-					Path: fmt.Sprintf("%s/%s", repo.Project.Key, repo.Slug),
-					URL:  href,
+					ID:         strconv.Itoa(repo.ID),
+					Name:       repo.Name,
+					Path:       fmt.Sprintf("%s/%s", repo.Project.Key, repo.Slug),
+					URL:        href,
+					Visibility: "private",
+				}
+				if repo.Public {
+					r.Visibility = "public"
 				}
 
 				// Set clone branch to default (master/main)
@@ -334,10 +355,32 @@ func (c Bitbucket) addCredentialsToURL(cloneURL string) string {
 	return cloneURL
 }
 
+// AuthenticateCloneURL adds the credentials to an http clone url the way listing repos does
+func (c Bitbucket) AuthenticateCloneURL(url string) string {
+	if !strings.HasPrefix(url, "http") {
+		return url
+	}
+	if c.isServer {
+		return c.addCredentialsToURL(url)
+	}
+	if os.Getenv("GHORG_BITBUCKET_OAUTH_TOKEN") != "" {
+		// OAuth token - no credentials needed in URL, handled by git credential helper
+		return url
+	}
+	if c.useAPIToken {
+		return insertAPITokenCredentialsIntoURL(url, c.apiToken)
+	}
+	return insertAppPasswordCredentialsIntoURL(url)
+}
+
 func (c Bitbucket) filter(resp []bitbucket.Repository) (repoData []Repo, err error) {
 	cloneData := []Repo{}
 
 	for _, a := range resp {
+		if !hasMatchingLanguage(a.Language) {
+			continue
+		}
+
 		links := a.Links["clone"].([]any)
 		for _, l := range links {
 			link := l.(map[string]any)["href"]
@@ -348,8 +391,21 @@ func (c Bitbucket) filter(resp []bitbucket.Repository) (repoData []Repo, err err
 			}
 
 			r := Repo{}
+			r.ID = a.Uuid
 			r.Name = a.Name
 			r.Path = a.Full_name
+			r.Language = a.Language
+			r.Fork = a.Parent != nil
+			if a.Parent != nil {
+				r.UpstreamPath = a.Parent.Full_name
+			}
+			if a.UpdatedOnTime != nil {
+				r.LastActivityAt = *a.UpdatedOnTime
+			}
+			r.Visibility = "public"
+			if a.Is_private {
+				r.Visibility = "private"
+			}
 			if os.Getenv("GHORG_BRANCH") == "" {
 				r.CloneBranch = a.Mainbranch.Name
 			} else {
@@ -0,0 +1,191 @@
+package scm
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/gabrie30/ghorg/colorlog"
+)
+
+// bitbucketSnippet is a snippet returned by the bitbucket cloud snippets api
+type bitbucketSnippet struct {
+	ID        string                     `json:"id"`
+	Title     string                     `json:"title"`
+	SCM       string                     `json:"scm"`
+	IsPrivate bool                       `json:"is_private"`
+	Files     map[string]json.RawMessage `json:"files"`
+	Links     struct {
+		Clone []struct {
+			Name string `json:"name"`
+			Href string `json:"href"`
+		} `json:"clone"`
+	} `json:"links"`
+}
+
+type bitbucketSnippetsPage struct {
+	Values []bitbucketSnippet `json:"values"`
+	Next   string             `json:"next"`
+}
+
+// appendSnippets adds the snippets of a workspace to repos when --clone-snippets is set. Failing to
+// list snippets is reported but doesn't stop the repos from being cloned.
+func (c Bitbucket) appendSnippets(repos []Repo, workspace string) ([]Repo, error) {
+	if os.Getenv("GHORG_CLONE_SNIPPETS") != "true" {
+		return repos, nil
+	}
+
+	snippets, err := c.GetSnippets(workspace)
+	if err != nil {
+		spinningSpinner.Stop()
+		colorlog.PrintError(fmt.Sprintf("Error getting snippets, error: %v", err))
+		return repos, nil
+	}
+
+	return append(repos, snippets...), nil
+}
+
+// GetSnippets lists the snippets of a bitbucket cloud workspace
+func (c Bitbucket) GetSnippets(workspace string) ([]Repo, error) {
+	var all []bitbucketSnippet
+
+	next := fmt.Sprintf("%s/snippets/%s?pagelen=100", c.GetApiBaseURL(), url.PathEscape(workspace))
+	for next != "" {
+		page, err := c.getSnippetsPage(next)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, page.Values...)
+		next = page.Next
+	}
+
+	return c.filterSnippets(all), nil
+}
+
+func (c Bitbucket) getSnippetsPage(pageURL string) (bitbucketSnippetsPage, error) {
+	var page bitbucketSnippetsPage
+
+	req, err := http.NewRequest("GET", pageURL, nil)
+	if err != nil {
+		return page, err
+	}
+	setBitbucketCloudAuth(req)
+
+	hc := c.HttpClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+
+	resp, err := hc.Do(req)
+	if err != nil {
+		return page, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 200))
+		return page, fmt.Errorf("unexpected response code %d from bitbucket: %q", resp.StatusCode, string(body))
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&page)
+	return page, err
+}
+
+// setBitbucketCloudAuth authenticates a request to the bitbucket cloud api the same way NewClient does
+func setBitbucketCloudAuth(req *http.Request) {
+	if oAuth := os.Getenv("GHORG_BITBUCKET_OAUTH_TOKEN"); oAuth != "" {
+		req.Header.Set("Authorization", "Bearer "+oAuth)
+		return
+	}
+
+	if apiToken := os.Getenv("GHORG_BITBUCKET_API_TOKEN"); apiToken != "" {
+		apiUser := os.Getenv("GHORG_BITBUCKET_API_EMAIL")
+		if apiUser == "" {
+			apiUser = os.Getenv("GHORG_BITBUCKET_USERNAME")
+		}
+		req.SetBasicAuth(apiUser, apiToken)
+		return
+	}
+
+	req.SetBasicAuth(os.Getenv("GHORG_BITBUCKET_USERNAME"), os.Getenv("GHORG_BITBUCKET_APP_PASSWORD"))
+}
+
+// filterSnippets converts snippets into Repos, naming their folders like github gists
+func (c Bitbucket) filterSnippets(snippets []bitbucketSnippet) []Repo {
+	var valid []bitbucketSnippet
+	var ids, names []string
+
+	for _, snippet := range snippets {
+		// mercurial snippets were removed from bitbucket along with mercurial repos
+		if snippet.ID == "" || (snippet.SCM != "" && snippet.SCM != "git") {
+			continue
+		}
+
+		filenames := make([]string, 0, len(snippet.Files))
+		for fn := range snippet.Files {
+			filenames = append(filenames, fn)
+		}
+
+		valid = append(valid, snippet)
+		ids = append(ids, snippet.ID)
+		names = append(names, snippetFolderName(snippet.ID, filenames))
+	}
+
+	folderNames := uniqueSnippetFolderNames(ids, names)
+
+	var repoData []Repo
+	for i, snippet := range valid {
+		var httpsURL, sshURL string
+		for _, link := range snippet.Links.Clone {
+			switch link.Name {
+			case "https":
+				httpsURL = link.Href
+			case "ssh":
+				sshURL = link.Href
+			}
+		}
+
+		r := Repo{}
+		r.ID = snippet.ID
+		r.Name = folderNames[i]
+		r.Path = snippetPath(folderNames[i])
+		r.IsSnippet = true
+		r.Visibility = "public"
+		if snippet.IsPrivate {
+			r.Visibility = "private"
+		}
+		if os.Getenv("GHORG_BRANCH") != "" {
+			r.CloneBranch = os.Getenv("GHORG_BRANCH")
+		} else {
+			r.CloneBranch = "master"
+		}
+
+		if os.Getenv("GHORG_CLONE_PROTOCOL") == "ssh" {
+			if sshURL == "" {
+				continue
+			}
+			r.URL = sshURL
+			r.CloneURL = ReplaceSSHHostname(sshURL, os.Getenv("GHORG_SSH_HOSTNAME"))
+		} else {
+			if httpsURL == "" {
+				continue
+			}
+			r.URL = httpsURL
+			r.CloneURL = httpsURL
+			if os.Getenv("GHORG_BITBUCKET_OAUTH_TOKEN") != "" {
+				// OAuth token - no credentials needed in URL, handled by git credential helper
+			} else if os.Getenv("GHORG_BITBUCKET_API_TOKEN") != "" {
+				r.CloneURL = insertAPITokenCredentialsIntoURL(r.CloneURL, os.Getenv("GHORG_BITBUCKET_API_TOKEN"))
+			} else {
+				r.CloneURL = insertAppPasswordCredentialsIntoURL(r.CloneURL)
+			}
+		}
+
+		repoData = append(repoData, r)
+	}
+
+	return repoData
+}
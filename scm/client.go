@@ -1,6 +1,9 @@
 package scm
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 // Client define the interface a scm client has to have
 type Client interface {
@@ -12,6 +15,50 @@ type Client interface {
 	GetType() string
 }
 
+// MembershipLister is implemented by clients that can list the orgs or top
+// level groups the authenticated user is a member of
+type MembershipLister interface {
+	GetMemberOrgs() ([]string, error)
+}
+
+// StarredLister is implemented by clients that can list the repos a user has
+// starred, an empty targetUser is the authenticated user
+type StarredLister interface {
+	GetUserStarredRepos(targetUser string) ([]Repo, error)
+}
+
+// ReviewRequestLister is implemented by clients that can list the pull or merge
+// requests of a repo, only open ones unless includeClosed is set
+type ReviewRequestLister interface {
+	GetReviewRequests(repo Repo, includeClosed bool) ([]ReviewRequest, error)
+}
+
+// MetadataExporter is implemented by clients that can export the issues, pull or
+// merge requests, releases, labels and milestones of a repo into dir. Only issues
+// and pull or merge requests updated after since are exported, a zero since exports
+// everything.
+type MetadataExporter interface {
+	ExportMetadata(repo Repo, dir string, since time.Time) error
+}
+
+// ForkSourceResolver is implemented by clients whose repo listings don't include the upstream of forks, it
+// returns the path of the repo at the root of the fork network of a fork
+type ForkSourceResolver interface {
+	GetForkSource(fork Repo) (string, error)
+}
+
+// CloneURLAuthenticator is implemented by clients that add their credentials to http clone urls, it's used
+// to restore the credentials of existing clones, whose origin is stored without them
+type CloneURLAuthenticator interface {
+	AuthenticateCloneURL(url string) string
+}
+
+// WatchedLister is implemented by clients that can list the repos a user is
+// watching, an empty targetUser is the authenticated user
+type WatchedLister interface {
+	GetUserWatchedRepos(targetUser string) ([]Repo, error)
+}
+
 var (
 	clients []Client
 )
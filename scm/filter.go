@@ -3,9 +3,20 @@ package scm
 import (
 	"os"
 	"regexp"
+	"strconv"
 	"strings"
+
+	"github.com/gabrie30/ghorg/colorlog"
 )
 
+// isOwnerOrganized reports whether repos should be laid out as owner/repo. Starred
+// and watched clones collect repos from many owners, so the owner is kept in the
+// path to stop repos with the same name from colliding.
+func isOwnerOrganized() bool {
+	cloneType := os.Getenv("GHORG_CLONE_TYPE")
+	return cloneType == "starred" || cloneType == "watched"
+}
+
 func hasMatchingTopic(rpTopics []string) bool {
 	envTopics := strings.Split(os.Getenv("GHORG_TOPICS"), ",")
 
@@ -25,6 +36,93 @@ func hasMatchingTopic(rpTopics []string) bool {
 	return true
 }
 
+// filterExpressionUsesTopics reports whether --filter references topics, scms that need an extra api
+// call per repo to list topics only make it when this or --topics is set
+func filterExpressionUsesTopics() bool {
+	return strings.Contains(os.Getenv("GHORG_FILTER"), "topics")
+}
+
+// filterExpressionUsesLanguage reports whether --filter references language, scms that need an extra api
+// call per repo to get its language only make it when this or --filter-language is set
+func filterExpressionUsesLanguage() bool {
+	return strings.Contains(os.Getenv("GHORG_FILTER"), "language")
+}
+
+// filterLanguages returns the lowercased languages set with --filter-language. GHORG_GITHUB_FILTER_LANGUAGE
+// is read when it isn't set so configs written before the filter worked on every scm keep working.
+func filterLanguages() []string {
+	languages := os.Getenv("GHORG_FILTER_LANGUAGE")
+	if languages == "" {
+		languages = os.Getenv("GHORG_GITHUB_FILTER_LANGUAGE")
+	}
+	if languages == "" {
+		return nil
+	}
+
+	var langs []string
+	for _, lang := range strings.Split(strings.ToLower(languages), ",") {
+		if lang = strings.TrimSpace(lang); lang != "" {
+			langs = append(langs, lang)
+		}
+	}
+	return langs
+}
+
+// hasMatchingLanguage reports whether a repo with the primary language lang passes --filter-language.
+// Repos without a language never match when the filter is set.
+func hasMatchingLanguage(lang string) bool {
+	langs := filterLanguages()
+	if langs == nil {
+		return true
+	}
+
+	for _, l := range langs {
+		if strings.EqualFold(lang, l) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasMatchingLanguageBreakdown is hasMatchingLanguage for providers that report the percentage of
+// each language in a repo. With --filter-language-threshold a repo matches when any of the languages
+// makes up at least that percentage, otherwise only the language with the largest share counts.
+func hasMatchingLanguageBreakdown(percentages map[string]float64) bool {
+	if filterLanguages() == nil {
+		return true
+	}
+
+	threshold, err := strconv.ParseFloat(os.Getenv("GHORG_FILTER_LANGUAGE_THRESHOLD"), 64)
+	if err != nil || threshold <= 0 {
+		return hasMatchingLanguage(primaryLanguage(percentages))
+	}
+
+	for lang, pct := range percentages {
+		if pct >= threshold && hasMatchingLanguage(lang) {
+			return true
+		}
+	}
+	return false
+}
+
+// primaryLanguage returns the language with the largest share, ties are broken alphabetically
+func primaryLanguage(percentages map[string]float64) string {
+	primary := ""
+	for lang, pct := range percentages {
+		if primary == "" || pct > percentages[primary] || (pct == percentages[primary] && lang < primary) {
+			primary = lang
+		}
+	}
+	return primary
+}
+
+// warnLanguageFilterUnsupported tells users --filter-language is ignored by scms that don't report languages
+func warnLanguageFilterUnsupported(scm string) {
+	if filterLanguages() != nil {
+		colorlog.PrintError("WARNING: Filtering by language is not supported for " + scm + ", all repos will be cloned")
+	}
+}
+
 // ReplaceSSHHostname replaces the hostname in an SSH clone URL with a custom hostname.
 // This allows users to leverage SSH configs with multiple host aliases.
 // For example: git@gitlab.com:org/repo.git -> git@my-gitlab-alias:org/repo.git
@@ -175,3 +175,64 @@ func TestReplaceSSHHostname(t *testing.T) {
 		}
 	})
 }
+
+func TestHasMatchingLanguage(t *testing.T) {
+	tests := []struct {
+		name         string
+		filter       string
+		githubFilter string
+		lang         string
+		want         bool
+	}{
+		{name: "no filter keeps every repo", lang: "", want: true},
+		{name: "matches case insensitively", filter: "go,Python", lang: "python", want: true},
+		{name: "other languages are skipped", filter: "go", lang: "Ruby", want: false},
+		{name: "repos without a language are skipped", filter: "go", lang: "", want: false},
+		{name: "spaces around languages are ignored", filter: "go, ruby", lang: "Ruby", want: true},
+		{name: "github filter is still read", githubFilter: "go", lang: "Go", want: true},
+		{name: "filter language wins over github filter", filter: "ruby", githubFilter: "go", lang: "Go", want: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(tt *testing.T) {
+			tt.Setenv("GHORG_FILTER_LANGUAGE", tc.filter)
+			tt.Setenv("GHORG_GITHUB_FILTER_LANGUAGE", tc.githubFilter)
+
+			if got := hasMatchingLanguage(tc.lang); got != tc.want {
+				tt.Errorf("hasMatchingLanguage(%q): expected %v, got %v", tc.lang, tc.want, got)
+			}
+		})
+	}
+}
+
+func TestHasMatchingLanguageBreakdown(t *testing.T) {
+	breakdown := map[string]float64{"Go": 70, "Shell": 25, "Makefile": 5}
+
+	tests := []struct {
+		name      string
+		filter    string
+		threshold string
+		want      bool
+	}{
+		{name: "primary language matches", filter: "go", want: true},
+		{name: "secondary language needs a threshold", filter: "shell", want: false},
+		{name: "secondary language above the threshold", filter: "shell", threshold: "20", want: true},
+		{name: "language below the threshold", filter: "makefile", threshold: "20", want: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(tt *testing.T) {
+			tt.Setenv("GHORG_FILTER_LANGUAGE", tc.filter)
+			tt.Setenv("GHORG_GITHUB_FILTER_LANGUAGE", "")
+			tt.Setenv("GHORG_FILTER_LANGUAGE_THRESHOLD", tc.threshold)
+
+			if got := hasMatchingLanguageBreakdown(breakdown); got != tc.want {
+				tt.Errorf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+
+	if got := primaryLanguage(map[string]float64{"Ruby": 50, "C": 50}); got != "C" {
+		t.Errorf("Expected ties to be broken alphabetically, got %s", got)
+	}
+}
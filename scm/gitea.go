@@ -2,9 +2,13 @@ package scm
 
 import (
 	"crypto/tls"
+	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
 	"os"
+	"strconv"
 	"strings"
 
 	"code.gitea.io/sdk/gitea"
@@ -13,6 +17,11 @@ import (
 
 // compile-time assertion that Gitea implements the Client interface
 var _ Client = Gitea{}
+var _ MembershipLister = Gitea{}
+var _ StarredLister = Gitea{}
+var _ WatchedLister = Gitea{}
+var _ ReviewRequestLister = Gitea{}
+var _ CloneURLAuthenticator = Gitea{}
 
 func init() {
 	registerClient(Gitea{})
@@ -25,6 +34,9 @@ type Gitea struct {
 	perPage int
 	// token used to authenticate api calls and clone urls
 	token string
+	// baseURL and httpClient are used for api calls the gitea sdk does not paginate
+	baseURL    string
+	httpClient *http.Client
 	// insecure permits connecting to instances served over http
 	insecure bool
 	// insecureFlag is the cli flag users must set to permit http connections,
@@ -90,6 +102,159 @@ func (c Gitea) GetUserRepos(targetUsername string) ([]Repo, error) {
 	return c.fetchUserReposParallel(targetUsername, rps)
 }
 
+// GetMemberOrgs returns the name of every org the authenticated user is a member of
+func (c Gitea) GetMemberOrgs() ([]string, error) {
+	spinningSpinner.Start()
+	defer spinningSpinner.Stop()
+
+	orgs := []string{}
+	for page := 1; ; page++ {
+		myOrgs, _, err := c.ListMyOrgs(gitea.ListOrgsOptions{ListOptions: gitea.ListOptions{
+			Page:     page,
+			PageSize: c.perPage,
+		}})
+		if err != nil {
+			return nil, err
+		}
+		for _, org := range myOrgs {
+			name := org.Name
+			if name == "" {
+				name = org.UserName
+			}
+			orgs = append(orgs, name)
+		}
+		if len(myOrgs) < c.perPage {
+			break
+		}
+	}
+
+	return orgs, nil
+}
+
+// GetUserStarredRepos gets the repos a user has starred, an empty targetUser is the authenticated user
+func (c Gitea) GetUserStarredRepos(targetUser string) ([]Repo, error) {
+	if targetUser == "" {
+		return c.listReposPaged("/user/starred", targetUser)
+	}
+	return c.listReposPaged(fmt.Sprintf("/users/%s/starred", url.PathEscape(targetUser)), targetUser)
+}
+
+// GetUserWatchedRepos gets the repos a user is watching, an empty targetUser is the authenticated user
+func (c Gitea) GetUserWatchedRepos(targetUser string) ([]Repo, error) {
+	if targetUser == "" {
+		return c.listReposPaged("/user/subscriptions", targetUser)
+	}
+	return c.listReposPaged(fmt.Sprintf("/users/%s/subscriptions", url.PathEscape(targetUser)), targetUser)
+}
+
+// listReposPaged fetches every page of repos from a gitea api path. The gitea
+// sdk does not accept list options for starred and watched repos so only the
+// first page would be returned through it.
+func (c Gitea) listReposPaged(apiPath string, targetUser string) ([]Repo, error) {
+	spinningSpinner.Start()
+	defer spinningSpinner.Stop()
+
+	var rps []*gitea.Repository
+	for page := 1; ; page++ {
+		pageRepos, err := c.getReposPage(apiPath, targetUser, page)
+		if err != nil {
+			return nil, err
+		}
+		rps = append(rps, pageRepos...)
+		if len(pageRepos) < c.perPage {
+			break
+		}
+	}
+
+	return c.filter(rps)
+}
+
+func (c Gitea) getReposPage(apiPath string, targetUser string, page int) ([]*gitea.Repository, error) {
+	u := fmt.Sprintf("%s/api/v1%s?page=%d&limit=%d", strings.TrimSuffix(c.baseURL, "/"), apiPath, page, c.perPage)
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "token "+c.token)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	hc := c.httpClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("user \"%s\" not found", targetUser)
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 200))
+		return nil, fmt.Errorf("unexpected response code %d from %s: %q", resp.StatusCode, apiPath, string(body))
+	}
+
+	var rps []*gitea.Repository
+	if err := json.NewDecoder(resp.Body).Decode(&rps); err != nil {
+		return nil, err
+	}
+	return rps, nil
+}
+
+// GetReviewRequests gets the pull requests of a repo
+func (c Gitea) GetReviewRequests(repo Repo, includeClosed bool) ([]ReviewRequest, error) {
+	owner, name, ok := strings.Cut(repo.FullName, "/")
+	if !ok {
+		return nil, fmt.Errorf("could not determine the owner of %s", repo.Name)
+	}
+
+	state := gitea.StateOpen
+	if includeClosed {
+		state = gitea.StateAll
+	}
+
+	reviewRequests := []ReviewRequest{}
+	for page := 1; ; page++ {
+		prs, _, err := c.ListRepoPullRequests(owner, name, gitea.ListPullRequestsOptions{
+			ListOptions: gitea.ListOptions{Page: page, PageSize: c.perPage},
+			State:       state,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, pr := range prs {
+			r := ReviewRequest{
+				Number: pr.Index,
+				Title:  pr.Title,
+				State:  string(pr.State),
+				URL:    pr.HTMLURL,
+			}
+			if pr.HasMerged {
+				r.State = "merged"
+			}
+			if pr.Poster != nil {
+				r.Author = pr.Poster.UserName
+			}
+			if pr.Head != nil {
+				r.SourceBranch = pr.Head.Ref
+			}
+			reviewRequests = append(reviewRequests, r)
+		}
+
+		if len(prs) < c.perPage {
+			break
+		}
+	}
+
+	return reviewRequests, nil
+}
+
 // NewClient create new gitea scm client
 func (Gitea) NewClient() (Client, error) {
 	baseURL := os.Getenv("GHORG_SCM_BASE_URL")
@@ -117,6 +282,7 @@ func newGiteaClient(baseURL string, token string, insecure bool, insecureFlag st
 
 	var err error
 	var c *gitea.Client
+	httpClient := &http.Client{}
 	if insecure {
 		defaultTransport := http.DefaultTransport.(*http.Transport)
 		// Create new Transport that ignores self-signed SSL
@@ -129,7 +295,7 @@ func newGiteaClient(baseURL string, token string, insecure bool, insecureFlag st
 			TLSHandshakeTimeout:   defaultTransport.TLSHandshakeTimeout,
 			TLSClientConfig:       &tls.Config{InsecureSkipVerify: true},
 		}
-		httpClient := &http.Client{Transport: customTransport}
+		httpClient = &http.Client{Transport: customTransport}
 		c, err = gitea.NewClient(baseURL, gitea.SetToken(token), gitea.SetHTTPClient(httpClient))
 		if err != nil {
 			return nil, err
@@ -141,7 +307,7 @@ func newGiteaClient(baseURL string, token string, insecure bool, insecureFlag st
 			return nil, err
 		}
 	}
-	client := Gitea{Client: c, token: token, insecure: insecure, insecureFlag: insecureFlag}
+	client := Gitea{Client: c, token: token, baseURL: baseURL, httpClient: httpClient, insecure: insecure, insecureFlag: insecureFlag}
 
 	//set small limit so gitea most likely will have a bigger one
 	client.perPage = 10
@@ -168,6 +334,14 @@ func (c Gitea) addTokenToCloneURL(url string, token string) string {
 	return "https://" + token + "@" + splitURL[1]
 }
 
+// AuthenticateCloneURL adds the token to an http clone url the way listing repos does
+func (c Gitea) AuthenticateCloneURL(url string) string {
+	if c.token == "" || !strings.HasPrefix(url, "http") {
+		return url
+	}
+	return c.addTokenToCloneURL(url, c.token)
+}
+
 func (c Gitea) filter(rps []*gitea.Repository) (repoData []Repo, err error) {
 	for _, rp := range rps {
 
@@ -183,8 +357,14 @@ func (c Gitea) filter(rps []*gitea.Repository) (repoData []Repo, err error) {
 			}
 		}
 
-		if os.Getenv("GHORG_TOPICS") != "" {
-			rpTopics, _, err := c.ListRepoTopics(rp.Owner.UserName, rp.Name, gitea.ListRepoTopicsOptions{})
+		if !hasMatchingLanguage(rp.Language) {
+			continue
+		}
+
+		// gitea doesn't include topics when listing repos so they're only fetched when something filters on them
+		var rpTopics []string
+		if os.Getenv("GHORG_TOPICS") != "" || filterExpressionUsesTopics() {
+			rpTopics, _, err = c.ListRepoTopics(rp.Owner.UserName, rp.Name, gitea.ListRepoTopicsOptions{})
 			if err != nil {
 				return []Repo{}, err
 			}
@@ -194,8 +374,27 @@ func (c Gitea) filter(rps []*gitea.Repository) (repoData []Repo, err error) {
 		}
 
 		r := Repo{}
+		r.ID = strconv.FormatInt(rp.ID, 10)
+		r.FullName = rp.FullName
 		r.Path = rp.FullName
 		r.Name = rp.Name
+		r.Language = rp.Language
+		r.Archived = rp.Archived
+		r.Fork = rp.Fork
+		if rp.Parent != nil {
+			r.UpstreamPath = rp.Parent.FullName
+		}
+		r.Topics = rpTopics
+		r.LastActivityAt = rp.Updated
+		r.SizeKB = int64(rp.Size)
+		switch {
+		case rp.Private:
+			r.Visibility = "private"
+		case rp.Internal:
+			r.Visibility = "internal"
+		default:
+			r.Visibility = "public"
+		}
 
 		if os.Getenv("GHORG_BRANCH") == "" {
 			defaultBranch := rp.DefaultBranch
@@ -221,20 +420,20 @@ func (c Gitea) filter(rps []*gitea.Repository) (repoData []Repo, err error) {
 			repoData = append(repoData, r)
 		}
 
-		if rp.HasWiki && os.Getenv("GHORG_CLONE_WIKI") == "true" {
-			wiki := Repo{}
-			wiki.IsWiki = true
-			wiki.CloneURL = strings.Replace(r.CloneURL, ".git", ".wiki.git", 1)
-			wiki.URL = strings.Replace(r.URL, ".git", ".wiki.git", 1)
+		// repos with an external wiki still report has_wiki but have nothing to clone
+		hasExternalWiki := rp.ExternalWiki != nil && rp.ExternalWiki.ExternalWikiURL != ""
+		if rp.HasWiki && !hasExternalWiki && shouldCloneWikis() {
 			// Modern Gitea/Forgejo (e.g. Codeberg) wikis follow the repo's
 			// default branch rather than always using master
 			wikiBranch := rp.DefaultBranch
 			if wikiBranch == "" {
 				wikiBranch = "master"
 			}
-			wiki.CloneBranch = wikiBranch
-			wiki.Path = fmt.Sprintf("%s%s", r.Name, ".wiki")
-			repoData = append(repoData, wiki)
+			wikiPath := r.Name + ".wiki"
+			if isOwnerOrganized() {
+				wikiPath = r.Path + ".wiki"
+			}
+			repoData = append(repoData, newWiki(r, wikiPath, wikiBranch))
 		}
 	}
 	return repoData, nil
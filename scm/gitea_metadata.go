@@ -0,0 +1,185 @@
+package scm
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"code.gitea.io/sdk/gitea"
+)
+
+var _ MetadataExporter = Gitea{}
+
+// giteaIssueExport is the file written for each issue
+type giteaIssueExport struct {
+	Issue    *gitea.Issue     `json:"issue"`
+	Comments []*gitea.Comment `json:"comments"`
+}
+
+// giteaPullRequestExport is the file written for each pull request
+type giteaPullRequestExport struct {
+	PullRequest *gitea.PullRequest  `json:"pull_request"`
+	Comments    []*gitea.Comment    `json:"comments"`
+	Reviews     []*gitea.PullReview `json:"reviews"`
+}
+
+// giteaListAll pages through a gitea list call until a short page is returned
+func giteaListAll[T any](perPage int, list func(opt gitea.ListOptions) ([]T, *gitea.Response, error)) ([]T, error) {
+	var all []T
+
+	for page := 1; ; page++ {
+		items, _, err := list(gitea.ListOptions{Page: page, PageSize: perPage})
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, items...)
+		if len(items) < perPage {
+			break
+		}
+	}
+
+	return all, nil
+}
+
+// ExportMetadata exports the issues, pull requests, releases, labels and milestones of a repo
+func (c Gitea) ExportMetadata(repo Repo, dir string, since time.Time) error {
+	owner, name, ok := strings.Cut(repo.FullName, "/")
+	if !ok {
+		return fmt.Errorf("could not determine the owner of %s", repo.Name)
+	}
+
+	if err := c.exportIssues(owner, name, dir, since); err != nil {
+		return fmt.Errorf("exporting issues: %w", err)
+	}
+
+	if err := c.exportReleases(owner, name, dir); err != nil {
+		return fmt.Errorf("exporting releases: %w", err)
+	}
+
+	labels, err := giteaListAll(c.perPage, func(opt gitea.ListOptions) ([]*gitea.Label, *gitea.Response, error) {
+		return c.ListRepoLabels(owner, name, gitea.ListLabelsOptions{ListOptions: opt})
+	})
+	if err != nil {
+		return fmt.Errorf("exporting labels: %w", err)
+	}
+	if err := writeMetadataFile(filepath.Join(dir, metadataLabelsFile), labels); err != nil {
+		return err
+	}
+
+	milestones, err := giteaListAll(c.perPage, func(opt gitea.ListOptions) ([]*gitea.Milestone, *gitea.Response, error) {
+		return c.ListRepoMilestones(owner, name, gitea.ListMilestoneOption{ListOptions: opt, State: gitea.StateAll})
+	})
+	if err != nil {
+		return fmt.Errorf("exporting milestones: %w", err)
+	}
+	return writeMetadataFile(filepath.Join(dir, metadataMilestonesFile), milestones)
+}
+
+// exportIssues exports issues and pull requests, gitea lists pull requests as issues
+func (c Gitea) exportIssues(owner, name, dir string, since time.Time) error {
+	issues, err := giteaListAll(c.perPage, func(opt gitea.ListOptions) ([]*gitea.Issue, *gitea.Response, error) {
+		return c.ListRepoIssues(owner, name, gitea.ListIssueOption{
+			ListOptions: opt,
+			State:       gitea.StateAll,
+			Type:        gitea.IssueTypeAll,
+			Since:       since,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, issue := range issues {
+		index := issue.Index
+
+		comments, err := giteaListAll(c.perPage, func(opt gitea.ListOptions) ([]*gitea.Comment, *gitea.Response, error) {
+			return c.ListIssueComments(owner, name, index, gitea.ListIssueCommentOptions{ListOptions: opt})
+		})
+		if err != nil {
+			return err
+		}
+
+		if issue.PullRequest == nil {
+			if err := writeMetadataFile(metadataItemPath(dir, metadataIssuesDir, index), giteaIssueExport{Issue: issue, Comments: comments}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		pr, _, err := c.GetPullRequest(owner, name, index)
+		if err != nil {
+			return err
+		}
+
+		reviews, err := giteaListAll(c.perPage, func(opt gitea.ListOptions) ([]*gitea.PullReview, *gitea.Response, error) {
+			return c.ListPullReviews(owner, name, index, gitea.ListPullReviewsOptions{ListOptions: opt})
+		})
+		if err != nil {
+			return err
+		}
+
+		export := giteaPullRequestExport{PullRequest: pr, Comments: comments, Reviews: reviews}
+		if err := writeMetadataFile(metadataItemPath(dir, metadataPullRequestsDir, index), export); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// exportReleases exports every release and downloads its attachments
+func (c Gitea) exportReleases(owner, name, dir string) error {
+	releases, err := giteaListAll(c.perPage, func(opt gitea.ListOptions) ([]*gitea.Release, *gitea.Response, error) {
+		return c.ListReleases(owner, name, gitea.ListReleasesOptions{ListOptions: opt})
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, release := range releases {
+		if err := writeMetadataFile(metadataItemPath(dir, metadataReleasesDir, release.ID), release); err != nil {
+			return err
+		}
+
+		for _, attachment := range release.Attachments {
+			downloadURL := attachment.DownloadURL
+			err := saveMetadataAsset(metadataAssetPath(dir, release.ID, attachment.Name), attachment.Size, func() (io.ReadCloser, error) {
+				return c.download(downloadURL)
+			})
+			if err != nil {
+				return fmt.Errorf("downloading asset %s: %w", attachment.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// download opens a file served by the gitea instance, authenticating with the token so attachments of private repos can be read
+func (c Gitea) download(downloadURL string) (io.ReadCloser, error) {
+	req, err := http.NewRequest("GET", downloadURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "token "+c.token)
+	}
+
+	hc := c.httpClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("unexpected response code %d downloading %s", resp.StatusCode, downloadURL)
+	}
+	return resp.Body, nil
+}
@@ -6,7 +6,10 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"code.gitea.io/sdk/gitea"
 )
@@ -60,6 +63,7 @@ func setupGiteaTest() (client Gitea, mux *http.ServeMux, serverURL string, teard
 
 	client = Gitea{
 		Client:  giteaClient,
+		baseURL: server.URL,
 		perPage: 10, // Small page size for testing pagination
 	}
 
@@ -361,3 +365,249 @@ func BenchmarkGitea_GetOrgRepos_LargePagination(b *testing.B) {
 		}
 	}
 }
+
+func TestGitea_GetMemberOrgs_Pagination(t *testing.T) {
+	client, mux, _, teardown := setupGiteaTest()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/user/orgs", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		orgs := []*gitea.Organization{}
+		if r.URL.Query().Get("page") == "1" {
+			for i := 0; i < 10; i++ {
+				orgs = append(orgs, &gitea.Organization{Name: fmt.Sprintf("org-%02d", i+1)})
+			}
+		} else {
+			orgs = append(orgs, &gitea.Organization{UserName: "org-11"})
+		}
+		_ = json.NewEncoder(w).Encode(orgs)
+	})
+
+	result, err := client.GetMemberOrgs()
+	if err != nil {
+		t.Fatalf("GetMemberOrgs failed: %v", err)
+	}
+
+	if len(result) != 11 {
+		t.Fatalf("Expected 11 orgs, got %d", len(result))
+	}
+
+	if result[0] != "org-01" || result[10] != "org-11" {
+		t.Errorf("Unexpected orgs: %v", result)
+	}
+}
+
+func TestGitea_GetUserStarredRepos_Pagination(t *testing.T) {
+	client, mux, _, teardown := setupGiteaTest()
+	defer teardown()
+
+	_ = os.Setenv("GHORG_CLONE_PROTOCOL", "https")
+	_ = os.Setenv("GHORG_CLONE_TYPE", "starred")
+	defer func() {
+		_ = os.Unsetenv("GHORG_CLONE_PROTOCOL")
+		_ = os.Unsetenv("GHORG_CLONE_TYPE")
+	}()
+
+	mux.HandleFunc("/api/v1/users/someone/starred", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		repos := []*gitea.Repository{}
+		if r.URL.Query().Get("page") == "1" {
+			for i := 0; i < 10; i++ {
+				repos = append(repos, mockGiteaRepository(int64(i+1), fmt.Sprintf("repo-%03d", i+1)))
+			}
+		} else {
+			repo := mockGiteaRepository(11, "repo-001")
+			repo.FullName = "other-owner/repo-001"
+			repos = append(repos, repo)
+		}
+		_ = json.NewEncoder(w).Encode(repos)
+	})
+
+	result, err := client.GetUserStarredRepos("someone")
+	if err != nil {
+		t.Fatalf("GetUserStarredRepos failed: %v", err)
+	}
+
+	if len(result) != 11 {
+		t.Fatalf("Expected 11 repositories across pages, got %d", len(result))
+	}
+
+	if result[0].Path != "test-org/repo-001" || result[10].Path != "other-owner/repo-001" {
+		t.Errorf("Expected repos to be organized by owner, got %s and %s", result[0].Path, result[10].Path)
+	}
+}
+
+func TestGitea_GetUserWatchedRepos_UserNotFound(t *testing.T) {
+	client, _, _, teardown := setupGiteaTest()
+	defer teardown()
+
+	_, err := client.GetUserWatchedRepos("nobody")
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Expected a not found error, got: %v", err)
+	}
+}
+
+func TestGitea_GetReviewRequests(t *testing.T) {
+	client, mux, _, teardown := setupGiteaTest()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/repos/test-org/repo-001/pulls", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("state"); got != "open" {
+			t.Errorf("Expected state=open, got: %s", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode([]*gitea.PullRequest{
+			{Index: 3, Title: "Open PR", State: gitea.StateOpen, Poster: &gitea.User{UserName: "alice"}, Head: &gitea.PRBranchInfo{Ref: "feature"}},
+		})
+	})
+
+	result, err := client.GetReviewRequests(Repo{Name: "repo-001", FullName: "test-org/repo-001"}, false)
+	if err != nil {
+		t.Fatalf("GetReviewRequests failed: %v", err)
+	}
+
+	if len(result) != 1 || result[0].Number != 3 || result[0].Author != "alice" || result[0].SourceBranch != "feature" {
+		t.Errorf("Unexpected pull requests: %+v", result)
+	}
+}
+
+func TestGitea_ExportMetadata_DownloadsAttachmentsOnce(t *testing.T) {
+	client, mux, serverURL, teardown := setupGiteaTest()
+	defer teardown()
+
+	client.token = "secret"
+	downloads := 0
+
+	writeJSON := func(v any) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(v)
+		}
+	}
+
+	mux.HandleFunc("/api/v1/repos/test-org/repo-001/issues", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("since") == "" {
+			t.Errorf("Expected issues to be listed with since")
+		}
+		writeJSON([]*gitea.Issue{{Index: 1, Title: "Bug"}})(w, r)
+	})
+	mux.HandleFunc("/api/v1/repos/test-org/repo-001/issues/1/comments", writeJSON([]*gitea.Comment{{ID: 2, Body: "me too"}}))
+	mux.HandleFunc("/api/v1/repos/test-org/repo-001/labels", writeJSON([]*gitea.Label{{Name: "bug"}}))
+	mux.HandleFunc("/api/v1/repos/test-org/repo-001/milestones", writeJSON([]*gitea.Milestone{{Title: "v1"}}))
+	mux.HandleFunc("/api/v1/repos/test-org/repo-001/releases", writeJSON([]*gitea.Release{{
+		ID:          3,
+		TagName:     "v1.0.0",
+		Attachments: []*gitea.Attachment{{Name: "app.tar.gz", Size: 7, DownloadURL: serverURL + "/attachments/app"}},
+	}}))
+	mux.HandleFunc("/attachments/app", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "token secret" {
+			t.Errorf("Expected attachment download to be authenticated")
+		}
+		downloads++
+		_, _ = fmt.Fprint(w, "archive")
+	})
+
+	dir := t.TempDir()
+	repo := Repo{Name: "repo-001", FullName: "test-org/repo-001"}
+	for i := 0; i < 2; i++ {
+		if err := client.ExportMetadata(repo, dir, time.Now().Add(-time.Hour)); err != nil {
+			t.Fatalf("ExportMetadata failed: %v", err)
+		}
+	}
+
+	if downloads != 1 {
+		t.Errorf("Expected the attachment to be downloaded once, got %d downloads", downloads)
+	}
+
+	for _, p := range []string{"issues/1.json", "releases/3.json", "releases/3/assets/app.tar.gz", "labels.json", "milestones.json"} {
+		if _, err := os.Stat(filepath.Join(dir, p)); err != nil {
+			t.Errorf("Expected %s to be exported: %v", p, err)
+		}
+	}
+}
+
+func TestGitea_FilterWikis(t *testing.T) {
+	t.Setenv("GHORG_CLONE_WIKI", "true")
+	t.Setenv("GHORG_CLONE_PROTOCOL", "https")
+
+	withWiki := mockGiteaRepository(1, "with-wiki")
+	withWiki.HasWiki = true
+	externalWiki := mockGiteaRepository(2, "external-wiki")
+	externalWiki.HasWiki = true
+	externalWiki.ExternalWiki = &gitea.ExternalWiki{ExternalWikiURL: "https://wiki.example.com"}
+	noWiki := mockGiteaRepository(3, "no-wiki")
+
+	client := Gitea{}
+	repos, err := client.filter([]*gitea.Repository{withWiki, externalWiki, noWiki})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wikis []Repo
+	for _, r := range repos {
+		if r.IsWiki {
+			wikis = append(wikis, r)
+		}
+	}
+	if len(wikis) != 1 {
+		t.Fatalf("Expected 1 wiki, got %d: %+v", len(wikis), wikis)
+	}
+	if want := "https://gitea.example.com/test-org/with-wiki.wiki.git"; wikis[0].CloneURL != want {
+		t.Errorf("Expected clone url %s, got %s", want, wikis[0].CloneURL)
+	}
+	if wikis[0].CloneBranch != "main" || wikis[0].Path != "with-wiki.wiki" || wikis[0].Name != "with-wiki" {
+		t.Errorf("Unexpected wiki %+v", wikis[0])
+	}
+}
+
+func TestGitea_FilterLastActivity(t *testing.T) {
+	t.Setenv("GHORG_CLONE_WIKI", "true")
+	t.Setenv("GHORG_CLONE_PROTOCOL", "https")
+
+	updated := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	repo := mockGiteaRepository(1, "active")
+	repo.Updated = updated
+	repo.HasWiki = true
+
+	client := Gitea{}
+	repos, err := client.filter([]*gitea.Repository{repo})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(repos) != 2 {
+		t.Fatalf("Expected a repo and its wiki, got %+v", repos)
+	}
+	for _, r := range repos {
+		if !r.LastActivityAt.Equal(updated) {
+			t.Errorf("Expected last activity %v for %s (wiki: %v), got %v", updated, r.Name, r.IsWiki, r.LastActivityAt)
+		}
+	}
+}
+
+func TestGitea_FilterSizeAndVisibility(t *testing.T) {
+	t.Setenv("GHORG_CLONE_PROTOCOL", "https")
+
+	public := mockGiteaRepository(1, "public")
+	public.Size = 2048
+	private := mockGiteaRepository(2, "private")
+	private.Private = true
+	internal := mockGiteaRepository(3, "internal")
+	internal.Internal = true
+
+	client := Gitea{}
+	repos, err := client.filter([]*gitea.Repository{public, private, internal})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{"public": "public", "private": "private", "internal": "internal"}
+	for _, r := range repos {
+		if r.Visibility != want[r.Name] {
+			t.Errorf("Expected visibility %s for %s, got %s", want[r.Name], r.Name, r.Visibility)
+		}
+	}
+	if repos[0].SizeKB != 2048 {
+		t.Errorf("Expected size 2048KB, got %d", repos[0].SizeKB)
+	}
+}
@@ -6,8 +6,6 @@ import (
 	"net/http"
 	"net/url"
 	"os"
-	"path/filepath"
-	"sort"
 	"strconv"
 	"strings"
 
@@ -18,6 +16,11 @@ import (
 
 // compile-time assertion that Github implements the Client interface
 var _ Client = Github{}
+var _ MembershipLister = Github{}
+var _ StarredLister = Github{}
+var _ WatchedLister = Github{}
+var _ ReviewRequestLister = Github{}
+var _ CloneURLAuthenticator = Github{}
 
 var (
 	reposPerPage  = 100
@@ -123,6 +126,126 @@ func (c Github) GetUserRepos(targetUser string) ([]Repo, error) {
 	return c.fetchUserReposParallel(targetUser, repos, resp.LastPage)
 }
 
+// GetMemberOrgs returns the login of every org the authenticated user is a member of
+func (c Github) GetMemberOrgs() ([]string, error) {
+	spinningSpinner.Start()
+	defer spinningSpinner.Stop()
+
+	opt := &github.ListOptions{PerPage: c.perPage, Page: 1}
+	orgs := []string{}
+
+	for {
+		page, resp, err := c.Organizations.List(context.Background(), "", opt)
+		if err != nil {
+			return nil, err
+		}
+		for _, org := range page {
+			orgs = append(orgs, org.GetLogin())
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+
+	return orgs, nil
+}
+
+// GetUserStarredRepos gets the repos a user has starred, an empty targetUser is the authenticated user
+func (c Github) GetUserStarredRepos(targetUser string) ([]Repo, error) {
+	c.SetTokensUsername()
+
+	spinningSpinner.Start()
+	defer spinningSpinner.Stop()
+
+	opt := &github.ActivityListStarredOptions{ListOptions: github.ListOptions{PerPage: c.perPage, Page: 1}}
+	var repos []*github.Repository
+
+	for {
+		starred, resp, err := c.Activity.ListStarred(context.Background(), targetUser, opt)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range starred {
+			repos = append(repos, s.GetRepository())
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+
+	return c.filter(repos), nil
+}
+
+// GetUserWatchedRepos gets the repos a user is watching, an empty targetUser is the authenticated user
+func (c Github) GetUserWatchedRepos(targetUser string) ([]Repo, error) {
+	c.SetTokensUsername()
+
+	spinningSpinner.Start()
+	defer spinningSpinner.Stop()
+
+	opt := &github.ListOptions{PerPage: c.perPage, Page: 1}
+	var repos []*github.Repository
+
+	for {
+		watched, resp, err := c.Activity.ListWatched(context.Background(), targetUser, opt)
+		if err != nil {
+			return nil, err
+		}
+		repos = append(repos, watched...)
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+
+	return c.filter(repos), nil
+}
+
+// GetReviewRequests gets the pull requests of a repo
+func (c Github) GetReviewRequests(repo Repo, includeClosed bool) ([]ReviewRequest, error) {
+	owner, name, ok := strings.Cut(repo.FullName, "/")
+	if !ok {
+		return nil, fmt.Errorf("could not determine the owner of %s", repo.Name)
+	}
+
+	state := "open"
+	if includeClosed {
+		state = "all"
+	}
+
+	opt := &github.PullRequestListOptions{State: state, ListOptions: github.ListOptions{PerPage: c.perPage, Page: 1}}
+	reviewRequests := []ReviewRequest{}
+
+	for {
+		prs, resp, err := c.PullRequests.List(context.Background(), owner, name, opt)
+		if err != nil {
+			return nil, err
+		}
+		for _, pr := range prs {
+			state := pr.GetState()
+			if pr.MergedAt != nil {
+				state = "merged"
+			}
+			reviewRequests = append(reviewRequests, ReviewRequest{
+				Number:       int64(pr.GetNumber()),
+				Title:        pr.GetTitle(),
+				Author:       pr.GetUser().GetLogin(),
+				State:        state,
+				URL:          pr.GetHTMLURL(),
+				SourceBranch: pr.GetHead().GetRef(),
+			})
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+
+	return reviewRequests, nil
+}
+
 // NewClient create new github scm client
 func (Github) NewClient() (Client, error) {
 	ctx := context.Background()
@@ -190,6 +313,32 @@ func (Github) addTokenToHTTPSCloneURL(url string, token string) string {
 	return "https://" + tokenUsername + ":" + token + "@" + splitURL[1]
 }
 
+// AuthenticateCloneURL adds the token to an https clone url the way listing repos does
+func (c Github) AuthenticateCloneURL(url string) string {
+	token := os.Getenv("GHORG_GITHUB_TOKEN")
+	if token == "" || !strings.HasPrefix(url, "https://") {
+		return url
+	}
+	if tokenUsername == "" {
+		c.SetTokensUsername()
+	}
+	return c.addTokenToHTTPSCloneURL(url, token)
+}
+
+// GetForkSource returns the full name of the repo at the root of the fork network of a fork. Listing repos
+// doesn't include it so the fork is looked up on its own.
+func (c Github) GetForkSource(fork Repo) (string, error) {
+	owner, name, _ := strings.Cut(fork.FullName, "/")
+	repo, _, err := c.Repositories.Get(context.Background(), owner, name)
+	if err != nil {
+		return "", err
+	}
+	if source := repo.GetSource().GetFullName(); source != "" {
+		return source, nil
+	}
+	return repo.GetParent().GetFullName(), nil
+}
+
 func (c Github) filter(allRepos []*github.Repository) []Repo {
 	var repoData []Repo
 
@@ -212,29 +361,32 @@ func (c Github) filter(allRepos []*github.Repository) []Repo {
 		}
 
 		// NOTE: for some reason forks do not always have a language field set so sometimes they get filtered out
-		if os.Getenv("GHORG_GITHUB_FILTER_LANGUAGE") != "" {
-			if ghRepo.Language != nil {
-				ghLang := strings.ToLower(*ghRepo.Language)
-				userLangs := strings.Split(strings.ToLower(os.Getenv("GHORG_GITHUB_FILTER_LANGUAGE")), ",")
-				matched := false
-				for _, userLang := range userLangs {
-					if ghLang == userLang {
-						matched = true
-						break
-					}
-				}
-				if !matched {
-					continue
-				}
-			} else {
-				continue
-			}
+		if !hasMatchingLanguage(ghRepo.GetLanguage()) {
+			continue
 		}
 
 		r := Repo{}
 
+		r.ID = strconv.FormatInt(ghRepo.GetID(), 10)
 		r.Name = *ghRepo.Name
+		r.FullName = ghRepo.GetFullName()
+		r.Language = ghRepo.GetLanguage()
+		r.Archived = ghRepo.GetArchived()
+		r.Fork = ghRepo.GetFork()
+		r.Topics = ghRepo.Topics
+		r.LastActivityAt = ghRepo.GetPushedAt().Time
+		r.SizeKB = int64(ghRepo.GetSize())
+		r.Visibility = ghRepo.GetVisibility()
+		if r.Visibility == "" {
+			r.Visibility = "public"
+			if ghRepo.GetPrivate() {
+				r.Visibility = "private"
+			}
+		}
 		r.Path = r.Name
+		if isOwnerOrganized() {
+			r.Path = ghRepo.GetFullName()
+		}
 
 		if os.Getenv("GHORG_BRANCH") == "" {
 			defaultBranch := ghRepo.GetDefaultBranch()
@@ -256,14 +408,9 @@ func (c Github) filter(allRepos []*github.Repository) []Repo {
 			repoData = append(repoData, r)
 		}
 
-		if ghRepo.GetHasWiki() && os.Getenv("GHORG_CLONE_WIKI") == "true" {
-			wiki := Repo{}
-			wiki.IsWiki = true
-			wiki.CloneURL = strings.Replace(r.CloneURL, ".git", ".wiki.git", 1)
-			wiki.URL = strings.Replace(r.URL, ".git", ".wiki.git", 1)
-			wiki.CloneBranch = "master"
-			wiki.Path = fmt.Sprintf("%s%s", r.Name, ".wiki")
-			repoData = append(repoData, wiki)
+		if ghRepo.GetHasWiki() && shouldCloneWikis() {
+			// github wikis always use master regardless of the repo's default branch
+			repoData = append(repoData, newWiki(r, r.Path+".wiki", "master"))
 		}
 	}
 
@@ -277,7 +424,13 @@ func (c Github) GetUserGists(targetUser string) ([]Repo, error) {
 	spinningSpinner.Start()
 	defer spinningSpinner.Stop()
 
+	filter, err := newGistFilter()
+	if err != nil {
+		return nil, err
+	}
+
 	opt := &github.GistListOptions{
+		Since:       filter.updatedSince,
 		ListOptions: github.ListOptions{PerPage: reposPerPage, Page: 1},
 	}
 
@@ -297,71 +450,59 @@ func (c Github) GetUserGists(targetUser string) ([]Repo, error) {
 		allGists = append(allGists, g...)
 	}
 
-	return c.filterGists(allGists), nil
+	return c.filterGists(allGists, filter)
 }
 
-// gistFolderName returns the folder name to use for a gist, derived from the
-// gist's primary filename (first filename alphabetically). The extension is
-// stripped and the result is lowercased. Falls back to the gist ID when the
-// gist has no files or the derived base name is empty.
+// gistFolderName returns the folder name to use for a gist, see snippetFolderName
 func gistFolderName(gist *github.Gist) string {
-	if len(gist.Files) == 0 {
-		return strings.ToLower(*gist.ID)
-	}
-
-	// Collect and sort filenames for a stable, deterministic result.
 	filenames := make([]string, 0, len(gist.Files))
 	for fn := range gist.Files {
 		filenames = append(filenames, string(fn))
 	}
-	sort.Strings(filenames)
-
-	firstName := filenames[0]
-	ext := filepath.Ext(firstName)
-	base := strings.ToLower(firstName[:len(firstName)-len(ext)])
-	if base == "" {
-		return strings.ToLower(*gist.ID)
-	}
-	return base
+	return snippetFolderName(gist.GetID(), filenames)
 }
 
-func (c Github) filterGists(allGists []*github.Gist) []Repo {
-	// First pass: compute a derived folder name for every valid gist and
-	// count how many gists share the same name so we can detect collisions.
-	type gistEntry struct {
-		gist       *github.Gist
-		folderName string
-	}
-
-	entries := make([]gistEntry, 0, len(allGists))
-	nameCount := make(map[string]int)
+func (c Github) filterGists(allGists []*github.Gist, filter gistFilter) ([]Repo, error) {
+	// Compute a derived folder name for every valid gist, appending the gist ID
+	// whenever two or more gists share the same derived name.
+	var valid []*github.Gist
+	var ids, names []string
 
 	for _, gist := range allGists {
-		if gist.ID == nil || gist.GitPullURL == nil {
+		if gist.ID == nil || gist.GitPullURL == nil || !filter.matches(gist) {
 			continue
 		}
-		name := gistFolderName(gist)
-		entries = append(entries, gistEntry{gist: gist, folderName: name})
-		nameCount[name]++
+
+		name := gistBaseFolderName(gist)
+		matched, err := gistNameMatchesRegex(name)
+		if err != nil {
+			return nil, err
+		}
+		if !matched {
+			continue
+		}
+
+		valid = append(valid, gist)
+		ids = append(ids, *gist.ID)
+		names = append(names, name)
 	}
 
-	// Second pass: build Repo records, appending the gist ID to the folder
-	// name whenever two or more gists share the same derived name.
-	var repoData []Repo
+	folderNames := uniqueSnippetFolderNames(ids, names)
 
-	for _, entry := range entries {
-		gist := entry.gist
-		folderName := entry.folderName
+	var repoData []Repo
 
-		if nameCount[folderName] > 1 {
-			folderName = folderName + "-" + *gist.ID
-		}
+	for i, gist := range valid {
+		folderName := folderNames[i]
 
 		r := Repo{}
 		r.ID = *gist.ID
 		r.Name = folderName
 		r.Path = folderName
 		r.IsGitHubGist = true
+		r.Visibility = "secret"
+		if gist.GetPublic() {
+			r.Visibility = "public"
+		}
 		if os.Getenv("GHORG_BRANCH") != "" {
 			r.CloneBranch = os.Getenv("GHORG_BRANCH")
 		} else {
@@ -381,7 +522,7 @@ func (c Github) filterGists(allGists []*github.Gist) []Repo {
 		repoData = append(repoData, r)
 	}
 
-	return repoData
+	return repoData, nil
 }
 
 // Sets the GitHub username tied to the github token to the package variable tokenUsername
@@ -0,0 +1,172 @@
+package scm
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/go-github/v72/github"
+)
+
+// maxGistDescriptionFolderNameLength keeps folder names derived from long descriptions readable
+const maxGistDescriptionFolderNameLength = 64
+
+// gistFilter holds the --gist-* filters that decide which of a user's gists are cloned
+type gistFilter struct {
+	// visibility is public or secret, anything else keeps both
+	visibility   string
+	description  *regexp.Regexp
+	extensions   map[string]bool
+	updatedSince time.Time
+}
+
+// parseGistUpdatedSince parses GHORG_GIST_UPDATED_SINCE, which is either a date (2006-01-02) or an RFC 3339 timestamp
+func parseGistUpdatedSince(value string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid GHORG_GIST_UPDATED_SINCE %q, expected a date like 2024-01-31 or an RFC 3339 timestamp", value)
+	}
+	return t, nil
+}
+
+// newGistFilter reads the gist filters from the environment
+func newGistFilter() (gistFilter, error) {
+	f := gistFilter{}
+
+	switch visibility := strings.ToLower(os.Getenv("GHORG_GIST_VISIBILITY")); visibility {
+	case "", "all":
+	case "public", "secret":
+		f.visibility = visibility
+	default:
+		return f, fmt.Errorf("invalid GHORG_GIST_VISIBILITY %q, must be one of all, public or secret", visibility)
+	}
+
+	if expr := os.Getenv("GHORG_GIST_DESCRIPTION_REGEX"); expr != "" {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return f, fmt.Errorf("invalid GHORG_GIST_DESCRIPTION_REGEX: %v", err)
+		}
+		f.description = re
+	}
+
+	if exts := os.Getenv("GHORG_GIST_FILE_EXTENSIONS"); exts != "" {
+		f.extensions = make(map[string]bool)
+		for _, ext := range strings.Split(exts, ",") {
+			ext = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(ext), "."))
+			if ext != "" {
+				f.extensions[ext] = true
+			}
+		}
+	}
+
+	switch folderName := os.Getenv("GHORG_GIST_FOLDER_NAME"); folderName {
+	case "", "filename", "description":
+	default:
+		return f, fmt.Errorf("invalid GHORG_GIST_FOLDER_NAME %q, must be filename or description", folderName)
+	}
+
+	if since := os.Getenv("GHORG_GIST_UPDATED_SINCE"); since != "" {
+		t, err := parseGistUpdatedSince(since)
+		if err != nil {
+			return f, err
+		}
+		f.updatedSince = t
+	}
+
+	return f, nil
+}
+
+// matches reports whether a gist passes every configured filter
+func (f gistFilter) matches(gist *github.Gist) bool {
+	if f.visibility == "public" && !gist.GetPublic() {
+		return false
+	}
+	if f.visibility == "secret" && gist.GetPublic() {
+		return false
+	}
+
+	if f.description != nil && !f.description.MatchString(gist.GetDescription()) {
+		return false
+	}
+
+	if f.extensions != nil && !gistHasFileExtension(gist, f.extensions) {
+		return false
+	}
+
+	if !f.updatedSince.IsZero() && gist.GetUpdatedAt().Before(f.updatedSince) {
+		return false
+	}
+
+	return true
+}
+
+// gistHasFileExtension reports whether any file of the gist has one of the extensions
+func gistHasFileExtension(gist *github.Gist, extensions map[string]bool) bool {
+	for fn := range gist.Files {
+		ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(string(fn)), "."))
+		if extensions[ext] {
+			return true
+		}
+	}
+	return false
+}
+
+// gistBaseFolderName returns the folder name of a gist before collisions are resolved. With
+// GHORG_GIST_FOLDER_NAME=description the description is used, falling back to the filename
+// when the gist has no description.
+func gistBaseFolderName(gist *github.Gist) string {
+	if os.Getenv("GHORG_GIST_FOLDER_NAME") == "description" {
+		if name := descriptionFolderName(gist.GetDescription()); name != "" {
+			return name
+		}
+	}
+	return gistFolderName(gist)
+}
+
+// descriptionFolderName turns a gist description into a lowercase, dash separated folder name
+func descriptionFolderName(description string) string {
+	words := strings.FieldsFunc(strings.ToLower(description), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	name := []rune(strings.Join(words, "-"))
+	if len(name) > maxGistDescriptionFolderNameLength {
+		name = name[:maxGistDescriptionFolderNameLength]
+	}
+
+	return strings.TrimRight(string(name), "-")
+}
+
+// gistNameMatchesRegex applies --match-regex and --exclude-match-regex to a gist folder name the
+// same way they are applied to repo names. Gists are matched here, before collisions add the gist
+// id to their folder names, so a pattern like ^notes$ matches every gist named notes.
+func gistNameMatchesRegex(name string) (bool, error) {
+	if expr := os.Getenv("GHORG_MATCH_REGEX"); expr != "" {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return false, fmt.Errorf("invalid GHORG_MATCH_REGEX: %v", err)
+		}
+		if !re.MatchString(name) {
+			return false, nil
+		}
+	}
+
+	if expr := os.Getenv("GHORG_EXCLUDE_MATCH_REGEX"); expr != "" {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return false, fmt.Errorf("invalid GHORG_EXCLUDE_MATCH_REGEX: %v", err)
+		}
+		if re.MatchString(name) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
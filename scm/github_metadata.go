@@ -0,0 +1,174 @@
+package scm
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v72/github"
+)
+
+var _ MetadataExporter = Github{}
+
+// githubIssueExport is the file written for each issue
+type githubIssueExport struct {
+	Issue    *github.Issue          `json:"issue"`
+	Comments []*github.IssueComment `json:"comments"`
+}
+
+// githubPullRequestExport is the file written for each pull request
+type githubPullRequestExport struct {
+	PullRequest    *github.PullRequest          `json:"pull_request"`
+	Comments       []*github.IssueComment       `json:"comments"`
+	ReviewComments []*github.PullRequestComment `json:"review_comments"`
+	Reviews        []*github.PullRequestReview  `json:"reviews"`
+}
+
+// githubListAll follows the pagination of a github list call until the last page
+func githubListAll[T any](perPage int, list func(opt github.ListOptions) ([]T, *github.Response, error)) ([]T, error) {
+	opt := github.ListOptions{PerPage: perPage, Page: 1}
+	var all []T
+
+	for {
+		items, resp, err := list(opt)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, items...)
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+
+	return all, nil
+}
+
+// ExportMetadata exports the issues, pull requests, releases, labels and milestones of a repo
+func (c Github) ExportMetadata(repo Repo, dir string, since time.Time) error {
+	owner, name, ok := strings.Cut(repo.FullName, "/")
+	if !ok {
+		return fmt.Errorf("could not determine the owner of %s", repo.Name)
+	}
+	ctx := context.Background()
+
+	if err := c.exportIssues(ctx, owner, name, dir, since); err != nil {
+		return fmt.Errorf("exporting issues: %w", err)
+	}
+
+	if err := c.exportReleases(ctx, owner, name, dir); err != nil {
+		return fmt.Errorf("exporting releases: %w", err)
+	}
+
+	labels, err := githubListAll(c.perPage, func(opt github.ListOptions) ([]*github.Label, *github.Response, error) {
+		return c.Issues.ListLabels(ctx, owner, name, &opt)
+	})
+	if err != nil {
+		return fmt.Errorf("exporting labels: %w", err)
+	}
+	if err := writeMetadataFile(filepath.Join(dir, metadataLabelsFile), labels); err != nil {
+		return err
+	}
+
+	milestones, err := githubListAll(c.perPage, func(opt github.ListOptions) ([]*github.Milestone, *github.Response, error) {
+		return c.Issues.ListMilestones(ctx, owner, name, &github.MilestoneListOptions{State: "all", ListOptions: opt})
+	})
+	if err != nil {
+		return fmt.Errorf("exporting milestones: %w", err)
+	}
+	return writeMetadataFile(filepath.Join(dir, metadataMilestonesFile), milestones)
+}
+
+// exportIssues exports issues and pull requests, github lists pull requests as issues
+func (c Github) exportIssues(ctx context.Context, owner, name, dir string, since time.Time) error {
+	issues, err := githubListAll(c.perPage, func(opt github.ListOptions) ([]*github.Issue, *github.Response, error) {
+		return c.Issues.ListByRepo(ctx, owner, name, &github.IssueListByRepoOptions{
+			State:       "all",
+			Since:       since,
+			Sort:        "updated",
+			Direction:   "asc",
+			ListOptions: opt,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, issue := range issues {
+		number := issue.GetNumber()
+
+		comments, err := githubListAll(c.perPage, func(opt github.ListOptions) ([]*github.IssueComment, *github.Response, error) {
+			return c.Issues.ListComments(ctx, owner, name, number, &github.IssueListCommentsOptions{ListOptions: opt})
+		})
+		if err != nil {
+			return err
+		}
+
+		if !issue.IsPullRequest() {
+			export := githubIssueExport{Issue: issue, Comments: comments}
+			if err := writeMetadataFile(metadataItemPath(dir, metadataIssuesDir, int64(number)), export); err != nil {
+				return err
+			}
+			continue
+		}
+
+		pr, _, err := c.PullRequests.Get(ctx, owner, name, number)
+		if err != nil {
+			return err
+		}
+
+		reviewComments, err := githubListAll(c.perPage, func(opt github.ListOptions) ([]*github.PullRequestComment, *github.Response, error) {
+			return c.PullRequests.ListComments(ctx, owner, name, number, &github.PullRequestListCommentsOptions{ListOptions: opt})
+		})
+		if err != nil {
+			return err
+		}
+
+		reviews, err := githubListAll(c.perPage, func(opt github.ListOptions) ([]*github.PullRequestReview, *github.Response, error) {
+			return c.PullRequests.ListReviews(ctx, owner, name, number, &opt)
+		})
+		if err != nil {
+			return err
+		}
+
+		export := githubPullRequestExport{PullRequest: pr, Comments: comments, ReviewComments: reviewComments, Reviews: reviews}
+		if err := writeMetadataFile(metadataItemPath(dir, metadataPullRequestsDir, int64(number)), export); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// exportReleases exports every release and downloads its assets
+func (c Github) exportReleases(ctx context.Context, owner, name, dir string) error {
+	releases, err := githubListAll(c.perPage, func(opt github.ListOptions) ([]*github.RepositoryRelease, *github.Response, error) {
+		return c.Repositories.ListReleases(ctx, owner, name, &opt)
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, release := range releases {
+		if err := writeMetadataFile(metadataItemPath(dir, metadataReleasesDir, release.GetID()), release); err != nil {
+			return err
+		}
+
+		for _, asset := range release.Assets {
+			assetID := asset.GetID()
+			err := saveMetadataAsset(metadataAssetPath(dir, release.GetID(), asset.GetName()), int64(asset.GetSize()), func() (io.ReadCloser, error) {
+				rc, _, err := c.Repositories.DownloadReleaseAsset(ctx, owner, name, assetID, http.DefaultClient)
+				return rc, err
+			})
+			if err != nil {
+				return fmt.Errorf("downloading asset %s: %w", asset.GetName(), err)
+			}
+		}
+	}
+
+	return nil
+}
@@ -6,6 +6,9 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -345,6 +348,142 @@ func TestFilterGistsCollisions(t *testing.T) {
 	}
 }
 
+func TestGetUserGists_Filters(t *testing.T) {
+	client, mux, _, teardown := setup()
+	gh := Github{Client: client}
+	defer teardown()
+
+	var since string
+	mux.HandleFunc("/users/filteruser/gists", func(w http.ResponseWriter, r *http.Request) {
+		since = r.URL.Query().Get("since")
+		_, _ = fmt.Fprint(w, `[
+			{"id":"aaa111", "git_pull_url": "https://gist.github.com/aaa111.git", "public": true, "description": "Deploy script for staging", "updated_at": "2024-03-01T00:00:00Z", "files": {"deploy.sh": {}}},
+			{"id":"bbb222", "git_pull_url": "https://gist.github.com/bbb222.git", "public": false, "description": "Meeting notes", "updated_at": "2023-06-01T00:00:00Z", "files": {"notes.md": {}}},
+			{"id":"ccc333", "git_pull_url": "https://gist.github.com/ccc333.git", "public": true, "description": "", "updated_at": "2024-05-01T00:00:00Z", "files": {"notes.py": {}, "utils.py": {}}}
+		]`)
+	})
+
+	tests := []struct {
+		name      string
+		env       map[string]string
+		wantNames []string
+		wantErr   bool
+	}{
+		{
+			name:      "no filters",
+			wantNames: []string{"deploy", "notes-bbb222", "notes-ccc333"},
+		},
+		{
+			name:      "public gists",
+			env:       map[string]string{"GHORG_GIST_VISIBILITY": "public"},
+			wantNames: []string{"deploy", "notes"},
+		},
+		{
+			name:      "secret gists",
+			env:       map[string]string{"GHORG_GIST_VISIBILITY": "secret"},
+			wantNames: []string{"notes"},
+		},
+		{
+			name:      "description regex",
+			env:       map[string]string{"GHORG_GIST_DESCRIPTION_REGEX": "(?i)script"},
+			wantNames: []string{"deploy"},
+		},
+		{
+			name:      "file extensions",
+			env:       map[string]string{"GHORG_GIST_FILE_EXTENSIONS": ".md, SH"},
+			wantNames: []string{"deploy", "notes"},
+		},
+		{
+			name:      "file extension only in one gist",
+			env:       map[string]string{"GHORG_GIST_FILE_EXTENSIONS": "py"},
+			wantNames: []string{"notes"},
+		},
+		{
+			name:      "updated since",
+			env:       map[string]string{"GHORG_GIST_UPDATED_SINCE": "2024-01-01"},
+			wantNames: []string{"deploy", "notes"},
+		},
+		{
+			name:      "description folder names",
+			env:       map[string]string{"GHORG_GIST_FOLDER_NAME": "description"},
+			wantNames: []string{"deploy-script-for-staging", "meeting-notes", "notes"},
+		},
+		{
+			name:      "match regex is applied before collisions are resolved",
+			env:       map[string]string{"GHORG_MATCH_REGEX": "^notes$"},
+			wantNames: []string{"notes-bbb222", "notes-ccc333"},
+		},
+		{
+			name:      "exclude match regex",
+			env:       map[string]string{"GHORG_EXCLUDE_MATCH_REGEX": "^notes$"},
+			wantNames: []string{"deploy"},
+		},
+		{
+			name:    "invalid visibility",
+			env:     map[string]string{"GHORG_GIST_VISIBILITY": "internal"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid updated since",
+			env:     map[string]string{"GHORG_GIST_UPDATED_SINCE": "last week"},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(tt *testing.T) {
+			tt.Setenv("GHORG_CLONE_PROTOCOL", "https")
+			tt.Setenv("GHORG_GITHUB_TOKEN", "testtoken")
+			for _, env := range []string{"GHORG_GIST_VISIBILITY", "GHORG_GIST_DESCRIPTION_REGEX", "GHORG_GIST_FILE_EXTENSIONS", "GHORG_GIST_UPDATED_SINCE", "GHORG_GIST_FOLDER_NAME", "GHORG_MATCH_REGEX", "GHORG_EXCLUDE_MATCH_REGEX"} {
+				tt.Setenv(env, tc.env[env])
+			}
+
+			resp, err := gh.GetUserGists("filteruser")
+			if tc.wantErr {
+				if err == nil {
+					tt.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				tt.Fatal(err)
+			}
+
+			var names []string
+			for _, repo := range resp {
+				names = append(names, repo.Name)
+			}
+			if !reflect.DeepEqual(names, tc.wantNames) {
+				tt.Errorf("expected gists %v, got %v", tc.wantNames, names)
+			}
+
+			if tc.env["GHORG_GIST_UPDATED_SINCE"] != "" && since != "2024-01-01T00:00:00Z" {
+				tt.Errorf("expected since to be sent to the api, got %q", since)
+			}
+		})
+	}
+}
+
+func TestDescriptionFolderName(t *testing.T) {
+	tests := []struct {
+		description string
+		want        string
+	}{
+		{"Deploy script for staging", "deploy-script-for-staging"},
+		{"  --Fix: the *thing*!  ", "fix-the-thing"},
+		{"Ünïcode Notes", "ünïcode-notes"},
+		{"!!!", ""},
+		{strings.Repeat("a", 70), strings.Repeat("a", 64)},
+		{strings.Repeat("a", 63) + " b", strings.Repeat("a", 63)},
+	}
+
+	for _, tc := range tests {
+		if got := descriptionFolderName(tc.description); got != tc.want {
+			t.Errorf("descriptionFolderName(%q): expected %q, got %q", tc.description, tc.want, got)
+		}
+	}
+}
+
 func TestGetOrgRepos_MultiPageRespectsListConcurrency(t *testing.T) {
 	mux := http.NewServeMux()
 	var mu sync.Mutex
@@ -418,3 +557,196 @@ func TestGetOrgRepos_MultiPageRespectsListConcurrency(t *testing.T) {
 		t.Fatalf("max concurrent list requests was %d, want 2 to confirm bounded parallelism", maxInFlight)
 	}
 }
+
+func TestGetMemberOrgs(t *testing.T) {
+	client, mux, serverURL, teardown := setup()
+
+	github := Github{Client: client, perPage: 2}
+
+	defer teardown()
+
+	mux.HandleFunc("/user/orgs", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "2" {
+			_, _ = fmt.Fprint(w, `[{"login": "org-c"}]`)
+			return
+		}
+		w.Header().Set("Link", fmt.Sprintf(`<%s%s/user/orgs?page=2>; rel="next"`, serverURL, baseURLPath))
+		_, _ = fmt.Fprint(w, `[{"login": "org-a"}, {"login": "org-b"}]`)
+	})
+
+	got, err := github.GetMemberOrgs()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"org-a", "org-b", "org-c"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Expected orgs %v, got: %v", want, got)
+	}
+}
+
+func TestGetUserStarredRepos(t *testing.T) {
+	client, mux, _, teardown := setup()
+
+	github := Github{Client: client}
+
+	defer teardown()
+
+	_ = os.Setenv("GHORG_CLONE_TYPE", "starred")
+	defer func() { _ = os.Unsetenv("GHORG_CLONE_TYPE") }()
+
+	mux.HandleFunc("/user/starred", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `[
+			{"starred_at": "2024-01-01T00:00:00Z", "repo": {"id":1, "clone_url": "https://example.com/a/tools.git", "name": "tools", "full_name": "a/tools", "ssh_url": "git@example.com:a/tools.git"}},
+			{"starred_at": "2024-01-02T00:00:00Z", "repo": {"id":2, "clone_url": "https://example.com/b/tools.git", "name": "tools", "full_name": "b/tools", "ssh_url": "git@example.com:b/tools.git"}}
+			]`)
+	})
+
+	got, err := github.GetUserStarredRepos("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"a/tools", "b/tools"}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d repos, got: %d", len(want), len(got))
+	}
+	for i, repo := range got {
+		if repo.Path != want[i] {
+			t.Errorf("Expected repo path %s, got: %s", want[i], repo.Path)
+		}
+	}
+}
+
+func TestGetUserWatchedRepos(t *testing.T) {
+	client, mux, _, teardown := setup()
+
+	github := Github{Client: client}
+
+	defer teardown()
+
+	_ = os.Setenv("GHORG_CLONE_TYPE", "watched")
+	defer func() { _ = os.Unsetenv("GHORG_CLONE_TYPE") }()
+
+	mux.HandleFunc("/users/someone/subscriptions", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `[{"id":1, "clone_url": "https://example.com/a/tools.git", "name": "tools", "full_name": "a/tools", "ssh_url": "git@example.com:a/tools.git"}]`)
+	})
+
+	got, err := github.GetUserWatchedRepos("someone")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 1 || got[0].Path != "a/tools" {
+		t.Errorf("Expected a/tools to be returned, got: %v", got)
+	}
+}
+
+func TestGetReviewRequests(t *testing.T) {
+	client, mux, _, teardown := setup()
+
+	github := Github{Client: client}
+
+	defer teardown()
+
+	mux.HandleFunc("/repos/testorg/foobar/pulls", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("state"); got != "all" {
+			t.Errorf("Expected state=all, got: %s", got)
+		}
+		_, _ = fmt.Fprint(w, `[
+			{"number": 2, "title": "Open PR", "state": "open", "user": {"login": "alice"}, "head": {"ref": "feature"}},
+			{"number": 1, "title": "Merged PR", "state": "closed", "merged_at": "2024-01-01T00:00:00Z", "user": {"login": "bob"}, "head": {"ref": "fix"}}
+			]`)
+	})
+
+	got, err := github.GetReviewRequests(Repo{Name: "foobar", FullName: "testorg/foobar"}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("Expected 2 pull requests, got: %d", len(got))
+	}
+	if got[0].Number != 2 || got[0].Author != "alice" || got[0].SourceBranch != "feature" || got[0].State != "open" {
+		t.Errorf("Unexpected pull request: %+v", got[0])
+	}
+	if got[1].State != "merged" {
+		t.Errorf("Expected merged pull request state, got: %s", got[1].State)
+	}
+}
+
+func TestExportMetadata(t *testing.T) {
+	client, mux, _, teardown := setup()
+
+	github := Github{Client: client, perPage: 100}
+
+	defer teardown()
+
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	mux.HandleFunc("/repos/testorg/foobar/issues", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("since"); got != since.Format(time.RFC3339) {
+			t.Errorf("Expected since=%s, got: %s", since.Format(time.RFC3339), got)
+		}
+		_, _ = fmt.Fprint(w, `[
+			{"number": 1, "title": "Bug"},
+			{"number": 2, "title": "Fix", "pull_request": {"url": "https://example.com"}}
+			]`)
+	})
+	mux.HandleFunc("/repos/testorg/foobar/issues/1/comments", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `[{"id": 10, "body": "me too"}]`)
+	})
+	mux.HandleFunc("/repos/testorg/foobar/issues/2/comments", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `[]`)
+	})
+	mux.HandleFunc("/repos/testorg/foobar/pulls/2", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `{"number": 2, "title": "Fix"}`)
+	})
+	mux.HandleFunc("/repos/testorg/foobar/pulls/2/comments", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `[{"id": 20, "body": "nit"}]`)
+	})
+	mux.HandleFunc("/repos/testorg/foobar/pulls/2/reviews", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `[{"id": 30, "state": "APPROVED"}]`)
+	})
+	mux.HandleFunc("/repos/testorg/foobar/releases", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `[{"id": 5, "tag_name": "v1.0.0", "assets": [{"id": 50, "name": "app.tar.gz", "size": 7}]}]`)
+	})
+	mux.HandleFunc("/repos/testorg/foobar/releases/assets/50", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		_, _ = fmt.Fprint(w, "archive")
+	})
+	mux.HandleFunc("/repos/testorg/foobar/labels", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `[{"name": "bug"}]`)
+	})
+	mux.HandleFunc("/repos/testorg/foobar/milestones", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `[{"number": 1, "title": "v1"}]`)
+	})
+	dir := t.TempDir()
+	err := github.ExportMetadata(Repo{Name: "foobar", FullName: "testorg/foobar"}, dir, since)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range []string{"issues/1.json", "pulls/2.json", "releases/5.json", "labels.json", "milestones.json"} {
+		if _, err := os.Stat(filepath.Join(dir, p)); err != nil {
+			t.Errorf("Expected %s to be exported: %v", p, err)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "issues/2.json")); err == nil {
+		t.Errorf("Expected pull requests to only be exported into pulls")
+	}
+
+	asset, err := os.ReadFile(filepath.Join(dir, "releases/5/assets/app.tar.gz"))
+	if err != nil || string(asset) != "archive" {
+		t.Errorf("Expected release asset to be downloaded, got: %q %v", asset, err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "pulls/2.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"APPROVED"`) || !strings.Contains(string(data), `"nit"`) {
+		t.Errorf("Expected reviews and review comments in pull request export, got: %s", data)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/gabrie30/ghorg/colorlog"
 	gitlab "gitlab.com/gitlab-org/api/client-go"
@@ -21,6 +22,10 @@ var (
 
 // compile-time assertion that Gitlab implements the Client interface
 var _ Client = Gitlab{}
+var _ MembershipLister = Gitlab{}
+var _ StarredLister = Gitlab{}
+var _ ReviewRequestLister = Gitlab{}
+var _ CloneURLAuthenticator = Gitlab{}
 
 func init() {
 	registerClient(Gitlab{})
@@ -104,6 +109,14 @@ func (c Gitlab) GetOrgRepos(targetOrg string) ([]Repo, error) {
 
 		repoData = append(repoData, repos...)
 
+		if shouldCloneWikis() {
+			wikis, err := c.GetGroupWikis(group)
+			if err != nil {
+				spinningSpinner.Stop()
+				colorlog.PrintError(fmt.Sprintf("Error getting group wikis for group '%s', error: %v", group, err))
+			}
+			repoData = append(repoData, wikis...)
+		}
 	}
 
 	// A project shared with another group is returned both by the group that owns
@@ -140,32 +153,65 @@ func dedupeReposByCloneURL(repos []Repo) []Repo {
 
 // GetTopLevelGroups all top level org groups with parallel pagination
 func (c Gitlab) GetTopLevelGroups() ([]string, error) {
-	opt := &gitlab.ListGroupsOptions{
-		ListOptions: gitlab.ListOptions{
-			PerPage: int64(perPage),
-			Page:    1,
-		},
-		TopLevelOnly: &[]bool{true}[0],
-		AllAvailable: &[]bool{true}[0],
+	groups, err := c.listTopLevelGroups(func(page int) *gitlab.ListGroupsOptions {
+		return &gitlab.ListGroupsOptions{
+			ListOptions: gitlab.ListOptions{
+				PerPage: int64(perPage),
+				Page:    int64(page),
+			},
+			TopLevelOnly: &[]bool{true}[0],
+			AllAvailable: &[]bool{true}[0],
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	allGroups := make([]string, 0, len(groups))
+	for _, g := range groups {
+		allGroups = append(allGroups, strconv.FormatInt(int64(g.ID), 10))
+	}
+	return allGroups, nil
+}
+
+// GetMemberOrgs returns the full path of every top level group the token is a member of
+func (c Gitlab) GetMemberOrgs() ([]string, error) {
+	groups, err := c.listTopLevelGroups(func(page int) *gitlab.ListGroupsOptions {
+		return &gitlab.ListGroupsOptions{
+			ListOptions: gitlab.ListOptions{
+				PerPage: int64(perPage),
+				Page:    int64(page),
+			},
+			TopLevelOnly:   &[]bool{true}[0],
+			MinAccessLevel: gitlab.Ptr(gitlab.GuestPermissions),
+		}
+	})
+	if err != nil {
+		return nil, err
 	}
 
+	memberGroups := make([]string, 0, len(groups))
+	for _, g := range groups {
+		memberGroups = append(memberGroups, g.FullPath)
+	}
+	return memberGroups, nil
+}
+
+// listTopLevelGroups fetches every page of groups returned for listOptions
+func (c Gitlab) listTopLevelGroups(listOptions func(page int) *gitlab.ListGroupsOptions) ([]*gitlab.Group, error) {
 	// Fetch first page to discover total number of pages
-	groups, resp, err := c.Groups.ListGroups(opt)
+	groups, resp, err := c.Groups.ListGroups(listOptions(1))
 	if err != nil {
 		return nil, err
 	}
 
 	// If only one page, return immediately
 	if resp.TotalPages <= 1 {
-		allGroups := make([]string, 0, len(groups))
-		for _, g := range groups {
-			allGroups = append(allGroups, strconv.FormatInt(int64(g.ID), 10))
-		}
-		return allGroups, nil
+		return groups, nil
 	}
 
 	// Multiple pages - fetch remaining pages in parallel
-	return c.fetchTopLevelGroupsParallel(groups, int(resp.TotalPages))
+	return c.fetchTopLevelGroupsParallel(groups, int(resp.TotalPages), listOptions)
 }
 
 // In this case take the cloneURL from the cloneTartet repo and just inject /snippets/:id before the .git
@@ -304,6 +350,9 @@ func (c Gitlab) GetSnippets(cloneData []Repo, target string) ([]Repo, error) {
 		// Iterate over all projects in the group. If it has snippets add them
 		colorlog.PrintInfo("Note: only snippets you have access to will be cloned. This process may take a while depending on the size of group you are trying to clone, please be patient.")
 		for _, repo := range cloneData {
+			if repo.IsWiki {
+				continue
+			}
 			snippets := c.getRepoSnippets(repo)
 			allSnippetsToClone = append(allSnippetsToClone, snippets...)
 		}
@@ -313,6 +362,9 @@ func (c Gitlab) GetSnippets(cloneData []Repo, target string) ([]Repo, error) {
 		// if its an all-user or all-group clone, for each repo get its snippets then also include all root level snippets
 		if target == "all-users" || target == "all-groups" {
 			for _, repo := range cloneData {
+				if repo.IsWiki {
+					continue
+				}
 				repoSnippets := c.getRepoSnippets(repo)
 				allSnippetsToClone = append(allSnippetsToClone, repoSnippets...)
 			}
@@ -325,6 +377,9 @@ func (c Gitlab) GetSnippets(cloneData []Repo, target string) ([]Repo, error) {
 		} else if os.Getenv("GHORG_CLONE_TYPE") != "user" {
 			// Handle single group clones on hosted instances
 			for _, repo := range cloneData {
+				if repo.IsWiki {
+					continue
+				}
 				repoSnippets := c.getRepoSnippets(repo)
 				allSnippetsToClone = append(allSnippetsToClone, repoSnippets...)
 			}
@@ -342,6 +397,7 @@ func (c Gitlab) GetSnippets(cloneData []Repo, target string) ([]Repo, error) {
 		s.Name = snippetTitle
 		s.GitLabSnippetInfo.ID = snippetID
 		s.URL = snippet.WebURL
+		s.Visibility = snippet.Visibility
 		// If the snippet is not made on any repo its a root level snippet, this works for cloud
 		if c.rootLevelSnippet(snippet.WebURL) {
 			s.IsGitLabRootLevelSnippet = true
@@ -350,7 +406,7 @@ func (c Gitlab) GetSnippets(cloneData []Repo, target string) ([]Repo, error) {
 		} else {
 			// Since this isn't a root level repo we want to find which repo the snippet is coming from
 			for _, cloneTarget := range cloneData {
-				if cloneTarget.ID == strconv.FormatInt(snippet.ProjectID, 10) {
+				if !cloneTarget.IsWiki && cloneTarget.ID == strconv.FormatInt(snippet.ProjectID, 10) {
 					s.CloneURL = c.createRepoSnippetCloneURL(cloneTarget.CloneURL, snippetID)
 					s.Path = cloneTarget.Path
 					s.GitLabSnippetInfo.URLOfRepo = cloneTarget.URL
@@ -366,6 +422,15 @@ func (c Gitlab) GetSnippets(cloneData []Repo, target string) ([]Repo, error) {
 	return snippetsToClone, nil
 }
 
+// shouldRequestRepoSizes reports whether project statistics, which hold the repo size, need to be
+// requested. They are expensive for gitlab to compute so they are only asked for when filtering by size.
+func shouldRequestRepoSizes() bool {
+	return os.Getenv("GHORG_MIN_REPO_SIZE") != "" || os.Getenv("GHORG_MAX_REPO_SIZE") != ""
+}
+
+// missingRepoSizeNotice tells once per run that projects without statistics can't be filtered by size
+var missingRepoSizeNotice sync.Once
+
 // GetGroupRepos fetches repo data from a specific group with parallel pagination
 func (c Gitlab) GetGroupRepos(targetGroup string) ([]Repo, error) {
 	opt := &gitlab.ListGroupProjectsOptions{
@@ -395,6 +460,57 @@ func (c Gitlab) GetGroupRepos(targetGroup string) ([]Repo, error) {
 	return c.fetchGroupReposParallel(targetGroup, ps, int(resp.TotalPages))
 }
 
+// GetGroupWikis returns the wikis of targetGroup and its subgroups. Group wikis are a premium
+// feature, instances without them don't return a wiki access level so no wikis are listed.
+func (c Gitlab) GetGroupWikis(targetGroup string) ([]Repo, error) {
+	group, _, err := c.Groups.GetGroup(targetGroup, &gitlab.GetGroupOptions{WithProjects: gitlab.Ptr(false)})
+	if err != nil {
+		return nil, err
+	}
+	groups := []*gitlab.Group{group}
+
+	descendants, err := gitlabListAll(func(opt gitlab.ListOptions) ([]*gitlab.Group, *gitlab.Response, error) {
+		return c.Groups.ListDescendantGroups(targetGroup, &gitlab.ListDescendantGroupsOptions{ListOptions: opt})
+	})
+	if err != nil {
+		return nil, err
+	}
+	groups = append(groups, descendants...)
+
+	wikis := []Repo{}
+	for _, g := range groups {
+		if g.WikiAccessLevel == "" || g.WikiAccessLevel == gitlab.DisabledAccessControl {
+			continue
+		}
+		wikis = append(wikis, c.groupWiki(targetGroup, g))
+	}
+
+	return wikis, nil
+}
+
+// groupWiki returns the wiki of a group, gitlab serves it as <group full path>.wiki.git
+func (c Gitlab) groupWiki(targetGroup string, g *gitlab.Group) Repo {
+	api := c.BaseURL()
+	webRoot := fmt.Sprintf("%s://%s%s", api.Scheme, api.Host, strings.TrimSuffix(strings.TrimSuffix(api.Path, "/"), "/api/v4"))
+
+	r := Repo{Name: g.Path, CloneBranch: "master", Visibility: string(g.Visibility)}
+	if os.Getenv("GHORG_CLONE_PROTOCOL") == "https" {
+		r.URL = fmt.Sprintf("%s/%s.git", webRoot, g.FullPath)
+		r.CloneURL = c.addTokenToCloneURL(r.URL, os.Getenv("GHORG_GITLAB_TOKEN"))
+	} else {
+		r.URL = fmt.Sprintf("git@%s:%s.git", api.Hostname(), g.FullPath)
+		r.CloneURL = ReplaceSSHHostname(r.URL, os.Getenv("GHORG_SSH_HOSTNAME"))
+	}
+
+	path := gitlabLocalPath(targetGroup, g.FullPath)
+	if path == "" {
+		// the wiki of the target group itself sits at the top of the clone directory
+		path = g.Path
+	}
+
+	return newWiki(r, path+".wiki", r.CloneBranch)
+}
+
 // GetUserRepos gets all of a users gitlab repos
 func (c Gitlab) GetUserRepos(targetUsername string) ([]Repo, error) {
 	cloneData := []Repo{}
@@ -405,6 +521,7 @@ func (c Gitlab) GetUserRepos(targetUsername string) ([]Repo, error) {
 			PerPage: int64(perPage),
 			Page:    1,
 		},
+		Statistics: gitlab.Ptr(shouldRequestRepoSizes()),
 	}
 
 	userOpts := &gitlab.ListUsersOptions{
@@ -472,6 +589,89 @@ func (c Gitlab) GetUserRepos(targetUsername string) ([]Repo, error) {
 	return cloneData, nil
 }
 
+// GetUserStarredRepos gets the projects a user has starred, an empty targetUser is the authenticated user
+func (c Gitlab) GetUserStarredRepos(targetUser string) ([]Repo, error) {
+	spinningSpinner.Start()
+	defer spinningSpinner.Stop()
+
+	repoData := []Repo{}
+	opt := &gitlab.ListProjectsOptions{
+		ListOptions: gitlab.ListOptions{
+			PerPage: int64(perPage),
+			Page:    1,
+		},
+		Statistics: gitlab.Ptr(shouldRequestRepoSizes()),
+	}
+
+	for {
+		var ps []*gitlab.Project
+		var resp *gitlab.Response
+		var err error
+
+		if targetUser == "" {
+			opt.Starred = gitlab.Ptr(true)
+			ps, resp, err = c.Projects.ListProjects(opt)
+		} else {
+			ps, resp, err = c.Projects.ListUserStarredProjects(targetUser, opt)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error getting starred projects, err: %v", err)
+		}
+
+		// An empty group keeps the full namespace in the path so projects are organized by owner
+		repoData = append(repoData, c.filter("", ps)...)
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+
+	return repoData, nil
+}
+
+// GetReviewRequests gets the merge requests of a project
+func (c Gitlab) GetReviewRequests(repo Repo, includeClosed bool) ([]ReviewRequest, error) {
+	opt := &gitlab.ListProjectMergeRequestsOptions{
+		ListOptions: gitlab.ListOptions{
+			PerPage: int64(perPage),
+			Page:    1,
+		},
+	}
+	if !includeClosed {
+		opt.State = gitlab.Ptr("opened")
+	}
+
+	reviewRequests := []ReviewRequest{}
+	for {
+		mrs, resp, err := c.MergeRequests.ListProjectMergeRequests(repo.ID, opt)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, mr := range mrs {
+			r := ReviewRequest{
+				Number:       mr.IID,
+				Title:        mr.Title,
+				State:        mr.State,
+				URL:          mr.WebURL,
+				SourceBranch: mr.SourceBranch,
+			}
+			if mr.Author != nil {
+				r.Author = mr.Author.Username
+			}
+			reviewRequests = append(reviewRequests, r)
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+
+	return reviewRequests, nil
+}
+
 // NewClient create new gitlab scm client
 func (Gitlab) NewClient() (Client, error) {
 	baseURL := os.Getenv("GHORG_SCM_BASE_URL")
@@ -512,11 +712,18 @@ func (Gitlab) addTokenToCloneURL(url string, token string) string {
 	return splitURL[0] + "://oauth2:" + token + "@" + splitURL[1]
 }
 
+// AuthenticateCloneURL adds the token to an http clone url the way listing repos does
+func (c Gitlab) AuthenticateCloneURL(url string) string {
+	token := os.Getenv("GHORG_GITLAB_TOKEN")
+	if token == "" || !strings.HasPrefix(url, "http") {
+		return url
+	}
+	return c.addTokenToCloneURL(url, token)
+}
+
 func (c Gitlab) filter(group string, ps []*gitlab.Project) []Repo {
 	var repoData []Repo
 
-	isSubgroup := strings.Contains(group, "/")
-
 	for _, p := range ps {
 
 		if os.Getenv("GHORG_SKIP_ARCHIVED") == "true" {
@@ -553,10 +760,55 @@ func (c Gitlab) filter(group string, ps []*gitlab.Project) []Repo {
 			}
 		}
 
+		// gitlab only reports languages per project so the cheaper filters above run first
+		var language string
+		if filterLanguages() != nil || filterExpressionUsesLanguage() {
+			languages, err := c.projectLanguages(p.ID)
+			if err != nil {
+				colorlog.PrintError(fmt.Sprintf("Error getting languages for project %s, it will not be cloned: %v", p.PathWithNamespace, err))
+				continue
+			}
+			if !hasMatchingLanguageBreakdown(languages) {
+				continue
+			}
+			language = primaryLanguage(languages)
+		}
+
+		// group listings can't ask for statistics, so with a size filter they are requested per project
+		statistics := p.Statistics
+		if statistics == nil && shouldRequestRepoSizes() {
+			project, _, err := c.Projects.GetProject(p.ID, &gitlab.GetProjectOptions{Statistics: gitlab.Ptr(true)})
+			if err != nil {
+				colorlog.PrintError(fmt.Sprintf("Error getting the size of project %s, it will not be cloned: %v", p.PathWithNamespace, err))
+				continue
+			}
+			statistics = project.Statistics
+		}
+		if statistics == nil && shouldRequestRepoSizes() {
+			missingRepoSizeNotice.Do(func() {
+				colorlog.PrintInfo("GitLab only reports the size of projects the token has at least reporter access to, projects without a size are kept by --min-repo-size and --max-repo-size")
+			})
+		}
+
 		r := Repo{}
 
 		r.Name = p.Name
 		r.ID = strconv.FormatInt(int64(p.ID), 10)
+		r.Language = language
+		r.Archived = p.Archived
+		r.Fork = p.ForkedFromProject != nil
+		if p.ForkedFromProject != nil {
+			r.UpstreamPath = p.ForkedFromProject.PathWithNamespace
+		}
+		r.Topics = p.Topics
+		if p.LastActivityAt != nil {
+			r.LastActivityAt = *p.LastActivityAt
+		}
+		r.Visibility = string(p.Visibility)
+		// statistics are only returned when requested and the token has at least reporter access
+		if statistics != nil {
+			r.SizeKB = statistics.RepositorySize / 1024
+		}
 
 		if os.Getenv("GHORG_BRANCH") == "" {
 			defaultBranch := p.DefaultBranch
@@ -568,21 +820,7 @@ func (c Gitlab) filter(group string, ps []*gitlab.Project) []Repo {
 			r.CloneBranch = os.Getenv("GHORG_BRANCH")
 		}
 
-		path := p.PathWithNamespace
-
-		// The PathWithNamespace includes the org/group name
-		// https://github.com/gabrie30/ghorg/issues/228
-		// https://github.com/gabrie30/ghorg/issues/267
-		// https://github.com/gabrie30/ghorg/issues/271
-		if !gitLabAllGroups && !gitLabAllUsers {
-			if isSubgroup {
-				if os.Getenv("GHORG_OUTPUT_DIR") == "" {
-					path = strings.TrimPrefix(path, group)
-				}
-			} else {
-				path = strings.TrimPrefix(path, group)
-			}
-		}
+		path := gitlabLocalPath(group, p.PathWithNamespace)
 
 		r.Path = path
 		r.ID = fmt.Sprint(p.ID)
@@ -596,21 +834,50 @@ func (c Gitlab) filter(group string, ps []*gitlab.Project) []Repo {
 			repoData = append(repoData, r)
 		}
 
-		if p.WikiAccessLevel != gitlab.DisabledAccessControl && os.Getenv("GHORG_CLONE_WIKI") == "true" {
-			wiki := Repo{}
-			// wiki needs name for gitlab name collisions
-			wiki.Name = p.Name
-			wiki.IsWiki = true
-			wiki.CloneURL = strings.Replace(r.CloneURL, ".git", ".wiki.git", 1)
-			wiki.URL = strings.Replace(r.URL, ".git", ".wiki.git", 1)
-			wiki.CloneBranch = "master"
-			wiki.Path = fmt.Sprintf("%s%s", path, ".wiki")
-			repoData = append(repoData, wiki)
+		if p.WikiAccessLevel != gitlab.DisabledAccessControl && shouldCloneWikis() {
+			repoData = append(repoData, newWiki(r, path+".wiki", "master"))
 		}
 	}
 	return repoData
 }
 
+// projectLanguages returns the percentage of each language used in a project
+func (c Gitlab) projectLanguages(pid int64) (map[string]float64, error) {
+	languages, _, err := c.Projects.GetProjectLanguages(pid)
+	if err != nil {
+		return nil, err
+	}
+
+	percentages := make(map[string]float64)
+	if languages != nil {
+		for lang, pct := range *languages {
+			percentages[lang] = float64(pct)
+		}
+	}
+	return percentages, nil
+}
+
+// gitlabLocalPath returns where a project or group is placed relative to the clone directory
+func gitlabLocalPath(group string, pathWithNamespace string) string {
+	path := pathWithNamespace
+
+	// The PathWithNamespace includes the org/group name
+	// https://github.com/gabrie30/ghorg/issues/228
+	// https://github.com/gabrie30/ghorg/issues/267
+	// https://github.com/gabrie30/ghorg/issues/271
+	if !gitLabAllGroups && !gitLabAllUsers {
+		if strings.Contains(group, "/") {
+			if os.Getenv("GHORG_OUTPUT_DIR") == "" {
+				path = strings.TrimPrefix(path, group)
+			}
+		} else {
+			path = strings.TrimPrefix(path, group)
+		}
+	}
+
+	return path
+}
+
 func filterGitlabGroupByExcludeMatchRegex(groups []string) []string {
 	filteredGroups := []string{}
 	regex := fmt.Sprint(os.Getenv("GHORG_GITLAB_GROUP_EXCLUDE_MATCH_REGEX"))
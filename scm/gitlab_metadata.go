@@ -0,0 +1,181 @@
+package scm
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+var _ MetadataExporter = Gitlab{}
+
+// gitlabIssueExport is the file written for each issue
+type gitlabIssueExport struct {
+	Issue *gitlab.Issue  `json:"issue"`
+	Notes []*gitlab.Note `json:"notes"`
+}
+
+// gitlabMergeRequestExport is the file written for each merge request
+type gitlabMergeRequestExport struct {
+	MergeRequest *gitlab.BasicMergeRequest `json:"merge_request"`
+	Notes        []*gitlab.Note            `json:"notes"`
+}
+
+// gitlabListAll follows the pagination of a gitlab list call until the last page
+func gitlabListAll[T any](list func(opt gitlab.ListOptions) ([]T, *gitlab.Response, error)) ([]T, error) {
+	opt := gitlab.ListOptions{PerPage: int64(perPage), Page: 1}
+	var all []T
+
+	for {
+		items, resp, err := list(opt)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, items...)
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+
+	return all, nil
+}
+
+// download opens the file a release asset link points to. Links can point anywhere, so the token is
+// only sent to the gitlab instance and is dropped when a download redirects to another host.
+func (c Gitlab) download(downloadURL string) (io.ReadCloser, error) {
+	u, err := url.Parse(downloadURL)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("GET", downloadURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	hc := http.DefaultClient
+	if u.Host == c.BaseURL().Host {
+		instanceClient := *c.HTTPClient()
+		instanceClient.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return errors.New("stopped after 10 redirects")
+			}
+			if req.URL.Host != u.Host {
+				req.Header.Del("PRIVATE-TOKEN")
+			}
+			return nil
+		}
+		hc = &instanceClient
+		if token := os.Getenv("GHORG_GITLAB_TOKEN"); token != "" {
+			req.Header.Set("PRIVATE-TOKEN", token)
+		}
+	}
+
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("unexpected response code %d downloading %s", resp.StatusCode, downloadURL)
+	}
+	return resp.Body, nil
+}
+
+// ExportMetadata exports the issues, merge requests, releases, labels and milestones of a project.
+// Release assets on gitlab are links, the files they point to are downloaded. The source archives
+// gitlab generates for every release are left out since they can be recreated from the clone.
+func (c Gitlab) ExportMetadata(repo Repo, dir string, since time.Time) error {
+	pid := repo.ID
+	var updatedAfter *time.Time
+	if !since.IsZero() {
+		updatedAfter = &since
+	}
+
+	issues, err := gitlabListAll(func(opt gitlab.ListOptions) ([]*gitlab.Issue, *gitlab.Response, error) {
+		return c.Issues.ListProjectIssues(pid, &gitlab.ListProjectIssuesOptions{ListOptions: opt, UpdatedAfter: updatedAfter})
+	})
+	if err != nil {
+		return fmt.Errorf("exporting issues: %w", err)
+	}
+	for _, issue := range issues {
+		notes, err := gitlabListAll(func(opt gitlab.ListOptions) ([]*gitlab.Note, *gitlab.Response, error) {
+			return c.Notes.ListIssueNotes(pid, issue.IID, &gitlab.ListIssueNotesOptions{ListOptions: opt})
+		})
+		if err != nil {
+			return fmt.Errorf("exporting issue notes: %w", err)
+		}
+		if err := writeMetadataFile(metadataItemPath(dir, metadataIssuesDir, issue.IID), gitlabIssueExport{Issue: issue, Notes: notes}); err != nil {
+			return err
+		}
+	}
+
+	mrs, err := gitlabListAll(func(opt gitlab.ListOptions) ([]*gitlab.BasicMergeRequest, *gitlab.Response, error) {
+		return c.MergeRequests.ListProjectMergeRequests(pid, &gitlab.ListProjectMergeRequestsOptions{ListOptions: opt, UpdatedAfter: updatedAfter})
+	})
+	if err != nil {
+		return fmt.Errorf("exporting merge requests: %w", err)
+	}
+	for _, mr := range mrs {
+		notes, err := gitlabListAll(func(opt gitlab.ListOptions) ([]*gitlab.Note, *gitlab.Response, error) {
+			return c.Notes.ListMergeRequestNotes(pid, mr.IID, &gitlab.ListMergeRequestNotesOptions{ListOptions: opt})
+		})
+		if err != nil {
+			return fmt.Errorf("exporting merge request notes: %w", err)
+		}
+		if err := writeMetadataFile(metadataItemPath(dir, metadataPullRequestsDir, mr.IID), gitlabMergeRequestExport{MergeRequest: mr, Notes: notes}); err != nil {
+			return err
+		}
+	}
+
+	releases, err := gitlabListAll(func(opt gitlab.ListOptions) ([]*gitlab.Release, *gitlab.Response, error) {
+		return c.Releases.ListReleases(pid, &gitlab.ListReleasesOptions{ListOptions: opt})
+	})
+	if err != nil {
+		return fmt.Errorf("exporting releases: %w", err)
+	}
+	for _, release := range releases {
+		// gitlab releases are identified by their tag
+		if err := writeMetadataFile(metadataItemPath(dir, metadataReleasesDir, release.TagName), release); err != nil {
+			return err
+		}
+
+		for _, link := range release.Assets.Links {
+			downloadURL := link.DirectAssetURL
+			if downloadURL == "" {
+				downloadURL = link.URL
+			}
+			// links don't report the size of the file they point to
+			err := saveMetadataAsset(metadataAssetPath(dir, release.TagName, link.Name), -1, func() (io.ReadCloser, error) {
+				return c.download(downloadURL)
+			})
+			if err != nil {
+				return fmt.Errorf("downloading asset %s: %w", link.Name, err)
+			}
+		}
+	}
+
+	labels, err := gitlabListAll(func(opt gitlab.ListOptions) ([]*gitlab.Label, *gitlab.Response, error) {
+		return c.Labels.ListLabels(pid, &gitlab.ListLabelsOptions{ListOptions: opt})
+	})
+	if err != nil {
+		return fmt.Errorf("exporting labels: %w", err)
+	}
+	if err := writeMetadataFile(filepath.Join(dir, metadataLabelsFile), labels); err != nil {
+		return err
+	}
+
+	milestones, err := gitlabListAll(func(opt gitlab.ListOptions) ([]*gitlab.Milestone, *gitlab.Response, error) {
+		return c.Milestones.ListMilestones(pid, &gitlab.ListMilestonesOptions{ListOptions: opt})
+	})
+	if err != nil {
+		return fmt.Errorf("exporting milestones: %w", err)
+	}
+	return writeMetadataFile(filepath.Join(dir, metadataMilestonesFile), milestones)
+}
@@ -2,21 +2,18 @@ package scm
 
 import (
 	"os"
-	"strconv"
 	"sync"
 
 	gitlab "gitlab.com/gitlab-org/api/client-go"
 )
 
 // fetchTopLevelGroupsParallel fetches remaining pages of top-level groups concurrently
-func (c Gitlab) fetchTopLevelGroupsParallel(firstPageGroups []*gitlab.Group, totalPages int) ([]string, error) {
-	// Create slice to hold all group IDs
-	allGroups := make([]string, 0, len(firstPageGroups)*totalPages)
+func (c Gitlab) fetchTopLevelGroupsParallel(firstPageGroups []*gitlab.Group, totalPages int, listOptions func(page int) *gitlab.ListGroupsOptions) ([]*gitlab.Group, error) {
+	// Create slice to hold all groups
+	allGroups := make([]*gitlab.Group, 0, len(firstPageGroups)*totalPages)
 
 	// Add first page groups
-	for _, g := range firstPageGroups {
-		allGroups = append(allGroups, strconv.FormatInt(int64(g.ID), 10))
-	}
+	allGroups = append(allGroups, firstPageGroups...)
 
 	// Channel to collect results from parallel fetches
 	type pageResult struct {
@@ -35,16 +32,7 @@ func (c Gitlab) fetchTopLevelGroupsParallel(firstPageGroups []*gitlab.Group, tot
 		go func(pageNum int) {
 			defer wg.Done()
 
-			opt := &gitlab.ListGroupsOptions{
-				ListOptions: gitlab.ListOptions{
-					PerPage: int64(perPage),
-					Page:    int64(pageNum),
-				},
-				TopLevelOnly: &[]bool{true}[0],
-				AllAvailable: &[]bool{true}[0],
-			}
-
-			groups, _, err := c.Groups.ListGroups(opt)
+			groups, _, err := c.Groups.ListGroups(listOptions(pageNum))
 			resultChan <- pageResult{groups: groups, err: err, page: pageNum}
 		}(page)
 	}
@@ -67,9 +55,7 @@ func (c Gitlab) fetchTopLevelGroupsParallel(firstPageGroups []*gitlab.Group, tot
 	// Append results in page order to maintain consistency
 	for page := 2; page <= totalPages; page++ {
 		if groups, ok := pageResults[page]; ok {
-			for _, g := range groups {
-				allGroups = append(allGroups, strconv.FormatInt(int64(g.ID), 10))
-			}
+			allGroups = append(allGroups, groups...)
 		}
 	}
 
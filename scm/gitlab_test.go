@@ -1,8 +1,16 @@
 package scm
 
 import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
 )
 
 func TestFilterGitlabGroupByMatchRegex(t *testing.T) {
@@ -202,3 +210,227 @@ func TestFilterGitlabGroupMatchRegexWithAlternation(t *testing.T) {
 		}
 	})
 }
+
+func TestGitlabGetGroupWikis(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client, err := gitlab.NewClient("token", gitlab.WithBaseURL(server.URL+"/api/v4"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := Gitlab{client}
+
+	mux.HandleFunc("/api/v4/groups/mygroup", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `{"id": 1, "path": "mygroup", "full_path": "mygroup", "wiki_access_level": "enabled"}`)
+	})
+	mux.HandleFunc("/api/v4/groups/mygroup/descendant_groups", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `[
+			{"id": 2, "path": "sub", "full_path": "mygroup/sub", "wiki_access_level": "private"},
+			{"id": 3, "path": "nowiki", "full_path": "mygroup/nowiki", "wiki_access_level": "disabled"},
+			{"id": 4, "path": "free", "full_path": "mygroup/free"}
+		]`)
+	})
+
+	t.Run("lists enabled group wikis over https", func(t *testing.T) {
+		t.Setenv("GHORG_CLONE_PROTOCOL", "https")
+		t.Setenv("GHORG_GITLAB_TOKEN", "token")
+
+		wikis, err := c.GetGroupWikis("mygroup")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(wikis) != 2 {
+			t.Fatalf("Expected 2 wikis, got %d: %+v", len(wikis), wikis)
+		}
+
+		want := []Repo{
+			{Name: "mygroup", Path: "mygroup.wiki", URL: server.URL + "/mygroup.wiki.git", CloneBranch: "master", IsWiki: true},
+			{Name: "sub", Path: "/sub.wiki", URL: server.URL + "/mygroup/sub.wiki.git", CloneBranch: "master", IsWiki: true},
+		}
+		for i, w := range want {
+			got := wikis[i]
+			if got.Name != w.Name || got.Path != w.Path || got.URL != w.URL || got.CloneBranch != w.CloneBranch || !got.IsWiki {
+				t.Errorf("wiki %d = %+v, want %+v", i, got, w)
+			}
+			if !strings.Contains(got.CloneURL, "oauth2:token@") {
+				t.Errorf("Expected clone url with token, got %s", got.CloneURL)
+			}
+		}
+	})
+
+	t.Run("uses ssh urls", func(t *testing.T) {
+		t.Setenv("GHORG_CLONE_PROTOCOL", "ssh")
+
+		wikis, err := c.GetGroupWikis("mygroup")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "git@127.0.0.1:mygroup/sub.wiki.git"; wikis[1].CloneURL != want {
+			t.Errorf("Expected clone url %s, got %s", want, wikis[1].CloneURL)
+		}
+	})
+}
+
+func TestGitlabFilterLanguage(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client, err := gitlab.NewClient("token", gitlab.WithBaseURL(server.URL+"/api/v4"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := Gitlab{client}
+
+	mux.HandleFunc("/api/v4/projects/1/languages", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `{"Go": 80.5, "Shell": 19.5}`)
+	})
+	mux.HandleFunc("/api/v4/projects/2/languages", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `{"Ruby": 100}`)
+	})
+
+	projects := []*gitlab.Project{
+		{ID: 1, Name: "api", PathWithNamespace: "group/api", HTTPURLToRepo: server.URL + "/group/api.git"},
+		{ID: 2, Name: "web", PathWithNamespace: "group/web", HTTPURLToRepo: server.URL + "/group/web.git"},
+	}
+
+	t.Setenv("GHORG_CLONE_PROTOCOL", "https")
+	t.Setenv("GHORG_FILTER_LANGUAGE", "go")
+
+	repos := c.filter("group", projects)
+	if len(repos) != 1 || repos[0].Name != "api" || repos[0].Language != "Go" {
+		t.Fatalf("Expected only the go project, got %+v", repos)
+	}
+
+	t.Setenv("GHORG_FILTER_LANGUAGE", "shell")
+	t.Setenv("GHORG_FILTER_LANGUAGE_THRESHOLD", "10")
+
+	repos = c.filter("group", projects)
+	if len(repos) != 1 || repos[0].Name != "api" {
+		t.Fatalf("Expected the project with enough shell, got %+v", repos)
+	}
+}
+
+func TestGitlabFilterExpressionFetchesLanguage(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client, err := gitlab.NewClient("token", gitlab.WithBaseURL(server.URL+"/api/v4"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := Gitlab{client}
+
+	mux.HandleFunc("/api/v4/projects/1/languages", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `{"Go": 80.5, "Shell": 19.5}`)
+	})
+	mux.HandleFunc("/api/v4/projects/2/languages", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `{"Ruby": 100}`)
+	})
+
+	projects := []*gitlab.Project{
+		{ID: 1, Name: "api", PathWithNamespace: "group/api", HTTPURLToRepo: server.URL + "/group/api.git"},
+		{ID: 2, Name: "web", PathWithNamespace: "group/web", HTTPURLToRepo: server.URL + "/group/web.git"},
+	}
+
+	t.Setenv("GHORG_CLONE_PROTOCOL", "https")
+	t.Setenv("GHORG_FILTER", `language == "go"`)
+
+	// --filter is applied after listing so every project is kept, but with its language set
+	repos := c.filter("group", projects)
+	if len(repos) != 2 || repos[0].Language != "Go" || repos[1].Language != "Ruby" {
+		t.Fatalf("Expected the language of every project to be set, got %+v", repos)
+	}
+}
+
+func TestGitlabFilterFetchesGroupProjectSizes(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client, err := gitlab.NewClient("token", gitlab.WithBaseURL(server.URL+"/api/v4"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := Gitlab{client}
+
+	mux.HandleFunc("/api/v4/projects/1", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("statistics") != "true" {
+			t.Errorf("Expected statistics to be requested, got %s", r.URL.RawQuery)
+		}
+		_, _ = fmt.Fprint(w, `{"id": 1, "statistics": {"repository_size": 2097152}}`)
+	})
+
+	// group listings don't return statistics
+	projects := []*gitlab.Project{
+		{ID: 1, Name: "api", PathWithNamespace: "group/api", HTTPURLToRepo: server.URL + "/group/api.git"},
+	}
+
+	t.Setenv("GHORG_CLONE_PROTOCOL", "https")
+	t.Setenv("GHORG_MAX_REPO_SIZE", "1MB")
+
+	repos := c.filter("group", projects)
+	if len(repos) != 1 || repos[0].SizeKB != 2048 {
+		t.Fatalf("Expected the size of the project to be fetched, got %+v", repos)
+	}
+}
+
+func TestGitlabExportMetadata_DownloadsReleaseAssets(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	external := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PRIVATE-TOKEN") != "" {
+			t.Errorf("Expected the token not to be sent to other hosts")
+		}
+		_, _ = fmt.Fprint(w, "checksums")
+	}))
+	defer external.Close()
+
+	client, err := gitlab.NewClient("secret", gitlab.WithBaseURL(server.URL+"/api/v4"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := Gitlab{client}
+	t.Setenv("GHORG_GITLAB_TOKEN", "secret")
+
+	downloads := 0
+	for _, path := range []string{"issues", "merge_requests", "labels", "milestones"} {
+		mux.HandleFunc("/api/v4/projects/5/"+path, func(w http.ResponseWriter, r *http.Request) {
+			_, _ = fmt.Fprint(w, `[]`)
+		})
+	}
+	mux.HandleFunc("/api/v4/projects/5/releases", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, `[{"tag_name": "v1.0.0", "assets": {"links": [
+			{"name": "app.tar.gz", "url": "%[1]s/uploads/app", "direct_asset_url": "%[1]s/group/app/-/releases/v1.0.0/downloads/app.tar.gz"},
+			{"name": "checksums.txt", "url": "%[2]s/checksums.txt", "external": true}
+		]}}]`, server.URL, external.URL)
+	})
+	mux.HandleFunc("/group/app/-/releases/v1.0.0/downloads/app.tar.gz", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PRIVATE-TOKEN") != "secret" {
+			t.Errorf("Expected asset download to be authenticated")
+		}
+		downloads++
+		_, _ = fmt.Fprint(w, "archive")
+	})
+
+	dir := t.TempDir()
+	for i := 0; i < 2; i++ {
+		if err := c.ExportMetadata(Repo{ID: "5"}, dir, time.Time{}); err != nil {
+			t.Fatalf("ExportMetadata failed: %v", err)
+		}
+	}
+
+	if downloads != 1 {
+		t.Errorf("Expected the asset to be downloaded once, got %d downloads", downloads)
+	}
+	for _, p := range []string{"releases/v1.0.0.json", "releases/v1.0.0/assets/app.tar.gz", "releases/v1.0.0/assets/checksums.txt"} {
+		if _, err := os.Stat(filepath.Join(dir, p)); err != nil {
+			t.Errorf("Expected %s to be exported: %v", p, err)
+		}
+	}
+}
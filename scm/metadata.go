@@ -0,0 +1,92 @@
+package scm
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Layout of a metadata export directory, shared by every MetadataExporter
+const (
+	metadataIssuesDir        = "issues"
+	metadataPullRequestsDir  = "pulls"
+	metadataReleasesDir      = "releases"
+	metadataLabelsFile       = "labels.json"
+	metadataMilestonesFile   = "milestones.json"
+	metadataReleaseAssetsDir = "assets"
+)
+
+// writeMetadataFile writes v as indented JSON to path, creating any missing parent directories
+func writeMetadataFile(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0o644)
+}
+
+// metadataFileName turns an id or name from the scm into a single safe path element
+func metadataFileName(id any) string {
+	name := strings.NewReplacer("/", "_", "\\", "_").Replace(fmt.Sprint(id))
+	if name == "." || name == ".." {
+		name = "_"
+	}
+	return name
+}
+
+// metadataItemPath returns the path an issue, pull request or release is exported to
+func metadataItemPath(dir string, kind string, id any) string {
+	return filepath.Join(dir, kind, metadataFileName(id)+".json")
+}
+
+// metadataAssetPath returns the path a release asset is downloaded to
+func metadataAssetPath(dir string, releaseID any, name string) string {
+	return filepath.Join(dir, metadataReleasesDir, metadataFileName(releaseID), metadataReleaseAssetsDir, metadataFileName(name))
+}
+
+// saveMetadataAsset downloads a release asset to path. Assets never change once
+// uploaded so the download is skipped when a file of the same size already exists,
+// or any file when the size isn't known, which is passed as -1.
+func saveMetadataAsset(path string, size int64, open func() (io.ReadCloser, error)) error {
+	if info, err := os.Stat(path); err == nil && (size < 0 || info.Size() == size) {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	rc, err := open()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rc.Close() }()
+
+	// Write to a temp file first so an interrupted download is retried on the next run
+	tmp := path + ".part"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(f, rc); err != nil {
+		_ = f.Close()
+		_ = os.Remove(tmp)
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,57 @@
+package scm
+
+import (
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// SnippetsDirName is the folder inside the clone directory that snippets which don't
+// belong to a repo are cloned into
+const SnippetsDirName = "_ghorg_snippets"
+
+// snippetFolderName returns the folder name to use for a gist or snippet, derived from
+// its primary filename (first filename alphabetically). The extension is stripped and
+// the result is lowercased. Falls back to the id when there are no files or the derived
+// base name is empty.
+func snippetFolderName(id string, filenames []string) string {
+	if len(filenames) == 0 {
+		return strings.ToLower(id)
+	}
+
+	// Sort a copy for a stable, deterministic result.
+	sorted := append([]string(nil), filenames...)
+	sort.Strings(sorted)
+
+	firstName := sorted[0]
+	ext := filepath.Ext(firstName)
+	base := strings.ToLower(firstName[:len(firstName)-len(ext)])
+	if base == "" {
+		return strings.ToLower(id)
+	}
+	return base
+}
+
+// uniqueSnippetFolderNames appends the id to every folder name shared by two or more
+// snippets, ids and names are matched by index
+func uniqueSnippetFolderNames(ids []string, names []string) []string {
+	nameCount := make(map[string]int, len(names))
+	for _, name := range names {
+		nameCount[name]++
+	}
+
+	unique := make([]string, len(names))
+	for i, name := range names {
+		if nameCount[name] > 1 {
+			name = name + "-" + ids[i]
+		}
+		unique[i] = name
+	}
+	return unique
+}
+
+// snippetPath returns the path of a snippet that doesn't belong to a repo relative to the clone directory
+func snippetPath(folderName string) string {
+	return path.Join(SnippetsDirName, folderName)
+}
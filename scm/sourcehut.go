@@ -10,7 +10,9 @@ import (
 	"net/url"
 	"os"
 	"path"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gabrie30/ghorg/colorlog"
 )
@@ -26,6 +28,15 @@ type Sourcehut struct {
 	Client  *http.Client
 	Token   string
 	BaseURL string
+	// HgBaseURL is the hg.sr.ht instance mercurial repos are listed from, they are skipped when empty
+	HgBaseURL string
+}
+
+// sourcehutService is a sourcehut forge that hosts repos of one version control system
+type sourcehutService struct {
+	baseURL string
+	query   string
+	vcs     string
 }
 
 type sourcehutCursor string
@@ -64,14 +75,39 @@ func (c Sourcehut) GetUserRepos(targetUsername string) ([]Repo, error) {
 
 	repos := []Repo{}
 
+	warnLanguageFilterUnsupported("sourcehut")
+
 	// Normalize username for API (ensure ~ prefix)
 	apiUsername := normalizeUsername(targetUsername)
 	// Strip prefix for local paths
 	localUsername := stripUsernamePrefix(targetUsername)
 
+	gitRepos, err := c.listRepositories(sourcehutService{baseURL: c.BaseURL, query: sourcehutReposQuery, vcs: VCSGit}, apiUsername, localUsername)
+	if err != nil {
+		return nil, err
+	}
+	repos = append(repos, gitRepos...)
+
+	if c.HgBaseURL != "" {
+		hgRepos, err := c.listRepositories(sourcehutService{baseURL: c.HgBaseURL, query: sourcehutHgReposQuery, vcs: VCSHg}, apiUsername, localUsername)
+		if err != nil {
+			// tokens are scoped per service, a token without hg.sr.ht access should not stop git repos from cloning
+			colorlog.PrintError(fmt.Sprintf("Could not list mercurial repos from %s, only git repos will be cloned: %v", c.HgBaseURL, err))
+		} else {
+			repos = append(repos, hgRepos...)
+		}
+	}
+
+	return repos, nil
+}
+
+// listRepositories pages through every repo of a user on one sourcehut service
+func (c Sourcehut) listRepositories(service sourcehutService, apiUsername string, localUsername string) ([]Repo, error) {
+	repos := []Repo{}
+
 	var cursor sourcehutCursor
 	for {
-		reposPage, nextCursor, err := c.queryRepositoriesPage(cursor, apiUsername, localUsername)
+		reposPage, nextCursor, err := c.queryRepositoriesPage(service, cursor, apiUsername, localUsername)
 		if err != nil {
 			return nil, err
 		}
@@ -85,6 +121,26 @@ func (c Sourcehut) GetUserRepos(targetUsername string) ([]Repo, error) {
 	return repos, nil
 }
 
+// sourcehutHgBaseURL returns the hg.sr.ht instance that pairs with a git.sr.ht instance. Listing mercurial repos
+// is opt-in with GHORG_SOURCEHUT_HG because tokens are scoped per service, setting GHORG_SOURCEHUT_HG_BASE_URL
+// opts in as well for instances that are not named that way
+func sourcehutHgBaseURL(baseURL string) string {
+	if hgBaseURL := os.Getenv("GHORG_SOURCEHUT_HG_BASE_URL"); hgBaseURL != "" {
+		return strings.TrimSuffix(hgBaseURL, "/")
+	}
+
+	if os.Getenv("GHORG_SOURCEHUT_HG") != "true" {
+		return ""
+	}
+
+	u, err := url.Parse(baseURL)
+	if err != nil || !strings.HasPrefix(u.Host, "git.") {
+		return ""
+	}
+	u.Host = "hg." + strings.TrimPrefix(u.Host, "git.")
+	return strings.TrimSuffix(u.String(), "/")
+}
+
 // NewClient create new sourcehut scm client
 func (Sourcehut) NewClient() (Client, error) {
 	baseURL := os.Getenv("GHORG_SCM_BASE_URL")
@@ -120,9 +176,14 @@ func (Sourcehut) NewClient() (Client, error) {
 	}
 
 	client := Sourcehut{
-		BaseURL: baseURL,
-		Client:  hc,
-		Token:   token,
+		BaseURL:   baseURL,
+		HgBaseURL: sourcehutHgBaseURL(baseURL),
+		Client:    hc,
+		Token:     token,
+	}
+
+	if os.Getenv("GHORG_SOURCEHUT_HG") == "true" && client.HgBaseURL == "" {
+		colorlog.PrintError(fmt.Sprintf("Could not determine the hg.sr.ht instance of %s, set --sourcehut-hg-base-url to clone mercurial repos", baseURL))
 	}
 
 	return client, nil
@@ -135,6 +196,7 @@ query repositories($cursor: Cursor, $filter: Filter) {
       id
       name
       visibility
+      updated
       owner { canonicalName }
       HEAD { name }
     }
@@ -143,8 +205,24 @@ query repositories($cursor: Cursor, $filter: Filter) {
 }
 `
 
-func (c Sourcehut) queryRepositoriesPage(cursor sourcehutCursor, apiUsername string, localUsername string) ([]Repo, sourcehutCursor, error) {
-	u, err := url.Parse(c.BaseURL)
+// sourcehutHgReposQuery is sourcehutReposQuery for hg.sr.ht, which has no HEAD
+var sourcehutHgReposQuery = `
+query repositories($cursor: Cursor, $filter: Filter) {
+  repositories(cursor: $cursor, filter: $filter) {
+    results {
+      id
+      name
+      visibility
+      updated
+      owner { canonicalName }
+    }
+    cursor
+  }
+}
+`
+
+func (c Sourcehut) queryRepositoriesPage(service sourcehutService, cursor sourcehutCursor, apiUsername string, localUsername string) ([]Repo, sourcehutCursor, error) {
+	u, err := url.Parse(service.baseURL)
 	if err != nil {
 		return nil, "", err
 	}
@@ -156,7 +234,7 @@ func (c Sourcehut) queryRepositoriesPage(cursor sourcehutCursor, apiUsername str
 	}
 
 	inputBody, err := json.Marshal(map[string]any{
-		"query":     sourcehutReposQuery,
+		"query":     service.query,
 		"variables": vars,
 	})
 	if err != nil {
@@ -203,7 +281,7 @@ func (c Sourcehut) queryRepositoriesPage(cursor sourcehutCursor, apiUsername str
 		return nil, "", fmt.Errorf("sourcehut api returned errors while listing repos: %s", string(response.Errors))
 	}
 
-	repos, err := c.filter(response.Data.Repositories.Results, apiUsername, localUsername)
+	repos, err := c.filter(service, response.Data.Repositories.Results, apiUsername, localUsername)
 	if err != nil {
 		return nil, "", err
 	}
@@ -211,7 +289,7 @@ func (c Sourcehut) queryRepositoriesPage(cursor sourcehutCursor, apiUsername str
 	return repos, sourcehutCursor(response.Data.Repositories.Cursor), nil
 }
 
-func (c Sourcehut) filter(rps []repository, apiUsername string, localUsername string) ([]Repo, error) {
+func (c Sourcehut) filter(service sourcehutService, rps []repository, apiUsername string, localUsername string) ([]Repo, error) {
 	var repos []Repo
 
 	for _, rp := range rps {
@@ -225,9 +303,17 @@ func (c Sourcehut) filter(rps []repository, apiUsername string, localUsername st
 		// If these fields become available, add filtering here like other SCM providers
 
 		r := Repo{}
+		r.ID = strconv.FormatInt(rp.ID, 10)
 		// Use localUsername (without ~) for local paths to avoid shell expansion issues
 		r.Path = path.Join(localUsername, rp.Name)
+		if service.vcs == VCSHg {
+			// a git and a mercurial repo can share a name, so they're cloned into different directories
+			r.Path += ".hg"
+		}
 		r.Name = rp.Name
+		r.VCS = service.vcs
+		r.LastActivityAt = rp.Updated
+		r.Visibility = strings.ToLower(rp.Visibility)
 
 		// Build the repo path WITH ~ for clone URLs (git needs this)
 		repoPathWithTilde := path.Join(rp.Owner.CanonicalName, rp.Name)
@@ -237,6 +323,10 @@ func (c Sourcehut) filter(rps []repository, apiUsername string, localUsername st
 			if strings.HasPrefix(rp.HEAD.Name, "refs/heads/") {
 				defaultBranch = rp.HEAD.Name[len("refs/heads/"):]
 			}
+			if defaultBranch == "" && service.vcs == VCSHg {
+				// mercurial names its main branch default
+				defaultBranch = "default"
+			}
 			if defaultBranch == "" {
 				defaultBranch = "master"
 			}
@@ -253,7 +343,11 @@ func (c Sourcehut) filter(rps []repository, apiUsername string, localUsername st
 
 		if protocol == "https" {
 			// Use repoPathWithTilde for clone URL (git needs the ~ prefix)
-			r.CloneURL = fmt.Sprintf("%s/%s", c.BaseURL, repoPathWithTilde)
+			r.CloneURL = fmt.Sprintf("%s/%s", service.baseURL, repoPathWithTilde)
+		} else if service.vcs == VCSHg {
+			// hg has no scp-like syntax so ssh clones need a full url
+			host := strings.TrimPrefix(strings.TrimPrefix(service.baseURL, "http://"), "https://")
+			r.CloneURL = fmt.Sprintf("ssh://hg@%s/%s", host, repoPathWithTilde)
 		} else {
 			// SSH protocol
 			var gitBase string
@@ -282,9 +376,10 @@ type repository struct {
 		ID            string `json:"id"`
 		CanonicalName string `json:"canonicalName"`
 	} `json:"owner"`
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	Visibility  string `json:"visibility"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Visibility  string    `json:"visibility"`
+	Updated     time.Time `json:"updated"`
 	HEAD        struct {
 		Name   string `json:"name"`
 		Target string `json:"target"`
@@ -2,10 +2,12 @@ package scm
 
 import (
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"strings"
 	"testing"
 )
 
@@ -416,3 +418,116 @@ func TestSourcehutUsernameHandling(t *testing.T) {
 		}
 	})
 }
+
+func TestSourcehutHgBaseURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		baseURL  string
+		enabled  string
+		override string
+		want     string
+	}{
+		{name: "not listed by default", baseURL: "https://git.sr.ht", want: ""},
+		{name: "derived from git.sr.ht", baseURL: "https://git.sr.ht", enabled: "true", want: "https://hg.sr.ht"},
+		{name: "derived from self hosted git service", baseURL: "https://git.example.com/", enabled: "true", want: "https://hg.example.com"},
+		{name: "not derived from other hosts", baseURL: "https://code.example.com", enabled: "true", want: ""},
+		{name: "override wins", baseURL: "https://git.sr.ht", enabled: "true", override: "https://hg.example.com/", want: "https://hg.example.com"},
+		{name: "override opts in", baseURL: "https://git.sr.ht", override: "https://hg.example.com", want: "https://hg.example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GHORG_SOURCEHUT_HG", tt.enabled)
+			t.Setenv("GHORG_SOURCEHUT_HG_BASE_URL", tt.override)
+			if got := sourcehutHgBaseURL(tt.baseURL); got != tt.want {
+				t.Errorf("sourcehutHgBaseURL(%q) = %q, want %q", tt.baseURL, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSourcehutGetUserRepos_Hg(t *testing.T) {
+	client, mux, _, teardown := setupSourcehut()
+	defer teardown()
+
+	mux.HandleFunc("/query", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `{"data": {"repositories": {"results": [
+			{"id": 1, "name": "git-repo", "owner": {"canonicalName": "~testuser"}, "HEAD": {"name": "refs/heads/main"}}
+		], "cursor": ""}}}`)
+	})
+
+	hgMux := http.NewServeMux()
+	hgServer := httptest.NewServer(hgMux)
+	defer hgServer.Close()
+	client.HgBaseURL = hgServer.URL
+
+	hgMux.HandleFunc("/query", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(body), "HEAD") {
+			t.Errorf("hg.sr.ht has no HEAD field, got query: %s", body)
+		}
+		_, _ = fmt.Fprint(w, `{"data": {"repositories": {"results": [
+			{"id": 7, "name": "legacy", "owner": {"canonicalName": "~testuser"}},
+			{"id": 8, "name": "other", "owner": {"canonicalName": "~someone"}}
+		], "cursor": ""}}}`)
+	})
+
+	t.Run("Should list git and hg repos", func(tt *testing.T) {
+		tt.Setenv("GHORG_CLONE_PROTOCOL", "")
+		repos, err := client.GetUserRepos("testuser")
+		if err != nil {
+			tt.Fatal(err)
+		}
+		if len(repos) != 2 {
+			tt.Fatalf("Expected 2 repos, got %d", len(repos))
+		}
+
+		if repos[0].VCS != VCSGit {
+			tt.Errorf("Expected git repo to have VCS %q, got %q", VCSGit, repos[0].VCS)
+		}
+
+		hg := repos[1]
+		if hg.VCS != VCSHg {
+			tt.Errorf("Expected VCS %q, got %q", VCSHg, hg.VCS)
+		}
+		if hg.CloneBranch != "default" {
+			tt.Errorf("Expected clone branch 'default', got %q", hg.CloneBranch)
+		}
+		if want := hgServer.URL + "/~testuser/legacy"; hg.CloneURL != want {
+			tt.Errorf("Expected clone URL %q, got %q", want, hg.CloneURL)
+		}
+		if hg.Path != "testuser/legacy.hg" {
+			tt.Errorf("Expected path 'testuser/legacy.hg', got %q", hg.Path)
+		}
+	})
+
+	t.Run("Should build hg ssh urls", func(tt *testing.T) {
+		tt.Setenv("GHORG_CLONE_PROTOCOL", "ssh")
+		repos, err := client.GetUserRepos("testuser")
+		if err != nil {
+			tt.Fatal(err)
+		}
+
+		want := "ssh://hg@" + strings.TrimPrefix(hgServer.URL, "http://") + "/~testuser/legacy"
+		if repos[1].CloneURL != want {
+			tt.Errorf("Expected clone URL %q, got %q", want, repos[1].CloneURL)
+		}
+	})
+
+	t.Run("Should still return git repos when hg listing fails", func(tt *testing.T) {
+		unauthorized := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+		defer unauthorized.Close()
+		failing := client
+		failing.HgBaseURL = unauthorized.URL
+
+		repos, err := failing.GetUserRepos("testuser")
+		if err != nil {
+			tt.Fatal(err)
+		}
+		if len(repos) != 1 || repos[0].VCS != VCSGit {
+			tt.Errorf("Expected only the git repo, got %+v", repos)
+		}
+	})
+}
@@ -1,5 +1,14 @@
 package scm
 
+import "time"
+
+const (
+	// VCSGit is the version control system of a Repo unless told otherwise
+	VCSGit = "git"
+	// VCSHg marks a Repo as a mercurial repository
+	VCSHg = "hg"
+)
+
 // Repo represents an SCM repo, should probably be renamed to "cloneable" since we clone wikis and snippets with this
 type Repo struct {
 	// The ID of the repo that is assigned via the SCM provider. This is used for example with gitlab snippets on cloud gropus where we need to know the repo id to look up all he snippets it has.
@@ -8,6 +17,8 @@ type Repo struct {
 	Name string
 	// HostPath is the path on the users machine that the repo will be cloned to. Its used in all the git commands to locate the directory of the repo. HostPath is updated for wikis and snippets because the folder for the clone is appended with .wiki and .snippet
 	HostPath string
+	// FullName is the owner/name of the repo on providers that address repos that way, it's used for api calls made about a single repo
+	FullName string
 	// Path where the repo is located within the scm provider. Its mostly used with gitlab repos when the directory structure is preserved. In this case the path becomes where to locate the repo in relation to gitlab.com/group/group/group/repo.git => /group/group/group/repo
 	Path string
 	// URL is the web address of the repo
@@ -16,6 +27,28 @@ type Repo struct {
 	CloneURL string
 	// CloneBranch the branch to clone. This will be the default branch if not specified. It will always be main for snippets.
 	CloneBranch string
+	// VCS is the version control system used to clone the repo, an empty value means VCSGit
+	VCS string
+	// SparsePaths are the cone mode directories of a sparse checkout, every file is checked out when it's empty. It's set from GHORG_SPARSE_PATHS and GHORG_SPARSE_PATHS_FILE just before the repo is cloned or pulled
+	SparsePaths []string
+	// ReferencePath is the bare repo in the GHORG_REFERENCE_STORE the repo borrows objects from when it's cloned, it's set just before a new clone and empty when the store is not used
+	ReferencePath string
+	// LastActivityAt is when the repo was last pushed to or updated according to the scm provider, it's zero when the provider doesn't report it. Wikis use the value of their repo
+	LastActivityAt time.Time
+	// Language is the primary language of the repo as reported by the scm provider
+	Language string
+	// SizeKB is the size of the repo in kilobytes as reported by the scm provider, it's zero when the provider doesn't report it
+	SizeKB int64
+	// Visibility is public, private or internal as reported by the scm provider (unlisted on sourcehut, secret for gists), it's empty when unknown. Wikis use the visibility of their repo
+	Visibility string
+	// Archived is set to true when the repo is archived on the scm provider
+	Archived bool
+	// Fork is set to true when the repo is a fork of another repo
+	Fork bool
+	// UpstreamPath is the path of the repo a fork was forked from on the same scm, like owner/repo or group/subgroup/repo. It's empty when the repo is not a fork or the scm doesn't report it when listing, github forks are resolved with a ForkSourceResolver when they are cloned
+	UpstreamPath string
+	// Topics are the topics of the repo as reported by the scm provider
+	Topics []string
 	// IsWiki is set to true when the data is for a wiki page
 	IsWiki bool
 	// IsGitLabSnippet is set to true when the data is for a gitlab snippet
@@ -24,6 +57,8 @@ type Repo struct {
 	IsGitLabRootLevelSnippet bool
 	// IsGitHubGist is set to true when the data is for a github gist
 	IsGitHubGist bool
+	// IsSnippet is set to true when the data is for a snippet that doesn't belong to a repo on providers other than gitlab, these are cloned into SnippetsDirName
+	IsSnippet bool
 	// GitLabSnippetInfo provides additional information when the thing we are cloning is a gitlab snippet
 	GitLabSnippetInfo GitLabSnippet
 	Commits           RepoCommits
@@ -35,6 +70,16 @@ type RepoCommits struct {
 	CountDiff     int
 }
 
+// ReviewRequest is a pull request or merge request recorded alongside a repo when fetching review refs
+type ReviewRequest struct {
+	Number       int64  `json:"number"`
+	Title        string `json:"title"`
+	Author       string `json:"author"`
+	State        string `json:"state"`
+	URL          string `json:"url"`
+	SourceBranch string `json:"source_branch"`
+}
+
 type GitLabSnippet struct {
 	// GitLab ID of the snippet
 	ID string
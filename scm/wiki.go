@@ -0,0 +1,37 @@
+package scm
+
+import (
+	"os"
+	"strings"
+)
+
+// shouldCloneWikis reports whether clients should list wikis alongside repos
+func shouldCloneWikis() bool {
+	return os.Getenv("GHORG_CLONE_WIKI") == "true"
+}
+
+// wikiURL returns the git url of the wiki that belongs to a repo url. Every provider ghorg
+// supports serves wikis as a separate repo next to the repo, named <repo>.wiki.git.
+func wikiURL(repoURL string) string {
+	return strings.TrimSuffix(repoURL, ".git") + ".wiki.git"
+}
+
+// newWiki returns the wiki of repo r, cloned to path. Providers only report whether a wiki
+// is enabled, wikis without any pages are skipped when they are cloned.
+func newWiki(r Repo, path string, branch string) Repo {
+	return Repo{
+		ID:             r.ID,
+		Name:           r.Name,
+		Path:           path,
+		URL:            wikiURL(r.URL),
+		CloneURL:       wikiURL(r.CloneURL),
+		CloneBranch:    branch,
+		VCS:            r.VCS,
+		LastActivityAt: r.LastActivityAt,
+		Visibility:     r.Visibility,
+		Archived:       r.Archived,
+		Fork:           r.Fork,
+		Topics:         r.Topics,
+		IsWiki:         true,
+	}
+}
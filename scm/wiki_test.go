@@ -0,0 +1,24 @@
+package scm
+
+import "testing"
+
+func TestWikiURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		repoURL string
+		want    string
+	}{
+		{name: "https", repoURL: "https://github.com/org/repo.git", want: "https://github.com/org/repo.wiki.git"},
+		{name: "ssh", repoURL: "git@github.com:org/repo.git", want: "git@github.com:org/repo.wiki.git"},
+		{name: "host containing .git", repoURL: "https://my.gitlab.example.com/group/repo.git", want: "https://my.gitlab.example.com/group/repo.wiki.git"},
+		{name: "without extension", repoURL: "https://gitea.example.com/org/repo", want: "https://gitea.example.com/org/repo.wiki.git"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := wikiURL(tt.repoURL); got != tt.want {
+				t.Errorf("wikiURL(%q) = %q, want %q", tt.repoURL, got, tt.want)
+			}
+		})
+	}
+}
@@ -123,7 +123,7 @@ fi
 
 rm -f $PWD/scripts/testing_confs/updated_reclone.yaml
 
-COUNT=$(ls /tmp/testing_reclone_with_tag | wc -l)
+COUNT=$(find /tmp/testing_reclone_with_tag -mindepth 1 -maxdepth 1 -type d | wc -l | tr -d " ")
 
 if [ "${COUNT}" -eq 1 ]
 then
@@ -141,7 +141,7 @@ else
     exit 1
 fi
 
-COUNT=$(ls /tmp/all-repos | wc -l)
+COUNT=$(find /tmp/all-repos -mindepth 1 -maxdepth 1 -type d | wc -l | tr -d " ")
 
 if [ "${COUNT}" -ge 3 ]
 then
@@ -173,7 +173,7 @@ else
 fi
 
 # Verify that only matching repos were cloned (should be 1 repo: ghorg-ci-test)
-COUNT=$(ls /tmp/testing_ghorgonly | wc -l)
+COUNT=$(find /tmp/testing_ghorgonly -mindepth 1 -maxdepth 1 -type d | wc -l | tr -d " ")
 
 if [ "${COUNT}" -eq 1 ]
 then
@@ -198,7 +198,7 @@ else
     exit 1
 fi
 
-COUNT=$(ls /tmp/testing_ghorgonly_topic | wc -l)
+COUNT=$(find /tmp/testing_ghorgonly_topic -mindepth 1 -maxdepth 1 -type d | wc -l | tr -d " ")
 
 if [ "${COUNT}" -eq 1 ]
 then
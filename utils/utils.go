@@ -3,6 +3,8 @@ package utils
 import (
 	"os"
 	"path/filepath"
+	"sync"
+	"sync/atomic"
 )
 
 // IsStringInSlice check if a string is in a given slice
@@ -16,19 +18,78 @@ func IsStringInSlice(s string, sl []string) bool {
 }
 
 func CalculateDirSizeInMb(path string) (float64, error) {
-	var size int64
-	err := filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		if !info.IsDir() {
-			size += info.Size()
-		}
-		return nil
+	var size atomic.Int64
+	err := WalkFileSizes(path, func(_ string, fileSize int64) {
+		size.Add(fileSize)
 	})
 	if err != nil {
 		return 0, err
 	}
 	const bytesInMegabyte = 1000 * 1000
-	return float64(size) / bytesInMegabyte, nil // Return size in Megabyte
+	return float64(size.Load()) / bytesInMegabyte, nil // Return size in Megabyte
+}
+
+// walkConcurrency is how many directories WalkFileSizes reads at once
+const walkConcurrency = 16
+
+// WalkFileSizes calls fn with the path and size of every file under root, symlinks are counted by the size of
+// the link and not followed. Directories are read concurrently so fn must be safe to call from several
+// goroutines. The first error stops the walk from descending any further and is returned.
+func WalkFileSizes(root string, fn func(path string, size int64)) error {
+	info, err := os.Lstat(root)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		fn(root, info.Size())
+		return nil
+	}
+
+	sem := make(chan struct{}, walkConcurrency)
+	var wg sync.WaitGroup
+	var errOnce sync.Once
+	var walkErr error
+	var failed atomic.Bool
+
+	fail := func(err error) {
+		errOnce.Do(func() { walkErr = err })
+		failed.Store(true)
+	}
+
+	var walk func(dir string)
+	walk = func(dir string) {
+		defer wg.Done()
+		if failed.Load() {
+			return
+		}
+
+		sem <- struct{}{}
+		entries, err := os.ReadDir(dir)
+		<-sem
+		if err != nil {
+			fail(err)
+			return
+		}
+
+		for _, entry := range entries {
+			path := filepath.Join(dir, entry.Name())
+			if entry.IsDir() {
+				wg.Add(1)
+				go walk(path)
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil {
+				fail(err)
+				return
+			}
+			fn(path, info.Size())
+		}
+	}
+
+	wg.Add(1)
+	walk(root)
+	wg.Wait()
+
+	return walkErr
 }